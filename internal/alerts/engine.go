@@ -0,0 +1,302 @@
+package alerts
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/storage"
+	"bitcoin-price-streamer/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// IndicatorSource provides the indicators computed for a price update, used
+// by volatility_spike rules
+type IndicatorSource interface {
+	At(symbol string, seq uint64) (models.Indicators, bool)
+}
+
+// pricePoint is a past price of a symbol
+type pricePoint struct {
+	timestamp time.Time
+	price     float64
+}
+
+// symbolHistory is the last 24 hours of a symbol's prices, oldest first.
+// start is when the first price was recorded, so the history only covers the
+// whole window once it is at least historyWindow old
+type symbolHistory struct {
+	lastSeq uint64
+	start   time.Time
+	points  []pricePoint
+}
+
+// ruleState is a rule and whether it is armed to fire. A rule starts armed
+// only if its condition does not already hold, so it fires on a transition
+type ruleState struct {
+	rule        models.AlertRule
+	initialized bool
+	armed       bool
+}
+
+// Engine evaluates alert rules against every price update and delivers the
+// resulting alerts to subscribers
+type Engine struct {
+	rules           []*ruleState
+	history         map[string]*symbolHistory
+	recent          []models.Alert
+	recentCapacity  int
+	defaultCooldown time.Duration
+	indicators      IndicatorSource
+	nextID          uint64
+	observers       []func(models.Alert)
+	subscribers     map[*Subscription]bool
+	bufferSize      int
+	mutex           sync.RWMutex
+	logger          *logrus.Logger
+}
+
+// NewEngine creates an alert engine keeping the last ALERT_HISTORY alerts.
+// Rules without a cooldown use ALERT_COOLDOWN_SECONDS. indicators may be nil,
+// in which case volatility_spike rules are rejected
+func NewEngine(indicators IndicatorSource, logger *logrus.Logger) *Engine {
+	return &Engine{
+		history:         make(map[string]*symbolHistory),
+		recentCapacity:  utils.GetEnvInt("ALERT_HISTORY", 100),
+		defaultCooldown: time.Duration(utils.GetEnvInt("ALERT_COOLDOWN_SECONDS", 60)) * time.Second,
+		indicators:      indicators,
+		subscribers:     make(map[*Subscription]bool),
+		bufferSize:      utils.GetEnvInt("CLIENT_BUFFER_SIZE", 50),
+		logger:          logger,
+	}
+}
+
+// AddRule validates and registers a rule, returning it with its assigned ID
+func (e *Engine) AddRule(rule models.AlertRule) (models.AlertRule, error) {
+	if err := e.validate(&rule); err != nil {
+		return models.AlertRule{}, err
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.nextID++
+	rule.ID = "rule-" + strconv.FormatUint(e.nextID, 10)
+	rule.CreatedAt = time.Now()
+	rule.LastTriggered = nil
+	rule.TriggerCount = 0
+	e.rules = append(e.rules, &ruleState{rule: rule})
+
+	e.logger.Infof("Added %s alert rule %s for %s", rule.Type, rule.ID, rule.Symbol)
+	return rule, nil
+}
+
+// Rules returns the registered rules in creation order
+func (e *Engine) Rules() []models.AlertRule {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	rules := make([]models.AlertRule, len(e.rules))
+	for i, state := range e.rules {
+		rules[i] = state.rule
+	}
+	return rules
+}
+
+// Rule returns the rule with the given ID
+func (e *Engine) Rule(id string) (models.AlertRule, bool) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	for _, state := range e.rules {
+		if state.rule.ID == id {
+			return state.rule, true
+		}
+	}
+	return models.AlertRule{}, false
+}
+
+// DeleteRule removes a rule, reporting whether it existed
+func (e *Engine) DeleteRule(id string) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for i, state := range e.rules {
+		if state.rule.ID == id {
+			e.rules = append(e.rules[:i], e.rules[i+1:]...)
+			e.logger.Infof("Deleted alert rule %s", id)
+			return true
+		}
+	}
+	return false
+}
+
+// Recent returns up to limit of the most recent alerts, optionally only for
+// symbol, oldest first
+func (e *Engine) Recent(symbol string, limit int) []models.Alert {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	var alerts []models.Alert
+	for i := len(e.recent) - 1; i >= 0 && (limit <= 0 || len(alerts) < limit); i-- {
+		if symbol == "" || e.recent[i].Symbol == symbol {
+			alerts = append(alerts, e.recent[i])
+		}
+	}
+	for i, j := 0, len(alerts)-1; i < j; i, j = i+1, j-1 {
+		alerts[i], alerts[j] = alerts[j], alerts[i]
+	}
+	return alerts
+}
+
+// Add evaluates every rule of the update's symbol and records the update in
+// the symbol's history. Updates already seen are ignored
+func (e *Engine) Add(update models.PriceUpdate) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	h := e.record(update)
+	if h == nil {
+		return
+	}
+	// Rules compare against the prices before this update
+	history := h.points[:len(h.points)-1]
+
+	var alerts []models.Alert
+	for _, state := range e.rules {
+		if state.rule.Symbol != update.Symbol {
+			continue
+		}
+		if alert, fired := e.evaluate(state, update, history, h.start); fired {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	for _, alert := range alerts {
+		e.logger.Infof("Alert %s: %s", alert.RuleID, alert.Message)
+		e.recent = append(e.recent, alert)
+	}
+	if len(e.recent) > e.recentCapacity {
+		e.recent = e.recent[len(e.recent)-e.recentCapacity:]
+	}
+
+	for _, alert := range alerts {
+		for _, fn := range e.observers {
+			fn(alert)
+		}
+	}
+
+	// Publish under the lock so subscribers see alerts in order
+	e.publish(alerts)
+}
+
+// Observe registers fn to be called with every alert as it fires, before it
+// is published to subscribers. fn must not block or call back into the engine
+func (e *Engine) Observe(fn func(models.Alert)) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.observers = append(e.observers, fn)
+}
+
+// record appends an update to its symbol's history, dropping prices older
+// than the history window, and returns the history or nil for a stale
+// update. Callers must hold the lock
+func (e *Engine) record(update models.PriceUpdate) *symbolHistory {
+	h, exists := e.history[update.Symbol]
+	if !exists {
+		h = &symbolHistory{}
+		e.history[update.Symbol] = h
+	}
+
+	if n := len(h.points); n > 0 && (update.Seq != 0 && update.Seq <= h.lastSeq || update.Timestamp.Before(h.points[n-1].timestamp)) {
+		return nil
+	}
+	h.lastSeq = update.Seq
+	if h.start.IsZero() {
+		h.start = update.Timestamp
+	}
+
+	cutoff := update.Timestamp.Add(-historyWindow)
+	drop := 0
+	for drop < len(h.points) && h.points[drop].timestamp.Before(cutoff) {
+		drop++
+	}
+	h.points = append(h.points[drop:], pricePoint{timestamp: update.Timestamp, price: update.Price})
+	return h
+}
+
+// evaluate advances a rule's armed state with an update, returning an alert
+// if the rule fired outside its cooldown. Callers must hold the lock
+func (e *Engine) evaluate(state *ruleState, update models.PriceUpdate, history []pricePoint, start time.Time) (models.Alert, bool) {
+	obs, ok := e.observe(state.rule, update, history, start)
+	if !ok {
+		return models.Alert{}, false
+	}
+
+	if !state.initialized {
+		state.initialized = true
+		state.armed = !obs.fires
+		return models.Alert{}, false
+	}
+
+	if !state.armed {
+		if obs.rearm && !obs.fires {
+			state.armed = true
+		}
+		return models.Alert{}, false
+	}
+	if !obs.fires {
+		return models.Alert{}, false
+	}
+
+	// The condition was met: disarm until it retreats past the hysteresis
+	// band, but stay quiet while the rule is cooling down
+	state.armed = false
+	rule := &state.rule
+	cooldown := e.defaultCooldown
+	if rule.CooldownSeconds > 0 {
+		cooldown = time.Duration(rule.CooldownSeconds) * time.Second
+	}
+	if rule.LastTriggered != nil && update.Timestamp.Sub(*rule.LastTriggered) < cooldown {
+		e.logger.Debugf("Suppressing alert %s during cooldown", rule.ID)
+		return models.Alert{}, false
+	}
+
+	triggered := update.Timestamp
+	rule.LastTriggered = &triggered
+	rule.TriggerCount++
+
+	return models.Alert{
+		RuleID:    rule.ID,
+		Symbol:    update.Symbol,
+		Type:      rule.Type,
+		Seq:       update.Seq,
+		Timestamp: update.Timestamp,
+		Price:     update.Price,
+		Value:     obs.value,
+		Threshold: rule.Threshold,
+		Message:   describe(*rule, obs.value, update.Price),
+	}, true
+}
+
+// Backfill seeds the price history from the updates already held in store,
+// archived ones included, without evaluating rules. Windows are known on
+// startup, and new_high/new_low rules arm once the history spans 24 hours
+func (e *Engine) Backfill(store storage.Store) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	count := 0
+	for _, symbol := range store.Symbols() {
+		store.Iterate(symbol, func(update models.PriceUpdate) bool {
+			if e.record(update) != nil {
+				count++
+			}
+			return true
+		})
+	}
+	e.logger.Infof("Backfilled alert history from %d stored updates", count)
+}
@@ -0,0 +1,196 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var base = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fakeIndicators serves a fixed volatility for every update
+type fakeIndicators struct {
+	volatility map[uint64]float64
+}
+
+func (f *fakeIndicators) At(symbol string, seq uint64) (models.Indicators, bool) {
+	v, ok := f.volatility[seq]
+	if !ok {
+		return models.Indicators{}, false
+	}
+	return models.Indicators{Symbol: symbol, Seq: seq, Volatility: &v}, true
+}
+
+func newTestEngine(t *testing.T, indicators IndicatorSource) *Engine {
+	t.Setenv("ALERT_COOLDOWN_SECONDS", "1")
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return NewEngine(indicators, logger)
+}
+
+// feed adds prices one minute apart, returning the rule IDs that fired
+func feed(e *Engine, prices ...float64) []string {
+	sub := e.Subscribe()
+	defer e.Unsubscribe(sub)
+
+	start := uint64(1)
+	e.mutex.RLock()
+	if h, ok := e.history["BTC"]; ok {
+		start = h.lastSeq + 1
+	}
+	e.mutex.RUnlock()
+
+	var fired []string
+	for i, price := range prices {
+		seq := start + uint64(i)
+		e.Add(models.PriceUpdate{
+			Seq:       seq,
+			Timestamp: base.Add(time.Duration(seq) * time.Minute),
+			Price:     price,
+			Symbol:    "BTC",
+		})
+		for len(sub.C) > 0 {
+			fired = append(fired, (<-sub.C).RuleID)
+		}
+	}
+	return fired
+}
+
+func TestAddRuleValidation(t *testing.T) {
+	e := newTestEngine(t, nil)
+
+	invalid := []models.AlertRule{
+		{Type: models.AlertPriceCross, Direction: "above", Threshold: 100},
+		{Symbol: "BTC", Type: "moon"},
+		{Symbol: "BTC", Type: models.AlertPriceCross, Direction: "sideways", Threshold: 100},
+		{Symbol: "BTC", Type: models.AlertPriceCross, Direction: "above"},
+		{Symbol: "BTC", Type: models.AlertPriceCross, Direction: "above", Threshold: 100, Hysteresis: 100},
+		{Symbol: "BTC", Type: models.AlertPercentMove, Threshold: 5},
+		{Symbol: "BTC", Type: models.AlertPercentMove, Threshold: 5, WindowSeconds: 2 * 86400},
+		{Symbol: "BTC", Type: models.AlertVolatilitySpike, Threshold: 1},
+	}
+	for _, rule := range invalid {
+		_, err := e.AddRule(rule)
+		assert.Error(t, err, "%+v", rule)
+	}
+
+	rule, err := e.AddRule(models.AlertRule{Symbol: "btc", Type: models.AlertPercentMove, Threshold: 5, WindowSeconds: 60})
+	require.NoError(t, err)
+	assert.Equal(t, "rule-1", rule.ID)
+	assert.Equal(t, "BTC", rule.Symbol)
+	assert.Equal(t, "any", rule.Direction)
+
+	assert.Len(t, e.Rules(), 1)
+	assert.True(t, e.DeleteRule(rule.ID))
+	assert.False(t, e.DeleteRule(rule.ID))
+	assert.Empty(t, e.Rules())
+}
+
+func TestPriceCrossHysteresis(t *testing.T) {
+	e := newTestEngine(t, nil)
+	rule, err := e.AddRule(models.AlertRule{Symbol: "BTC", Type: models.AlertPriceCross, Direction: "above", Threshold: 100, Hysteresis: 5})
+	require.NoError(t, err)
+
+	// Already above on the first update: no crossing
+	assert.Empty(t, feed(e, 101))
+	// Dipping within the hysteresis band does not re-arm
+	assert.Empty(t, feed(e, 97, 102))
+	// Falling through the band re-arms; the next crossing fires once
+	assert.Equal(t, []string{rule.ID}, feed(e, 94, 100, 103, 99, 101))
+
+	stored, _ := e.Rule(rule.ID)
+	assert.Equal(t, 1, stored.TriggerCount)
+	require.Len(t, e.Recent("BTC", 10), 1)
+	assert.Equal(t, 100.0, e.Recent("BTC", 10)[0].Price)
+	assert.Empty(t, e.Recent("ETH", 10))
+}
+
+func TestCooldown(t *testing.T) {
+	e := newTestEngine(t, nil)
+	rule, err := e.AddRule(models.AlertRule{Symbol: "BTC", Type: models.AlertPriceCross, Direction: "below", Threshold: 100, CooldownSeconds: 150})
+	require.NoError(t, err)
+
+	// Updates are a minute apart: the second crossing falls inside the
+	// cooldown, the third after it
+	assert.Equal(t, []string{rule.ID, rule.ID}, feed(e, 101, 99, 101, 99, 101, 101, 99))
+
+	alerts := e.Recent("BTC", 0)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, uint64(2), alerts[0].Seq)
+	assert.Equal(t, uint64(7), alerts[1].Seq)
+}
+
+func TestPercentMove(t *testing.T) {
+	e := newTestEngine(t, nil)
+	up, err := e.AddRule(models.AlertRule{Symbol: "BTC", Type: models.AlertPercentMove, Direction: "up", Threshold: 5, WindowSeconds: 120})
+	require.NoError(t, err)
+	down, err := e.AddRule(models.AlertRule{Symbol: "BTC", Type: models.AlertPercentMove, Direction: "down", Threshold: 5, WindowSeconds: 120})
+	require.NoError(t, err)
+
+	assert.Empty(t, feed(e, 100, 101, 102))
+	// 101 two minutes earlier -> 108 is more than 5%
+	assert.Equal(t, []string{up.ID}, feed(e, 108))
+	assert.Equal(t, []string{down.ID}, feed(e, 108, 100))
+
+	alerts := e.Recent("", 0)
+	require.Len(t, alerts, 2)
+	assert.InDelta(t, (108.0-101)/101*100, alerts[0].Value, 1e-9)
+}
+
+func TestNewHighAndLow(t *testing.T) {
+	e := newTestEngine(t, nil)
+	high, err := e.AddRule(models.AlertRule{Symbol: "BTC", Type: models.AlertNewHigh})
+	require.NoError(t, err)
+	low, err := e.AddRule(models.AlertRule{Symbol: "BTC", Type: models.AlertNewLow})
+	require.NoError(t, err)
+
+	// Nothing fires before the history spans 24 hours
+	assert.Empty(t, feed(e, 100, 105, 95))
+
+	// Two days later the earlier prices have left the window
+	e.Add(models.PriceUpdate{Seq: 2880, Timestamp: base.Add(2880 * time.Minute), Price: 100, Symbol: "BTC"})
+	assert.Empty(t, feed(e, 101, 100))
+	// A rally only fires once until the price pulls back
+	assert.Equal(t, []string{high.ID}, feed(e, 102, 103, 104))
+	assert.Equal(t, []string{high.ID, low.ID}, feed(e, 103, 105, 99))
+}
+
+func TestVolatilitySpike(t *testing.T) {
+	indicators := &fakeIndicators{volatility: map[uint64]float64{1: 0.5, 2: 0.9, 3: 0.75, 4: 0.95, 5: 0.5, 6: 1.2}}
+	e := newTestEngine(t, indicators)
+	rule, err := e.AddRule(models.AlertRule{Symbol: "BTC", Type: models.AlertVolatilitySpike, Threshold: 0.8, Hysteresis: 0.1})
+	require.NoError(t, err)
+
+	// 0.75 is within the hysteresis band, so 0.95 does not fire again
+	assert.Equal(t, []string{rule.ID, rule.ID}, feed(e, 1, 2, 3, 4, 5, 6))
+}
+
+func TestAddIgnoresStaleUpdates(t *testing.T) {
+	e := newTestEngine(t, nil)
+	_, err := e.AddRule(models.AlertRule{Symbol: "BTC", Type: models.AlertPriceCross, Direction: "above", Threshold: 100})
+	require.NoError(t, err)
+
+	feed(e, 99)
+	e.Add(models.PriceUpdate{Seq: 1, Timestamp: base.Add(time.Minute), Price: 150, Symbol: "BTC"})
+	assert.Empty(t, e.Recent("", 0))
+}
+
+func TestObserveReceivesAlerts(t *testing.T) {
+	e := newTestEngine(t, nil)
+	rule, err := e.AddRule(models.AlertRule{Symbol: "BTC", Type: models.AlertPriceCross, Direction: "above", Threshold: 100})
+	require.NoError(t, err)
+
+	var observed []models.Alert
+	e.Observe(func(alert models.Alert) {
+		observed = append(observed, alert)
+	})
+
+	feed(e, 99, 101)
+	require.Len(t, observed, 1)
+	assert.Equal(t, rule.ID, observed[0].RuleID)
+}
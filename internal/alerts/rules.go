@@ -0,0 +1,169 @@
+package alerts
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+)
+
+// historyWindow is how much price history is kept per symbol, bounding
+// percent move windows and defining the high and low of new_high/new_low
+const historyWindow = 24 * time.Hour
+
+// Directions of price_cross and percent_move rules
+const (
+	directionAbove = "above"
+	directionBelow = "below"
+	directionUp    = "up"
+	directionDown  = "down"
+	directionAny   = "any"
+)
+
+// validate normalizes a rule submitted by a client and checks it is complete
+func (e *Engine) validate(rule *models.AlertRule) error {
+	rule.Symbol = strings.ToUpper(strings.TrimSpace(rule.Symbol))
+	rule.Direction = strings.ToLower(rule.Direction)
+	if rule.Symbol == "" {
+		return fmt.Errorf("symbol is required")
+	}
+	if rule.Hysteresis < 0 || rule.CooldownSeconds < 0 {
+		return fmt.Errorf("hysteresis and cooldown_seconds must not be negative")
+	}
+
+	switch rule.Type {
+	case models.AlertPriceCross:
+		if rule.Direction != directionAbove && rule.Direction != directionBelow {
+			return fmt.Errorf("price_cross direction must be above or below")
+		}
+	case models.AlertPercentMove:
+		if rule.Direction == "" {
+			rule.Direction = directionAny
+		}
+		if rule.Direction != directionUp && rule.Direction != directionDown && rule.Direction != directionAny {
+			return fmt.Errorf("percent_move direction must be up, down or any")
+		}
+		if rule.WindowSeconds <= 0 || time.Duration(rule.WindowSeconds)*time.Second > historyWindow {
+			return fmt.Errorf("percent_move window_seconds must be between 1 and %d", int(historyWindow.Seconds()))
+		}
+	case models.AlertNewHigh, models.AlertNewLow:
+		rule.Direction = ""
+		return nil
+	case models.AlertVolatilitySpike:
+		rule.Direction = ""
+		if e.indicators == nil {
+			return fmt.Errorf("volatility_spike rules require indicators")
+		}
+	default:
+		return fmt.Errorf("unknown alert type: %s", rule.Type)
+	}
+
+	if rule.Threshold <= 0 {
+		return fmt.Errorf("threshold must be positive")
+	}
+	if rule.Hysteresis >= rule.Threshold {
+		return fmt.Errorf("hysteresis must be smaller than the threshold")
+	}
+	return nil
+}
+
+// observation is a rule's view of one update: the observed value, whether
+// the rule's condition holds and whether it has retreated far enough to re-arm
+type observation struct {
+	value float64
+	fires bool
+	rearm bool
+}
+
+// observe evaluates a rule against an update and the symbol's earlier
+// prices, recorded since start, returning false if there is not enough data yet
+func (e *Engine) observe(rule models.AlertRule, update models.PriceUpdate, history []pricePoint, start time.Time) (observation, bool) {
+	price, t, h := update.Price, rule.Threshold, rule.Hysteresis
+
+	switch rule.Type {
+	case models.AlertPriceCross:
+		if rule.Direction == directionAbove {
+			return observation{value: price, fires: price >= t, rearm: price <= t-h}, true
+		}
+		return observation{value: price, fires: price <= t, rearm: price >= t+h}, true
+
+	case models.AlertPercentMove:
+		since := update.Timestamp.Add(-time.Duration(rule.WindowSeconds) * time.Second)
+		reference, ok := firstSince(history, since)
+		if !ok || reference == 0 {
+			return observation{}, false
+		}
+
+		change := (price - reference) / reference * 100
+		switch rule.Direction {
+		case directionUp:
+			return observation{value: change, fires: change >= t, rearm: change <= t-h}, true
+		case directionDown:
+			return observation{value: change, fires: change <= -t, rearm: change >= -(t - h)}, true
+		default:
+			return observation{value: change, fires: math.Abs(change) >= t, rearm: math.Abs(change) <= t-h}, true
+		}
+
+	case models.AlertNewHigh, models.AlertNewLow:
+		// Until the history spans the whole window its extreme is not the
+		// 24h one, e.g. right after a restart with little stored history
+		if len(history) == 0 || update.Timestamp.Sub(start) < historyWindow {
+			return observation{}, false
+		}
+
+		extreme := history[0].price
+		for _, p := range history[1:] {
+			if rule.Type == models.AlertNewHigh {
+				extreme = max(extreme, p.price)
+			} else {
+				extreme = min(extreme, p.price)
+			}
+		}
+
+		// Hysteresis is the percentage the price must pull back from the extreme
+		if rule.Type == models.AlertNewHigh {
+			return observation{value: extreme, fires: price > extreme, rearm: price <= extreme*(1-h/100)}, true
+		}
+		return observation{value: extreme, fires: price < extreme, rearm: price >= extreme*(1+h/100)}, true
+
+	case models.AlertVolatilitySpike:
+		indicators, ok := e.indicators.At(update.Symbol, update.Seq)
+		if !ok || indicators.Volatility == nil {
+			return observation{}, false
+		}
+		volatility := *indicators.Volatility
+		return observation{value: volatility, fires: volatility >= t, rearm: volatility <= t-h}, true
+	}
+	return observation{}, false
+}
+
+// firstSince returns the oldest price at or after since
+func firstSince(history []pricePoint, since time.Time) (float64, bool) {
+	i := sort.Search(len(history), func(i int) bool {
+		return !history[i].timestamp.Before(since)
+	})
+	if i == len(history) {
+		return 0, false
+	}
+	return history[i].price, true
+}
+
+// describe formats a human-readable alert message
+func describe(rule models.AlertRule, value, price float64) string {
+	switch rule.Type {
+	case models.AlertPriceCross:
+		return fmt.Sprintf("%s crossed %s %g at %g", rule.Symbol, rule.Direction, rule.Threshold, price)
+	case models.AlertPercentMove:
+		return fmt.Sprintf("%s moved %+.2f%% within %ds to %g", rule.Symbol, value, rule.WindowSeconds, price)
+	case models.AlertNewHigh:
+		return fmt.Sprintf("%s made a new 24h high of %g above %g", rule.Symbol, price, value)
+	case models.AlertNewLow:
+		return fmt.Sprintf("%s made a new 24h low of %g below %g", rule.Symbol, price, value)
+	case models.AlertVolatilitySpike:
+		return fmt.Sprintf("%s volatility spiked to %.4f above %g", rule.Symbol, value, rule.Threshold)
+	}
+	return rule.Symbol + " alert"
+}
@@ -0,0 +1,46 @@
+package alerts
+
+import (
+	"bitcoin-price-streamer/internal/models"
+)
+
+// Subscription is a client's feed of alerts
+type Subscription struct {
+	C chan models.Alert
+}
+
+// Subscribe adds a client receiving every alert as it fires
+func (e *Engine) Subscribe() *Subscription {
+	sub := &Subscription{C: make(chan models.Alert, e.bufferSize)}
+
+	e.mutex.Lock()
+	e.subscribers[sub] = true
+	e.mutex.Unlock()
+
+	return sub
+}
+
+// Unsubscribe removes a client from receiving alerts
+func (e *Engine) Unsubscribe(sub *Subscription) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if _, exists := e.subscribers[sub]; exists {
+		delete(e.subscribers, sub)
+		close(sub.C)
+	}
+}
+
+// publish delivers alerts to every subscriber. A subscriber whose buffer is
+// full misses the alert. Callers must hold the lock
+func (e *Engine) publish(alerts []models.Alert) {
+	for _, alert := range alerts {
+		for sub := range e.subscribers {
+			select {
+			case sub.C <- alert:
+			default:
+				e.logger.Warnf("Dropping alert %s for slow client", alert.RuleID)
+			}
+		}
+	}
+}
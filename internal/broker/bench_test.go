@@ -0,0 +1,168 @@
+package broker
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+var benchSubscribers = []int{10000, 100000}
+
+// subscriber stands in for a client subscription, compared by identity
+type subscriber struct {
+	received atomic.Int64
+}
+
+// mutexSet is the single RWMutex-guarded map the broker replaces, kept as a
+// baseline
+type mutexSet struct {
+	mutex sync.RWMutex
+	subs  map[*subscriber]bool
+}
+
+func (m *mutexSet) add(sub *subscriber) {
+	m.mutex.Lock()
+	m.subs[sub] = true
+	m.mutex.Unlock()
+}
+
+func (m *mutexSet) remove(sub *subscriber) {
+	m.mutex.Lock()
+	delete(m.subs, sub)
+	m.mutex.Unlock()
+}
+
+func (m *mutexSet) each(fn func(*subscriber)) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	for sub := range m.subs {
+		fn(sub)
+	}
+}
+
+func deliver(sub *subscriber) {
+	sub.received.Add(1)
+}
+
+func newBenchBroker(n int) *Broker[*subscriber] {
+	b := New[*subscriber](DefaultShards)
+	for range n {
+		b.Add(&subscriber{})
+	}
+	return b
+}
+
+func newBenchMutexSet(n int) *mutexSet {
+	m := &mutexSet{subs: make(map[*subscriber]bool, n)}
+	for range n {
+		m.add(&subscriber{})
+	}
+	return m
+}
+
+// churn subscribes and unsubscribes clients in the background until stop is
+// closed, returning the number of changes made
+func churn(stop chan struct{}, add, remove func(*subscriber)) func() int64 {
+	var changes atomic.Int64
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				sub := &subscriber{}
+				add(sub)
+				remove(sub)
+				changes.Add(2)
+			}
+		}()
+	}
+	return func() int64 {
+		close(stop)
+		wg.Wait()
+		return changes.Load()
+	}
+}
+
+func BenchmarkFanout(b *testing.B) {
+	for _, n := range benchSubscribers {
+		b.Run(fmt.Sprintf("broker/%d", n), func(b *testing.B) {
+			broker := newBenchBroker(n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				broker.Each(deliver)
+			}
+		})
+		b.Run(fmt.Sprintf("mutex/%d", n), func(b *testing.B) {
+			set := newBenchMutexSet(n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				set.each(deliver)
+			}
+		})
+	}
+}
+
+// BenchmarkFanoutDuringChurn fans out while clients keep subscribing and
+// unsubscribing, reporting how many changes got through per fan-out
+func BenchmarkFanoutDuringChurn(b *testing.B) {
+	for _, n := range benchSubscribers {
+		b.Run(fmt.Sprintf("broker/%d", n), func(b *testing.B) {
+			broker := newBenchBroker(n)
+			stop := churn(make(chan struct{}),
+				func(s *subscriber) { broker.Add(s) },
+				func(s *subscriber) { broker.Remove(s) })
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				broker.Each(deliver)
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(stop())/float64(b.N), "changes/op")
+		})
+		b.Run(fmt.Sprintf("mutex/%d", n), func(b *testing.B) {
+			set := newBenchMutexSet(n)
+			stop := churn(make(chan struct{}), set.add, set.remove)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				set.each(deliver)
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(stop())/float64(b.N), "changes/op")
+		})
+	}
+}
+
+// BenchmarkSubscribeParallel subscribes and unsubscribes from many goroutines
+// with 10k subscribers present
+func BenchmarkSubscribeParallel(b *testing.B) {
+	b.Run("broker", func(b *testing.B) {
+		broker := newBenchBroker(benchSubscribers[0])
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				sub := &subscriber{}
+				broker.Add(sub)
+				broker.Remove(sub)
+			}
+		})
+	})
+	b.Run("mutex", func(b *testing.B) {
+		set := newBenchMutexSet(benchSubscribers[0])
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				sub := &subscriber{}
+				set.add(sub)
+				set.remove(sub)
+			}
+		})
+	})
+}
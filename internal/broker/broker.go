@@ -0,0 +1,133 @@
+package broker
+
+import (
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultShards is the number of shards used when none is configured
+const DefaultShards = 32
+
+// Broker is the set of subscribers a publisher fans out to. Subscribers are
+// spread over shards, each keeping an immutable snapshot that is replaced on
+// every change, so fan-out reads the snapshots without taking any lock while
+// subscribes and unsubscribes only contend within their shard
+type Broker[T comparable] struct {
+	seed   maphash.Seed
+	shards []shard[T]
+	size   atomic.Int64
+}
+
+// shard is one slice of the subscriber set. members and changes are guarded
+// by mutex, readers only load snapshot
+type shard[T comparable] struct {
+	mutex    sync.Mutex
+	members  map[T]int
+	snapshot atomic.Pointer[[]T]
+}
+
+// New creates an empty broker with the given number of shards, at least one
+func New[T comparable](shards int) *Broker[T] {
+	shards = max(shards, 1)
+
+	b := &Broker[T]{
+		seed:   maphash.MakeSeed(),
+		shards: make([]shard[T], shards),
+	}
+	for i := range b.shards {
+		b.shards[i].members = make(map[T]int)
+		b.shards[i].snapshot.Store(&[]T{})
+	}
+	return b
+}
+
+// shardFor returns the shard holding sub
+func (b *Broker[T]) shardFor(sub T) *shard[T] {
+	return &b.shards[maphash.Comparable(b.seed, sub)%uint64(len(b.shards))]
+}
+
+// Add adds a subscriber, returning false if it was already present
+func (b *Broker[T]) Add(sub T) bool {
+	s := b.shardFor(sub)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.members[sub]; exists {
+		return false
+	}
+
+	old := *s.snapshot.Load()
+	next := make([]T, len(old), len(old)+1)
+	copy(next, old)
+	s.members[sub] = len(next)
+	next = append(next, sub)
+	s.snapshot.Store(&next)
+
+	b.size.Add(1)
+	return true
+}
+
+// Remove removes a subscriber, returning false if it was not present. Only
+// one of several concurrent calls for the same subscriber returns true, so
+// the caller seeing true may safely release the subscriber's resources
+func (b *Broker[T]) Remove(sub T) bool {
+	s := b.shardFor(sub)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i, exists := s.members[sub]
+	if !exists {
+		return false
+	}
+
+	// Move the last subscriber into the gap, so indexes stay valid
+	old := *s.snapshot.Load()
+	next := make([]T, len(old)-1)
+	copy(next, old[:len(old)-1])
+	if last := old[len(old)-1]; last != sub {
+		next[i] = last
+		s.members[last] = i
+	}
+	delete(s.members, sub)
+	s.snapshot.Store(&next)
+
+	b.size.Add(-1)
+	return true
+}
+
+// Contains reports whether sub is subscribed
+func (b *Broker[T]) Contains(sub T) bool {
+	s := b.shardFor(sub)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, exists := s.members[sub]
+	return exists
+}
+
+// Len returns the number of subscribers
+func (b *Broker[T]) Len() int {
+	return int(b.size.Load())
+}
+
+// Each calls fn for every subscriber without holding any lock, so fn may add
+// or remove subscribers. Each shard is read from its snapshot as of when Each
+// reaches it: subscribers added meanwhile may be missed and ones removed
+// meanwhile may still be seen
+func (b *Broker[T]) Each(fn func(T)) {
+	for i := range b.shards {
+		for _, sub := range *b.shards[i].snapshot.Load() {
+			fn(sub)
+		}
+	}
+}
+
+// Snapshot returns the subscribers, in no particular order
+func (b *Broker[T]) Snapshot() []T {
+	subs := make([]T, 0, b.Len())
+	b.Each(func(sub T) {
+		subs = append(subs, sub)
+	})
+	return subs
+}
@@ -0,0 +1,98 @@
+package broker
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddRemove(t *testing.T) {
+	b := New[int](4)
+
+	for i := range 100 {
+		assert.True(t, b.Add(i))
+	}
+	assert.False(t, b.Add(7), "Adding twice should be a no-op")
+	assert.Equal(t, 100, b.Len())
+
+	for i := 0; i < 100; i += 2 {
+		assert.True(t, b.Remove(i))
+	}
+	assert.False(t, b.Remove(0), "Removing twice should be a no-op")
+	assert.Equal(t, 50, b.Len())
+	assert.True(t, b.Contains(1))
+	assert.False(t, b.Contains(2))
+
+	subs := b.Snapshot()
+	sort.Ints(subs)
+	for i, sub := range subs {
+		assert.Equal(t, 2*i+1, sub)
+	}
+}
+
+func TestNewClampsShards(t *testing.T) {
+	b := New[string](0)
+	assert.True(t, b.Add("a"))
+	assert.Equal(t, []string{"a"}, b.Snapshot())
+}
+
+func TestEachMayModifyBroker(t *testing.T) {
+	b := New[int](1)
+	for i := range 10 {
+		b.Add(i)
+	}
+
+	// Removing during fan-out does not disturb the snapshot being read
+	var seen []int
+	b.Each(func(sub int) {
+		seen = append(seen, sub)
+		b.Remove(sub)
+		b.Add(sub + 100)
+	})
+	sort.Ints(seen)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, seen)
+	assert.Equal(t, 10, b.Len())
+}
+
+func TestConcurrentChurn(t *testing.T) {
+	b := New[int](8)
+	for i := range 1000 {
+		b.Add(i)
+	}
+
+	var wg sync.WaitGroup
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				sub := 1000 + w*1000 + i
+				b.Add(sub)
+				b.Each(func(int) {})
+				b.Remove(sub)
+			}
+		}()
+	}
+
+	// Concurrent removals of one subscriber succeed exactly once
+	var removed sync.Map
+	for w := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				if b.Remove(i) {
+					if _, loaded := removed.LoadOrStore(i, w); loaded {
+						t.Errorf("subscriber %d removed twice", i)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 0, b.Len())
+	assert.Empty(t, b.Snapshot())
+}
@@ -0,0 +1,64 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+
+	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/utils"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// bufferSize is the number of messages a subscriber may fall behind before
+// delivery waits for it
+const bufferSize = 64
+
+// Message is a stored price update published by the instance named Origin
+type Message struct {
+	Origin string             `json:"origin"`
+	Update models.PriceUpdate `json:"update"`
+}
+
+// Bus carries the price updates stored by the polling instance to every
+// instance, so they all serve the same stream
+type Bus interface {
+	// Name identifies the bus in logs
+	Name() string
+	// Publish sends a message to every subscriber, including the publisher's
+	Publish(ctx context.Context, message Message) error
+	// Subscribe returns the messages published from now on. The channel is
+	// closed once ctx is done
+	Subscribe(ctx context.Context) (<-chan Message, error)
+	// Last returns the most recently published message, if any, so a new
+	// publisher can continue the sequence even if it missed messages
+	Last(ctx context.Context) (Message, bool, error)
+	// Close releases the connection to the bus
+	Close() error
+}
+
+// Local reports whether b only delivers messages within this process, so
+// instances that do not poll would never receive a price over it
+func Local(b Bus) bool {
+	_, local := b.(*Memory)
+	return local
+}
+
+// NewFromEnv builds the bus selected by BUS_BACKEND: 'memory' (a single
+// instance) or 'redis' (Redis pub/sub on BUS_CHANNEL at BUS_REDIS_URL)
+func NewFromEnv(logger *logrus.Logger) (Bus, error) {
+	switch backend := utils.GetEnvString("BUS_BACKEND", "memory"); backend {
+	case "memory":
+		return NewMemory(), nil
+	case "redis":
+		options, err := redis.ParseURL(utils.GetEnvString("BUS_REDIS_URL", "redis://localhost:6379/0"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse BUS_REDIS_URL: %w", err)
+		}
+		channel := utils.GetEnvString("BUS_CHANNEL", "prices")
+		return NewRedis(redis.NewClient(options), channel, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown bus backend: %s", backend)
+	}
+}
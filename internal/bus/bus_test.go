@@ -0,0 +1,111 @@
+package bus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receive waits for the next message on messages
+func receive(t *testing.T, messages <-chan Message) Message {
+	t.Helper()
+
+	select {
+	case message, ok := <-messages:
+		require.True(t, ok, "Channel closed")
+		return message
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for a message")
+		return Message{}
+	}
+}
+
+func TestMemoryFansOut(t *testing.T) {
+	bus := NewMemory()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first, err := bus.Subscribe(ctx)
+	require.NoError(t, err)
+	second, err := bus.Subscribe(ctx)
+	require.NoError(t, err)
+
+	message := Message{Origin: "a", Update: models.PriceUpdate{Seq: 1, Symbol: "BTC", Price: 50000.0}}
+	require.NoError(t, bus.Publish(ctx, message))
+
+	assert.Equal(t, message, receive(t, first))
+	assert.Equal(t, message, receive(t, second))
+
+	last, ok, err := bus.Last(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, message, last)
+}
+
+func TestMemoryUnsubscribesWhenDone(t *testing.T) {
+	bus := NewMemory()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	messages, err := bus.Subscribe(ctx)
+	require.NoError(t, err)
+
+	// A subscriber that stopped reading does not block publishers
+	for i := range bufferSize {
+		require.NoError(t, bus.Publish(context.Background(), Message{Update: models.PriceUpdate{Seq: uint64(i + 1)}}))
+	}
+	cancel()
+	require.NoError(t, bus.Publish(context.Background(), Message{}))
+
+	for range messages {
+	}
+	assert.Eventually(t, func() bool {
+		bus.mutex.RLock()
+		defer bus.mutex.RUnlock()
+		return len(bus.subscribers) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMemoryPublishHonorsContext(t *testing.T) {
+	bus := NewMemory()
+	_, err := bus.Subscribe(context.Background())
+	require.NoError(t, err)
+
+	for range bufferSize {
+		require.NoError(t, bus.Publish(context.Background(), Message{}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, bus.Publish(ctx, Message{}), context.DeadlineExceeded)
+}
+
+func TestNewFromEnv(t *testing.T) {
+	logger := logrus.New()
+
+	bus, err := NewFromEnv(logger)
+	require.NoError(t, err)
+	assert.Equal(t, "memory", bus.Name())
+	assert.True(t, Local(bus))
+
+	t.Setenv("BUS_BACKEND", "redis")
+	t.Setenv("BUS_REDIS_URL", "redis://localhost:6379/0")
+	bus, err = NewFromEnv(logger)
+	require.NoError(t, err)
+	assert.Equal(t, "redis", bus.Name())
+	assert.False(t, Local(bus))
+	bus.Close()
+
+	t.Setenv("BUS_REDIS_URL", "http://localhost")
+	_, err = NewFromEnv(logger)
+	assert.Error(t, err)
+
+	t.Setenv("BUS_BACKEND", "carrier-pigeon")
+	_, err = NewFromEnv(logger)
+	assert.Error(t, err)
+}
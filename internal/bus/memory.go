@@ -0,0 +1,89 @@
+package bus
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Memory is an in-process bus, for a single instance or for tests standing
+// in for a shared one
+type Memory struct {
+	subscribers map[*memorySubscriber]bool
+	last        atomic.Pointer[Message]
+	mutex       sync.RWMutex
+}
+
+// memorySubscriber is a channel fed until done is closed
+type memorySubscriber struct {
+	ch   chan Message
+	done <-chan struct{}
+}
+
+// NewMemory creates an in-process bus
+func NewMemory() *Memory {
+	return &Memory{
+		subscribers: make(map[*memorySubscriber]bool),
+	}
+}
+
+// Name identifies the bus in logs
+func (m *Memory) Name() string {
+	return "memory"
+}
+
+// Publish delivers message to every subscriber, waiting for those whose
+// buffer is full until ctx is done
+func (m *Memory) Publish(ctx context.Context, message Message) error {
+	m.last.Store(&message)
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for sub := range m.subscribers {
+		select {
+		case sub.ch <- message:
+		case <-sub.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Subscribe returns the messages published until ctx is done
+func (m *Memory) Subscribe(ctx context.Context) (<-chan Message, error) {
+	sub := &memorySubscriber{
+		ch:   make(chan Message, bufferSize),
+		done: ctx.Done(),
+	}
+
+	m.mutex.Lock()
+	m.subscribers[sub] = true
+	m.mutex.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		m.mutex.Lock()
+		delete(m.subscribers, sub)
+		m.mutex.Unlock()
+		close(sub.ch)
+	}()
+
+	return sub.ch, nil
+}
+
+// Last returns the most recently published message
+func (m *Memory) Last(ctx context.Context) (Message, bool, error) {
+	last := m.last.Load()
+	if last == nil {
+		return Message{}, false, nil
+	}
+	return *last, true, nil
+}
+
+// Close does nothing, an in-process bus holds no connection
+func (m *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,121 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// Redis is a bus on a Redis pub/sub channel. Redis delivers each message at
+// most once, so an instance that was disconnected misses what was published
+// meanwhile. The last message is also kept in the key <channel>:last
+type Redis struct {
+	client  *redis.Client
+	channel string
+	logger  *logrus.Logger
+}
+
+// NewRedis creates a bus publishing on channel through client
+func NewRedis(client *redis.Client, channel string, logger *logrus.Logger) *Redis {
+	return &Redis{
+		client:  client,
+		channel: channel,
+		logger:  logger,
+	}
+}
+
+// Name identifies the bus in logs
+func (r *Redis) Name() string {
+	return "redis"
+}
+
+// lastKey is the key holding the last message published on the channel
+func (r *Redis) lastKey() string {
+	return r.channel + ":last"
+}
+
+// Publish sends message as JSON on the channel and keeps it as the last one
+func (r *Redis) Publish(ctx context.Context, message Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, r.lastKey(), data, 0)
+		pipe.Publish(ctx, r.channel, data)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", r.channel, err)
+	}
+	return nil
+}
+
+// Last returns the last message published on the channel
+func (r *Redis) Last(ctx context.Context) (Message, bool, error) {
+	data, err := r.client.Get(ctx, r.lastKey()).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Message{}, false, nil
+	}
+	if err != nil {
+		return Message{}, false, fmt.Errorf("failed to read the last message on %s: %w", r.channel, err)
+	}
+
+	var message Message
+	if err := json.Unmarshal(data, &message); err != nil {
+		return Message{}, false, fmt.Errorf("failed to decode the last message on %s: %w", r.channel, err)
+	}
+	return message, true, nil
+}
+
+// Subscribe returns the messages published on the channel until ctx is done,
+// once Redis has confirmed the subscription. The client reconnects and
+// resubscribes by itself if the connection drops
+func (r *Redis) Subscribe(ctx context.Context) (<-chan Message, error) {
+	pubsub := r.client.Subscribe(ctx, r.channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", r.channel, err)
+	}
+
+	messages := make(chan Message, bufferSize)
+	go func() {
+		defer close(messages)
+		defer pubsub.Close()
+
+		received := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case raw, ok := <-received:
+				if !ok {
+					return
+				}
+
+				var message Message
+				if err := json.Unmarshal([]byte(raw.Payload), &message); err != nil {
+					r.logger.Warnf("Ignoring malformed message on %s: %v", r.channel, err)
+					continue
+				}
+
+				select {
+				case messages <- message:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return messages, nil
+}
+
+// Close closes the connection to Redis
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,100 @@
+package bus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRedis connects a bus to server as one instance would
+func newTestRedis(t *testing.T, server *miniredis.Miniredis) *Redis {
+	t.Helper()
+
+	bus := NewRedis(redis.NewClient(&redis.Options{Addr: server.Addr()}), "prices", logrus.New())
+	t.Cleanup(func() { bus.Close() })
+	return bus
+}
+
+func TestRedisDeliversAcrossInstances(t *testing.T) {
+	server := miniredis.RunT(t)
+	publisher := newTestRedis(t, server)
+	follower := newTestRedis(t, server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	messages, err := follower.Subscribe(ctx)
+	require.NoError(t, err)
+
+	update := models.PriceUpdate{
+		Seq:       42,
+		Timestamp: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		Price:     118738.05,
+		Symbol:    "BTC",
+		Name:      "Bitcoin",
+		MarketCap: 2361234567890.12,
+	}
+	require.NoError(t, publisher.Publish(ctx, Message{Origin: "a", Update: update}))
+
+	message := receive(t, messages)
+	assert.Equal(t, "a", message.Origin)
+	assert.Equal(t, update, message.Update)
+
+	// Any instance can read the last message, e.g. on taking over publishing
+	last, ok, err := follower.Last(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, message, last)
+}
+
+func TestRedisLastWithoutMessages(t *testing.T) {
+	server := miniredis.RunT(t)
+	bus := newTestRedis(t, server)
+
+	_, ok, err := bus.Last(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	server.Set("prices:last", "not json")
+	_, _, err = bus.Last(context.Background())
+	assert.Error(t, err)
+}
+
+func TestRedisSkipsMalformedMessages(t *testing.T) {
+	server := miniredis.RunT(t)
+	bus := newTestRedis(t, server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	messages, err := bus.Subscribe(ctx)
+	require.NoError(t, err)
+
+	server.Publish("prices", "not json")
+	require.NoError(t, bus.Publish(ctx, Message{Origin: "a", Update: models.PriceUpdate{Seq: 1}}))
+	assert.Equal(t, uint64(1), receive(t, messages).Update.Seq)
+
+	// The channel closes once the subscriber is done
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, ok := <-messages
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRedisSubscribeFailsWithoutServer(t *testing.T) {
+	server := miniredis.RunT(t)
+	bus := newTestRedis(t, server)
+	server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := bus.Subscribe(ctx)
+	assert.Error(t, err)
+	assert.Error(t, bus.Publish(ctx, Message{}))
+}
@@ -0,0 +1,234 @@
+package candles
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/storage"
+	"bitcoin-price-streamer/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Interval is a candle bar duration
+type Interval struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Intervals are the bar durations every symbol is aggregated into
+var Intervals = []Interval{
+	{Name: "1m", Duration: time.Minute},
+	{Name: "5m", Duration: 5 * time.Minute},
+	{Name: "15m", Duration: 15 * time.Minute},
+	{Name: "1h", Duration: time.Hour},
+	{Name: "1d", Duration: 24 * time.Hour},
+}
+
+// ParseInterval looks up a supported interval by name
+func ParseInterval(name string) (Interval, error) {
+	for _, interval := range Intervals {
+		if interval.Name == name {
+			return interval, nil
+		}
+	}
+	return Interval{}, fmt.Errorf("unknown interval: %s", name)
+}
+
+// seriesKey identifies the candles of one symbol at one interval
+type seriesKey struct {
+	symbol   string
+	interval string
+}
+
+// series holds the closed candles of one symbol and interval, oldest first,
+// and the bar currently being built
+type series struct {
+	closed  []models.Candle
+	current *models.Candle
+}
+
+// Builder aggregates price updates into OHLC candles at every interval and
+// streams in-progress and closed bars to subscribers
+type Builder struct {
+	series      map[seriesKey]*series
+	capacity    int
+	subscribers map[*Subscription]bool
+	bufferSize  int
+	mutex       sync.RWMutex
+	logger      *logrus.Logger
+}
+
+// NewBuilder creates a candle builder keeping CANDLE_CAPACITY closed candles
+// per symbol and interval
+func NewBuilder(logger *logrus.Logger) *Builder {
+	return &Builder{
+		series:      make(map[seriesKey]*series),
+		capacity:    utils.GetEnvInt("CANDLE_CAPACITY", 1000),
+		subscribers: make(map[*Subscription]bool),
+		bufferSize:  utils.GetEnvInt("CLIENT_BUFFER_SIZE", 50),
+		logger:      logger,
+	}
+}
+
+// Add folds a price update into the current bar of its symbol at every
+// interval, closing bars whose interval has ended
+func (b *Builder) Add(update models.PriceUpdate) {
+	var events []models.Candle
+
+	b.mutex.Lock()
+	for _, interval := range Intervals {
+		key := seriesKey{symbol: update.Symbol, interval: interval.Name}
+		s, exists := b.series[key]
+		if !exists {
+			s = &series{}
+			b.series[key] = s
+		}
+
+		start := update.Timestamp.UTC().Truncate(interval.Duration)
+		if s.current != nil {
+			if start.Before(s.current.Start) {
+				b.logger.Debugf("Ignoring late %s update at %s for closed %s candle",
+					update.Symbol, update.Timestamp.Format(time.RFC3339), interval.Name)
+				continue
+			}
+			if start.After(s.current.Start) {
+				events = append(events, b.closeCurrent(s))
+			}
+		}
+
+		if s.current == nil {
+			s.current = &models.Candle{
+				Symbol:   update.Symbol,
+				Interval: interval.Name,
+				Start:    start,
+				End:      start.Add(interval.Duration),
+				Open:     update.Price,
+				High:     update.Price,
+				Low:      update.Price,
+			}
+		}
+
+		current := s.current
+		current.High = max(current.High, update.Price)
+		current.Low = min(current.Low, update.Price)
+		current.Close = update.Price
+		current.Ticks++
+		if update.Volume24h > 0 {
+			current.Volume24h = update.Volume24h
+		}
+		events = append(events, *current)
+	}
+
+	// Publish under the lock so subscribers see bars in order
+	b.publish(events)
+	b.mutex.Unlock()
+}
+
+// closeCurrent moves the bar being built into the closed history and
+// returns it. Callers must hold the lock
+func (b *Builder) closeCurrent(s *series) models.Candle {
+	closed := *s.current
+	closed.Closed = true
+
+	s.closed = append(s.closed, closed)
+	if len(s.closed) > b.capacity {
+		s.closed = s.closed[len(s.closed)-b.capacity:]
+	}
+	s.current = nil
+	return closed
+}
+
+// Flush closes every bar whose interval ended at or before now, so bars close
+// on time even when no further updates arrive
+func (b *Builder) Flush(now time.Time) {
+	var events []models.Candle
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, s := range b.series {
+		if s.current != nil && !now.Before(s.current.End) {
+			events = append(events, b.closeCurrent(s))
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].End.Before(events[j].End)
+	})
+	b.publish(events)
+}
+
+// Backfill builds candles from the updates already held in store, e.g. after
+// history was restored from disk
+func (b *Builder) Backfill(store storage.Store) {
+	count := 0
+	for _, symbol := range store.Symbols() {
+		store.Iterate(symbol, func(update models.PriceUpdate) bool {
+			b.Add(update)
+			count++
+			return true
+		})
+	}
+	b.logger.Infof("Backfilled candles from %d stored updates", count)
+}
+
+// Run closes ended bars every second until ctx is cancelled
+func (b *Builder) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			b.Flush(now)
+		}
+	}
+}
+
+// Current returns the bar being built for symbol at interval
+func (b *Builder) Current(symbol string, interval Interval) (models.Candle, bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	s, exists := b.series[seriesKey{symbol: symbol, interval: interval.Name}]
+	if !exists || s.current == nil {
+		return models.Candle{}, false
+	}
+	return *s.current, true
+}
+
+// Candles returns the candles of symbol at interval starting in [from, to),
+// oldest first, including the bar being built. Zero bounds are unset
+func (b *Builder) Candles(symbol string, interval Interval, from, to time.Time) []models.Candle {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	s, exists := b.series[seriesKey{symbol: symbol, interval: interval.Name}]
+	if !exists {
+		return nil
+	}
+
+	candles := s.closed
+	if s.current != nil {
+		candles = append(candles[:len(candles):len(candles)], *s.current)
+	}
+
+	lo, hi := 0, len(candles)
+	if !from.IsZero() {
+		lo = sort.Search(len(candles), func(i int) bool { return !candles[i].Start.Before(from) })
+	}
+	if !to.IsZero() {
+		hi = sort.Search(len(candles), func(i int) bool { return !candles[i].Start.Before(to) })
+	}
+	if lo >= hi {
+		return nil
+	}
+	return append([]models.Candle(nil), candles[lo:hi]...)
+}
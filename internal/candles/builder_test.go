@@ -0,0 +1,156 @@
+package candles
+
+import (
+	"testing"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInterval(t *testing.T) {
+	interval, err := ParseInterval("15m")
+	assert.NoError(t, err)
+	assert.Equal(t, 15*time.Minute, interval.Duration)
+
+	_, err = ParseInterval("2m")
+	assert.Error(t, err)
+}
+
+func TestBuilderAggregatesOHLC(t *testing.T) {
+	builder := NewBuilder(logrus.New())
+	minute, _ := ParseInterval("1m")
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, price := range []float64{100, 105, 95, 102} {
+		builder.Add(models.PriceUpdate{Timestamp: base.Add(time.Duration(i*10) * time.Second), Price: price, Symbol: "BTC", Volume24h: 1000})
+	}
+	builder.Add(models.PriceUpdate{Timestamp: base.Add(time.Minute), Price: 110, Symbol: "BTC"})
+
+	candles := builder.Candles("BTC", minute, time.Time{}, time.Time{})
+	require.Len(t, candles, 2)
+
+	closed := candles[0]
+	assert.True(t, closed.Closed)
+	assert.Equal(t, base, closed.Start)
+	assert.Equal(t, base.Add(time.Minute), closed.End)
+	assert.Equal(t, 100.0, closed.Open)
+	assert.Equal(t, 105.0, closed.High)
+	assert.Equal(t, 95.0, closed.Low)
+	assert.Equal(t, 102.0, closed.Close)
+	assert.Equal(t, 1000.0, closed.Volume24h)
+	assert.Equal(t, 4, closed.Ticks)
+
+	// The in-progress bar is included but not closed
+	assert.False(t, candles[1].Closed)
+	assert.Equal(t, 110.0, candles[1].Open)
+
+	// Longer intervals still have a single bar in progress
+	hour, _ := ParseInterval("1h")
+	hourly := builder.Candles("BTC", hour, time.Time{}, time.Time{})
+	require.Len(t, hourly, 1)
+	assert.Equal(t, 110.0, hourly[0].High)
+	assert.Equal(t, 5, hourly[0].Ticks)
+
+	// Range bounds apply to the bar start
+	assert.Len(t, builder.Candles("BTC", minute, base.Add(time.Minute), time.Time{}), 1)
+	assert.Empty(t, builder.Candles("ETH", minute, time.Time{}, time.Time{}))
+}
+
+func TestBuilderIgnoresLateUpdates(t *testing.T) {
+	builder := NewBuilder(logrus.New())
+	minute, _ := ParseInterval("1m")
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	builder.Add(models.PriceUpdate{Timestamp: base.Add(time.Minute), Price: 100, Symbol: "BTC"})
+	builder.Add(models.PriceUpdate{Timestamp: base, Price: 50, Symbol: "BTC"})
+
+	candles := builder.Candles("BTC", minute, time.Time{}, time.Time{})
+	require.Len(t, candles, 1)
+	assert.Equal(t, 100.0, candles[0].Low)
+}
+
+func TestBuilderFlushAndSubscribe(t *testing.T) {
+	builder := NewBuilder(logrus.New())
+	minute, _ := ParseInterval("1m")
+
+	sub := builder.Subscribe("BTC", minute)
+	defer builder.Unsubscribe(sub)
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	builder.Add(models.PriceUpdate{Timestamp: base, Price: 100, Symbol: "BTC"})
+	builder.Add(models.PriceUpdate{Timestamp: base, Price: 10, Symbol: "ETH"})
+
+	// Only the matching in-progress bar is streamed
+	candle := <-sub.C
+	assert.Equal(t, "BTC", candle.Symbol)
+	assert.Equal(t, "1m", candle.Interval)
+	assert.False(t, candle.Closed)
+
+	current, exists := builder.Current("BTC", minute)
+	assert.True(t, exists)
+	assert.Equal(t, 100.0, current.Open)
+
+	// Flushing before the end keeps the bar open
+	builder.Flush(base.Add(30 * time.Second))
+	assert.Empty(t, sub.C)
+
+	builder.Flush(base.Add(time.Minute))
+	candle = <-sub.C
+	assert.True(t, candle.Closed)
+	assert.Equal(t, 100.0, candle.Close)
+	_, exists = builder.Current("BTC", minute)
+	assert.False(t, exists)
+
+	// The next update starts a fresh bar
+	builder.Add(models.PriceUpdate{Timestamp: base.Add(70 * time.Second), Price: 101, Symbol: "BTC"})
+	candle = <-sub.C
+	assert.False(t, candle.Closed)
+	assert.Equal(t, 101.0, candle.Open)
+	assert.Len(t, builder.Candles("BTC", minute, time.Time{}, time.Time{}), 2)
+}
+
+func TestBuilderSlowSubscriber(t *testing.T) {
+	t.Setenv("CLIENT_BUFFER_SIZE", "2")
+	builder := NewBuilder(logrus.New())
+	minute, _ := ParseInterval("1m")
+
+	sub := builder.Subscribe("BTC", minute)
+	defer builder.Unsubscribe(sub)
+
+	// In-progress bars beyond the buffer are dropped
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 4 {
+		builder.Add(models.PriceUpdate{Timestamp: base.Add(time.Duration(i) * time.Second), Price: 100, Symbol: "BTC"})
+	}
+	assert.Len(t, sub.C, 2)
+	assert.Nil(t, sub.CloseNotice())
+
+	// A closed bar is never dropped: the client is disconnected instead
+	builder.Flush(base.Add(time.Minute))
+	for range sub.C {
+	}
+	notice := sub.CloseNotice()
+	require.NotNil(t, notice)
+	assert.Equal(t, ReasonSlowConsumer, notice.Reason)
+	assert.Equal(t, uint64(2), notice.Dropped)
+}
+
+func TestBuilderCapacity(t *testing.T) {
+	t.Setenv("CANDLE_CAPACITY", "2")
+	builder := NewBuilder(logrus.New())
+	minute, _ := ParseInterval("1m")
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		builder.Add(models.PriceUpdate{Timestamp: base.Add(time.Duration(i) * time.Minute), Price: float64(i), Symbol: "BTC"})
+	}
+
+	// Two closed bars plus the one in progress
+	candles := builder.Candles("BTC", minute, time.Time{}, time.Time{})
+	require.Len(t, candles, 3)
+	assert.Equal(t, 2.0, candles[0].Open)
+}
@@ -0,0 +1,110 @@
+package candles
+
+import (
+	"fmt"
+	"sync"
+
+	"bitcoin-price-streamer/internal/models"
+)
+
+// ReasonSlowConsumer is the disconnect reason of clients that fell behind
+const ReasonSlowConsumer = "slow_consumer"
+
+// Subscription is a client's feed of candles for one symbol and interval.
+// When the builder closes C, CloseNotice says why
+type Subscription struct {
+	C        chan models.Candle
+	symbol   string
+	interval string
+	notice   *models.DisconnectNotice
+	dropped  uint64
+	mutex    sync.RWMutex
+}
+
+// Matches reports whether a candle should be delivered to the subscription
+func (s *Subscription) Matches(candle models.Candle) bool {
+	return s.symbol == candle.Symbol && s.interval == candle.Interval
+}
+
+// CloseNotice returns why the builder disconnected the client, or nil if it
+// did not
+func (s *Subscription) CloseNotice() *models.DisconnectNotice {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.notice
+}
+
+// Subscribe adds a client receiving every in-progress and closed candle of
+// symbol at interval
+func (b *Builder) Subscribe(symbol string, interval Interval) *Subscription {
+	sub := &Subscription{
+		C:        make(chan models.Candle, b.bufferSize),
+		symbol:   symbol,
+		interval: interval.Name,
+	}
+
+	b.mutex.Lock()
+	b.subscribers[sub] = true
+	b.mutex.Unlock()
+
+	b.logger.Infof("New candle client subscribed to %s %s", symbol, interval.Name)
+	return sub
+}
+
+// Unsubscribe removes a client from receiving candles
+func (b *Builder) Unsubscribe(sub *Subscription) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, exists := b.subscribers[sub]; exists {
+		delete(b.subscribers, sub)
+		close(sub.C)
+	}
+}
+
+// publish delivers candles to matching subscribers. A subscriber whose buffer
+// is full misses in-progress bars, as the next one supersedes them, but a
+// closed bar is final: a subscriber with no room for one is disconnected
+// rather than left with a gap in its history. Callers must hold the lock
+func (b *Builder) publish(candles []models.Candle) {
+	for _, candle := range candles {
+		for sub := range b.subscribers {
+			if !sub.Matches(candle) {
+				continue
+			}
+
+			select {
+			case sub.C <- candle:
+				continue
+			default:
+			}
+
+			if !candle.Closed {
+				sub.dropped++
+				b.logger.Debugf("Dropping in-progress %s %s candle for slow client", candle.Symbol, candle.Interval)
+				continue
+			}
+			b.disconnect(sub, ReasonSlowConsumer,
+				fmt.Sprintf("client buffer of %d candles is full", cap(sub.C)))
+		}
+	}
+}
+
+// disconnect removes a client and closes its channel with a notice of why.
+// Callers must hold the lock
+func (b *Builder) disconnect(sub *Subscription, reason, message string) {
+	delete(b.subscribers, sub)
+
+	sub.mutex.Lock()
+	sub.notice = &models.DisconnectNotice{
+		Reason:  reason,
+		Message: message,
+		Dropped: sub.dropped,
+	}
+	sub.mutex.Unlock()
+	close(sub.C)
+
+	b.logger.Warnf("Disconnected %s %s candle client (%s) after %d dropped candles",
+		sub.symbol, sub.interval, reason, sub.dropped)
+}
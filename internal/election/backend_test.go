@@ -0,0 +1,116 @@
+package election
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBackendLease checks the lease semantics shared by every backend. expire
+// makes the current lease run out
+func testBackendLease(t *testing.T, backend Backend, expire func()) {
+	ctx := context.Background()
+	ttl := time.Minute
+
+	acquired, err := backend.Acquire(ctx, "a", ttl)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// The holder renews, others wait
+	acquired, err = backend.Acquire(ctx, "b", ttl)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	acquired, err = backend.Acquire(ctx, "a", ttl)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// An expired lease can be taken over
+	expire()
+	acquired, err = backend.Acquire(ctx, "b", ttl)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// Only the holder can release
+	require.NoError(t, backend.Release(ctx, "a"))
+	acquired, err = backend.Acquire(ctx, "a", ttl)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	require.NoError(t, backend.Release(ctx, "b"))
+	acquired, err = backend.Acquire(ctx, "a", ttl)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func TestFileBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "election", "leader.lock")
+	backend := NewFileBackend(path)
+
+	testBackendLease(t, backend, func() {
+		// Rewrite the lease as if it had been taken long ago
+		require.NoError(t, backend.update(context.Background(), func(lease *fileLease, now time.Time) bool {
+			lease.Expires = now.Add(-time.Second)
+			return true
+		}))
+	})
+}
+
+func TestFileBackendIgnoresCorruptLease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader.lock")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o644))
+
+	acquired, err := NewFileBackend(path).Acquire(context.Background(), "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func TestFileBackendHonorsContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leader.lock")
+	backend := NewFileBackend(path)
+
+	// Another process holding the lock stalls candidates only until their deadline
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	require.NoError(t, err)
+	defer file.Close()
+	locked, err := tryLockFile(file)
+	require.NoError(t, err)
+	require.True(t, locked)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = backend.Acquire(ctx, "a", time.Minute)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, unlockFile(file))
+	acquired, err := backend.Acquire(context.Background(), "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func TestRedisBackend(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	backend := NewRedisBackend(client, "leader")
+
+	testBackendLease(t, backend, func() {
+		server.FastForward(2 * time.Minute)
+	})
+}
+
+func TestRedisBackendUnavailable(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+	server.Close()
+
+	_, err := NewRedisBackend(client, "leader").Acquire(context.Background(), "a", time.Minute)
+	assert.Error(t, err)
+}
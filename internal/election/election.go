@@ -0,0 +1,233 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"bitcoin-price-streamer/internal/metrics"
+	"bitcoin-price-streamer/internal/utils"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// Backend stores the lease candidates compete for. Only one holder can have
+// an unexpired lease at a time
+type Backend interface {
+	// Name identifies the backend in logs
+	Name() string
+	// Acquire takes the lease for holder, or renews it if holder already has
+	// it, until ttl from now. It returns false if another holder's lease has
+	// not expired yet
+	Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error)
+	// Release gives up the lease if holder has it, so another candidate can
+	// take over without waiting for it to expire
+	Release(ctx context.Context, holder string) error
+}
+
+// Options are the identity and timing of a candidate. A leader that dies is
+// replaced within LeaseTTL plus RenewInterval
+type Options struct {
+	ID            string
+	LeaseTTL      time.Duration
+	RenewInterval time.Duration
+}
+
+// electionMetrics reports whether this instance leads and how often that changed
+type electionMetrics struct {
+	leader      *metrics.Gauge
+	transitions *metrics.Counter
+}
+
+// newElectionMetrics registers the election metrics with registry
+func newElectionMetrics(registry *metrics.Registry) electionMetrics {
+	return electionMetrics{
+		leader:      registry.Gauge("election_leader", "1 if this instance holds the leader lease"),
+		transitions: registry.Counter("election_transitions_total", "Times this instance gained or lost leadership"),
+	}
+}
+
+// Elector campaigns for a lease and runs a task only while it holds it
+type Elector struct {
+	backend Backend
+	options Options
+	leading atomic.Bool
+	metrics electionMetrics
+	logger  *logrus.Logger
+}
+
+// New creates an elector for backend. The renew interval is capped at a third
+// of the lease TTL, so a leader gets several chances to renew before its
+// lease expires
+func New(backend Backend, options Options, logger *logrus.Logger) *Elector {
+	if options.ID == "" {
+		options.ID = utils.InstanceID()
+	}
+	if options.LeaseTTL <= 0 {
+		options.LeaseTTL = 15 * time.Second
+	}
+	if options.RenewInterval <= 0 || options.RenewInterval > options.LeaseTTL/3 {
+		options.RenewInterval = options.LeaseTTL / 3
+	}
+
+	return &Elector{
+		backend: backend,
+		options: options,
+		metrics: newElectionMetrics(metrics.NewRegistry()),
+		logger:  logger,
+	}
+}
+
+// NewFromEnv builds an elector with the backend selected by LEADER_ELECTION:
+// 'none' (always lead), 'file' (a locked lease file on a shared host) or
+// 'redis' (a lease key in Redis shared by every host)
+func NewFromEnv(logger *logrus.Logger) (*Elector, error) {
+	var backend Backend
+	switch name := utils.GetEnvString("LEADER_ELECTION", "none"); name {
+	case "none":
+		backend = SoleBackend{}
+	case "file":
+		backend = NewFileBackend(utils.GetEnvString("LEADER_LOCK_FILE", "./data/leader.lock"))
+	case "redis":
+		options, err := redis.ParseURL(utils.GetEnvString("LEADER_REDIS_URL", "redis://localhost:6379/0"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse LEADER_REDIS_URL: %w", err)
+		}
+		backend = NewRedisBackend(redis.NewClient(options), utils.GetEnvString("LEADER_KEY", "price-streamer:leader"))
+	default:
+		return nil, fmt.Errorf("unknown leader election backend: %s", name)
+	}
+
+	options := Options{
+		ID:            utils.InstanceID(),
+		LeaseTTL:      time.Duration(utils.GetEnvInt("LEADER_LEASE_MS", 15000)) * time.Millisecond,
+		RenewInterval: time.Duration(utils.GetEnvInt("LEADER_RENEW_MS", 5000)) * time.Millisecond,
+	}
+	return New(backend, options, logger), nil
+}
+
+// SetMetrics makes the elector record its metrics in registry
+func (e *Elector) SetMetrics(registry *metrics.Registry) {
+	e.metrics = newElectionMetrics(registry)
+}
+
+// Shared reports whether the election spans instances, so that some of them
+// follow instead of leading
+func (e *Elector) Shared() bool {
+	_, sole := e.backend.(SoleBackend)
+	return !sole
+}
+
+// IsLeader reports whether this instance currently holds the lease
+func (e *Elector) IsLeader() bool {
+	return e.leading.Load()
+}
+
+// Run campaigns until ctx is done, calling lead whenever this instance gains
+// the lease. The context passed to lead is cancelled as soon as a renewal
+// fails, which is before the lease expires, and Run waits for lead to return
+// before campaigning again. As long as lead returns promptly once cancelled,
+// it never runs alongside another leader. The lease is released when ctx is
+// done
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	var stepDown func()
+
+	ticker := time.NewTicker(e.options.RenewInterval)
+	defer ticker.Stop()
+
+	e.logger.Infof("Campaigning for leadership as %s with the %s backend", e.options.ID, e.backend.Name())
+	for {
+		acquired, err := e.acquire(ctx)
+		if err != nil && ctx.Err() == nil {
+			e.logger.Warnf("Failed to acquire leader lease: %v", err)
+		}
+
+		switch {
+		case acquired && stepDown == nil:
+			stepDown = e.startLeading(ctx, lead)
+		case !acquired && stepDown != nil && ctx.Err() == nil:
+			stepDown()
+			stepDown = nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if stepDown != nil {
+				stepDown()
+				e.release()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// startLeading runs lead in the background, returning a function that
+// cancels it and waits for it to return
+func (e *Elector) startLeading(ctx context.Context, lead func(ctx context.Context)) func() {
+	leadCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	e.setLeading(true)
+	go func() {
+		defer close(done)
+		lead(leadCtx)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		e.setLeading(false)
+	}
+}
+
+// acquire takes or renews the lease, giving up before the lease would expire
+func (e *Elector) acquire(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.options.RenewInterval)
+	defer cancel()
+
+	return e.backend.Acquire(ctx, e.options.ID, e.options.LeaseTTL)
+}
+
+// release gives up the lease on shutdown
+func (e *Elector) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.options.RenewInterval)
+	defer cancel()
+
+	if err := e.backend.Release(ctx, e.options.ID); err != nil {
+		e.logger.Warnf("Failed to release leader lease: %v", err)
+	}
+}
+
+// setLeading records a change of leadership
+func (e *Elector) setLeading(leading bool) {
+	e.leading.Store(leading)
+	e.metrics.transitions.Inc()
+	if leading {
+		e.metrics.leader.Set(1)
+		e.logger.Infof("%s became the leader", e.options.ID)
+	} else {
+		e.metrics.leader.Set(0)
+		e.logger.Infof("%s is no longer the leader", e.options.ID)
+	}
+}
+
+// SoleBackend grants the lease to every candidate, for a single instance
+type SoleBackend struct{}
+
+// Name identifies the backend in logs
+func (SoleBackend) Name() string {
+	return "none"
+}
+
+// Acquire always succeeds
+func (SoleBackend) Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+// Release does nothing
+func (SoleBackend) Release(ctx context.Context, holder string) error {
+	return nil
+}
@@ -0,0 +1,179 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bitcoin-price-streamer/internal/metrics"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testLeaseTTL = 150 * time.Millisecond
+	testRenew    = 30 * time.Millisecond
+)
+
+// failingBackend wraps a backend and fails every call once failing is set,
+// like a leader cut off from the shared store
+type failingBackend struct {
+	Backend
+	failing atomic.Bool
+}
+
+func (f *failingBackend) Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	if f.failing.Load() {
+		return false, errors.New("store unreachable")
+	}
+	return f.Backend.Acquire(ctx, holder, ttl)
+}
+
+// candidate runs an elector in the background, counting the instances
+// currently leading in active
+type candidate struct {
+	elector *Elector
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func startCandidate(t *testing.T, backend Backend, id string, active *atomic.Int32) *candidate {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	elector := New(backend, Options{ID: id, LeaseTTL: testLeaseTTL, RenewInterval: testRenew}, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &candidate{elector: elector, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(c.done)
+		elector.Run(ctx, func(ctx context.Context) {
+			if active.Add(1) > 1 {
+				t.Errorf("%s leads alongside another leader", id)
+			}
+			<-ctx.Done()
+			active.Add(-1)
+		})
+	}()
+	t.Cleanup(c.stop)
+	return c
+}
+
+func (c *candidate) stop() {
+	c.cancel()
+	<-c.done
+}
+
+func TestOnlyOneCandidateLeads(t *testing.T) {
+	backend := NewFileBackend(filepath.Join(t.TempDir(), "leader.lock"))
+	var active atomic.Int32
+
+	a := startCandidate(t, backend, "a", &active)
+	require.Eventually(t, a.elector.IsLeader, time.Second, 5*time.Millisecond)
+	b := startCandidate(t, backend, "b", &active)
+
+	// The follower keeps waiting while the leader renews its lease
+	time.Sleep(3 * testLeaseTTL)
+	assert.True(t, a.elector.IsLeader())
+	assert.False(t, b.elector.IsLeader())
+	assert.Equal(t, int32(1), active.Load())
+
+	// A leader shutting down releases the lease, so the follower takes over
+	// at its next attempt
+	stopped := time.Now()
+	a.stop()
+	assert.False(t, a.elector.IsLeader())
+	require.Eventually(t, b.elector.IsLeader, time.Second, 5*time.Millisecond)
+	assert.Less(t, time.Since(stopped), testLeaseTTL)
+}
+
+func TestFollowerTakesOverFromDeadLeader(t *testing.T) {
+	backend := NewFileBackend(filepath.Join(t.TempDir(), "leader.lock"))
+	cutOff := &failingBackend{Backend: backend}
+	var active atomic.Int32
+
+	a := startCandidate(t, cutOff, "a", &active)
+	require.Eventually(t, a.elector.IsLeader, time.Second, 5*time.Millisecond)
+	b := startCandidate(t, backend, "b", &active)
+
+	// The leader stops leading as soon as it cannot renew, and the follower
+	// takes over once the lease expires
+	died := time.Now()
+	cutOff.failing.Store(true)
+	require.Eventually(t, func() bool { return !a.elector.IsLeader() }, time.Second, 5*time.Millisecond)
+	require.Eventually(t, b.elector.IsLeader, time.Second, 5*time.Millisecond)
+	assert.GreaterOrEqual(t, time.Since(died), testLeaseTTL-testRenew)
+	assert.Less(t, time.Since(died), testLeaseTTL+3*testRenew)
+}
+
+func TestElectorMetrics(t *testing.T) {
+	logger := logrus.New()
+	registry := metrics.NewRegistry()
+	elector := New(SoleBackend{}, Options{ID: "a", LeaseTTL: testLeaseTTL}, logger)
+	elector.SetMetrics(registry)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	led := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		elector.Run(ctx, func(ctx context.Context) {
+			close(led)
+			<-ctx.Done()
+		})
+	}()
+
+	<-led
+	assert.Equal(t, int64(1), registry.Gauge("election_leader", "").Value())
+	cancel()
+	<-done
+	assert.Equal(t, int64(0), registry.Gauge("election_leader", "").Value())
+	assert.Equal(t, int64(2), registry.Counter("election_transitions_total", "").Value())
+}
+
+func TestNewCapsRenewInterval(t *testing.T) {
+	elector := New(SoleBackend{}, Options{LeaseTTL: 9 * time.Second, RenewInterval: time.Minute}, logrus.New())
+	assert.Equal(t, 3*time.Second, elector.options.RenewInterval)
+	assert.NotEmpty(t, elector.options.ID)
+}
+
+func TestNewFromEnv(t *testing.T) {
+	logger := logrus.New()
+
+	elector, err := NewFromEnv(logger)
+	require.NoError(t, err)
+	assert.Equal(t, "none", elector.backend.Name())
+	assert.False(t, elector.Shared())
+	assert.Equal(t, 15*time.Second, elector.options.LeaseTTL)
+	assert.Equal(t, 5*time.Second, elector.options.RenewInterval)
+
+	t.Setenv("LEADER_ELECTION", "file")
+	t.Setenv("LEADER_LOCK_FILE", filepath.Join(t.TempDir(), "leader.lock"))
+	t.Setenv("LEADER_LEASE_MS", "3000")
+	t.Setenv("INSTANCE_ID", "replica-1")
+	elector, err = NewFromEnv(logger)
+	require.NoError(t, err)
+	assert.Equal(t, "file", elector.backend.Name())
+	assert.True(t, elector.Shared())
+	assert.Equal(t, "replica-1", elector.options.ID)
+	assert.Equal(t, time.Second, elector.options.RenewInterval)
+
+	t.Setenv("LEADER_ELECTION", "redis")
+	elector, err = NewFromEnv(logger)
+	require.NoError(t, err)
+	assert.Equal(t, "redis", elector.backend.Name())
+
+	t.Setenv("LEADER_REDIS_URL", "http://localhost")
+	_, err = NewFromEnv(logger)
+	assert.Error(t, err)
+
+	t.Setenv("LEADER_ELECTION", "zookeeper")
+	_, err = NewFromEnv(logger)
+	assert.Error(t, err)
+}
@@ -0,0 +1,128 @@
+package election
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockRetryInterval is how often a locked lease file is retried
+const lockRetryInterval = 10 * time.Millisecond
+
+// FileBackend keeps the lease in a file that candidates lock while reading
+// and writing it, for replicas sharing a host or a volume with working locks
+type FileBackend struct {
+	path string
+}
+
+// fileLease is the content of the lease file
+type fileLease struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+}
+
+// NewFileBackend creates a backend keeping the lease in the file at path
+func NewFileBackend(path string) *FileBackend {
+	return &FileBackend{path: path}
+}
+
+// Name identifies the backend in logs
+func (f *FileBackend) Name() string {
+	return "file"
+}
+
+// Acquire takes or renews the lease for holder unless another holder's lease
+// has not expired
+func (f *FileBackend) Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	acquired := false
+	err := f.update(ctx, func(lease *fileLease, now time.Time) bool {
+		if lease.Holder != holder && lease.Holder != "" && now.Before(lease.Expires) {
+			return false
+		}
+		lease.Holder = holder
+		lease.Expires = now.Add(ttl)
+		acquired = true
+		return true
+	})
+	return acquired, err
+}
+
+// Release clears the lease if holder has it
+func (f *FileBackend) Release(ctx context.Context, holder string) error {
+	return f.update(ctx, func(lease *fileLease, now time.Time) bool {
+		if lease.Holder != holder {
+			return false
+		}
+		*lease = fileLease{}
+		return true
+	})
+}
+
+// update locks the lease file and lets fn change the lease, writing it back
+// if fn returns true. A missing or unreadable lease counts as expired
+func (f *FileBackend) update(ctx context.Context, fn func(lease *fileLease, now time.Time) bool) error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create lease directory: %w", err)
+	}
+	file, err := os.OpenFile(f.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open lease file: %w", err)
+	}
+	defer file.Close()
+
+	if err := lockFile(ctx, file); err != nil {
+		return fmt.Errorf("failed to lock lease file: %w", err)
+	}
+	defer unlockFile(file)
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return fmt.Errorf("failed to read lease file: %w", err)
+	}
+	var lease fileLease
+	if len(data) > 0 && json.Unmarshal(data, &lease) != nil {
+		lease = fileLease{}
+	}
+
+	if !fn(&lease, time.Now()) {
+		return nil
+	}
+
+	if data, err = json.Marshal(lease); err != nil {
+		return fmt.Errorf("failed to encode lease: %w", err)
+	}
+	if err := file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to write lease file: %w", err)
+	}
+	if _, err := file.WriteAt(data, 0); err != nil {
+		return fmt.Errorf("failed to write lease file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync lease file: %w", err)
+	}
+	return nil
+}
+
+// lockFile takes an exclusive lock on file, retrying while another process
+// holds it until ctx is done
+func lockFile(ctx context.Context, file *os.File) error {
+	ticker := time.NewTicker(lockRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil || locked {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package election
+
+import (
+	"errors"
+	"os"
+)
+
+// errLocksUnsupported is returned by the file backend where flock is missing
+var errLocksUnsupported = errors.New("file locks are not supported on this platform")
+
+// tryLockFile fails, file locks are only implemented on Unix
+func tryLockFile(file *os.File) (bool, error) {
+	return false, errLocksUnsupported
+}
+
+// unlockFile fails, file locks are only implemented on Unix
+func unlockFile(file *os.File) error {
+	return errLocksUnsupported
+}
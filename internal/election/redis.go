@@ -0,0 +1,66 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// acquireScript sets the lease key to the holder with a TTL unless another
+// holder has it. Keys expire on the server, so candidates' clocks don't matter
+var acquireScript = redis.NewScript(`
+local holder = redis.call("GET", KEYS[1])
+if holder and holder ~= ARGV[1] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1
+`)
+
+// releaseScript deletes the lease key if the holder has it
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisBackend keeps the lease in a Redis key with an expiry, for replicas on
+// different hosts
+type RedisBackend struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisBackend creates a backend keeping the lease in key through client
+func NewRedisBackend(client *redis.Client, key string) *RedisBackend {
+	return &RedisBackend{
+		client: client,
+		key:    key,
+	}
+}
+
+// Name identifies the backend in logs
+func (r *RedisBackend) Name() string {
+	return "redis"
+}
+
+// Acquire takes or renews the lease for holder unless another holder's lease
+// has not expired
+func (r *RedisBackend) Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	acquired, err := acquireScript.Run(ctx, r.client, []string{r.key}, holder, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire %s: %w", r.key, err)
+	}
+	return acquired == 1, nil
+}
+
+// Release deletes the lease if holder has it
+func (r *RedisBackend) Release(ctx context.Context, holder string) error {
+	if err := releaseScript.Run(ctx, r.client, []string{r.key}, holder).Err(); err != nil {
+		return fmt.Errorf("failed to release %s: %w", r.key, err)
+	}
+	return nil
+}
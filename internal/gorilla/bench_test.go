@@ -0,0 +1,30 @@
+package gorilla
+
+import (
+	"testing"
+)
+
+const benchPoints = 10000
+
+func BenchmarkEncode(b *testing.B) {
+	points := pricePoints(benchPoints)
+	var size int
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		size = len(encode(points))
+	}
+	b.ReportMetric(float64(size)/benchPoints, "bytes/point")
+}
+
+func BenchmarkDecode(b *testing.B) {
+	block := encode(pricePoints(benchPoints))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := block.Iterate(func(Point) bool { return true }); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(len(block))/benchPoints, "bytes/point")
+}
@@ -0,0 +1,78 @@
+package gorilla
+
+import (
+	"errors"
+)
+
+// errShortBlock reports a block that ends before all its points were decoded
+var errShortBlock = errors.New("gorilla: block is truncated")
+
+// bitWriter appends bits most significant first to a byte slice
+type bitWriter struct {
+	buf []byte
+	// free is the number of unused low bits in the last byte
+	free uint8
+}
+
+// writeBit appends a single bit
+func (w *bitWriter) writeBit(bit bool) {
+	if w.free == 0 {
+		w.buf = append(w.buf, 0)
+		w.free = 8
+	}
+	w.free--
+	if bit {
+		w.buf[len(w.buf)-1] |= 1 << w.free
+	}
+}
+
+// writeBits appends the low n bits of v
+func (w *bitWriter) writeBits(v uint64, n int) {
+	for n > 0 {
+		if w.free == 0 {
+			w.buf = append(w.buf, 0)
+			w.free = 8
+		}
+
+		// Fill as much of the current byte as possible at once
+		take := min(n, int(w.free))
+		chunk := byte(v>>(n-take)) & byte(1<<take-1)
+		w.free -= uint8(take)
+		w.buf[len(w.buf)-1] |= chunk << w.free
+		n -= take
+	}
+}
+
+// bitReader reads bits most significant first from a byte slice
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+// readBit reads a single bit
+func (r *bitReader) readBit() (bool, error) {
+	if r.pos >= len(r.data)*8 {
+		return false, errShortBlock
+	}
+	bit := r.data[r.pos/8]&(0x80>>(r.pos%8)) != 0
+	r.pos++
+	return bit, nil
+}
+
+// readBits reads n bits into the low bits of the result
+func (r *bitReader) readBits(n int) (uint64, error) {
+	if r.pos+n > len(r.data)*8 {
+		return 0, errShortBlock
+	}
+
+	var v uint64
+	for n > 0 {
+		offset := r.pos % 8
+		take := min(n, 8-offset)
+		chunk := (r.data[r.pos/8] >> (8 - offset - take)) & byte(1<<take-1)
+		v = v<<take | uint64(chunk)
+		r.pos += take
+		n -= take
+	}
+	return v, nil
+}
@@ -0,0 +1,351 @@
+package gorilla
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Point is one sample of a price series
+type Point struct {
+	Timestamp time.Time
+	Seq       uint64
+	Value     float64
+}
+
+// Encoder compresses points into a block. Timestamps (at millisecond
+// precision) and sequence numbers are stored as delta-of-deltas, which is a
+// single bit for evenly spaced points, and values as the XOR with the
+// previous value, which shares most bits for a slowly moving price
+type Encoder struct {
+	w     bitWriter
+	count int
+
+	prevTime  int64
+	timeDelta int64
+	prevSeq   int64
+	seqDelta  int64
+
+	prevValue    uint64
+	prevLeading  int
+	prevTrailing int
+}
+
+// NewEncoder creates an encoder for an empty block
+func NewEncoder() *Encoder {
+	return &Encoder{prevLeading: -1}
+}
+
+// Append adds a point to the block. Points must be appended in time order
+func (e *Encoder) Append(p Point) {
+	t := p.Timestamp.UnixMilli()
+	seq := int64(p.Seq)
+	value := math.Float64bits(p.Value)
+
+	if e.count == 0 {
+		e.w.writeBits(uint64(t), 64)
+		e.w.writeBits(uint64(seq), 64)
+		e.w.writeBits(value, 64)
+	} else {
+		delta := t - e.prevTime
+		e.writeDoD(delta - e.timeDelta)
+		e.timeDelta = delta
+
+		delta = seq - e.prevSeq
+		e.writeDoD(delta - e.seqDelta)
+		e.seqDelta = delta
+
+		e.writeXOR(value)
+	}
+
+	e.prevTime = t
+	e.prevSeq = seq
+	e.prevValue = value
+	e.count++
+}
+
+// dodBuckets are the bit widths of delta-of-delta values by control prefix
+// length: '10' holds 7 bits, '110' 9 bits, '1110' 12 bits and '1111' 64 bits
+var dodBuckets = []int{7, 9, 12}
+
+// writeDoD writes a delta-of-delta with a variable-length control prefix
+func (e *Encoder) writeDoD(dod int64) {
+	if dod == 0 {
+		e.w.writeBit(false)
+		return
+	}
+
+	for _, width := range dodBuckets {
+		e.w.writeBit(true)
+		if fitsSigned(dod, width) {
+			e.w.writeBit(false)
+			e.w.writeBits(uint64(dod), width)
+			return
+		}
+	}
+	e.w.writeBit(true)
+	e.w.writeBits(uint64(dod), 64)
+}
+
+// fitsSigned reports whether v is representable in width bits of two's complement
+func fitsSigned(v int64, width int) bool {
+	limit := int64(1) << (width - 1)
+	return v >= -limit && v < limit
+}
+
+// writeXOR writes a value as its XOR with the previous value. A '0' means an
+// unchanged value; '10' reuses the previous leading/trailing zero window; '11'
+// is followed by 5 bits of leading zeros, 6 bits of meaningful length (0 for
+// 64) and the meaningful bits
+func (e *Encoder) writeXOR(value uint64) {
+	xor := value ^ e.prevValue
+	if xor == 0 {
+		e.w.writeBit(false)
+		return
+	}
+	e.w.writeBit(true)
+
+	leading := min(bits.LeadingZeros64(xor), 31)
+	trailing := bits.TrailingZeros64(xor)
+
+	if e.prevLeading >= 0 && leading >= e.prevLeading && trailing >= e.prevTrailing {
+		e.w.writeBit(false)
+		e.w.writeBits(xor>>e.prevTrailing, 64-e.prevLeading-e.prevTrailing)
+		return
+	}
+
+	meaningful := 64 - leading - trailing
+	e.w.writeBit(true)
+	e.w.writeBits(uint64(leading), 5)
+	e.w.writeBits(uint64(meaningful&63), 6)
+	e.w.writeBits(xor>>trailing, meaningful)
+
+	e.prevLeading = leading
+	e.prevTrailing = trailing
+}
+
+// Len returns the number of points in the block
+func (e *Encoder) Len() int {
+	return e.count
+}
+
+// Size returns the encoded size of the block in bytes
+func (e *Encoder) Size() int {
+	return binary.PutUvarint(make([]byte, binary.MaxVarintLen64), uint64(e.count)) + len(e.w.buf)
+}
+
+// Block returns a copy of the encoded block: the point count as a uvarint
+// followed by the bit stream
+func (e *Encoder) Block() Block {
+	block := binary.AppendUvarint(make([]byte, 0, e.Size()), uint64(e.count))
+	return append(block, e.w.buf...)
+}
+
+// Block is an immutable compressed run of points
+type Block []byte
+
+// Len returns the number of points in the block
+func (b Block) Len() int {
+	count, n := binary.Uvarint(b)
+	if n <= 0 {
+		return 0
+	}
+	return int(count)
+}
+
+// Points decodes every point in the block
+func (b Block) Points() ([]Point, error) {
+	var points []Point
+	err := b.Iterate(func(p Point) bool {
+		points = append(points, p)
+		return true
+	})
+	return points, err
+}
+
+// Iterate decodes points in order, calling fn until it returns false
+func (b Block) Iterate(fn func(Point) bool) error {
+	count, n := binary.Uvarint(b)
+	if n <= 0 {
+		return errShortBlock
+	}
+	if count == 0 {
+		return nil
+	}
+
+	d := decoder{r: bitReader{data: b[n:]}, prevLeading: -1}
+	for i := uint64(0); i < count; i++ {
+		p, err := d.next(i == 0)
+		if err != nil {
+			return err
+		}
+		if !fn(p) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// decoder reverses the encoding of an Encoder
+type decoder struct {
+	r bitReader
+
+	prevTime  int64
+	timeDelta int64
+	prevSeq   int64
+	seqDelta  int64
+
+	prevValue    uint64
+	prevLeading  int
+	prevTrailing int
+}
+
+// next decodes the following point
+func (d *decoder) next(first bool) (Point, error) {
+	if first {
+		t, err := d.r.readBits(64)
+		if err != nil {
+			return Point{}, err
+		}
+		seq, err := d.r.readBits(64)
+		if err != nil {
+			return Point{}, err
+		}
+		value, err := d.r.readBits(64)
+		if err != nil {
+			return Point{}, err
+		}
+		d.prevTime, d.prevSeq, d.prevValue = int64(t), int64(seq), value
+	} else {
+		dod, err := d.readDoD()
+		if err != nil {
+			return Point{}, err
+		}
+		d.timeDelta += dod
+		d.prevTime += d.timeDelta
+
+		dod, err = d.readDoD()
+		if err != nil {
+			return Point{}, err
+		}
+		d.seqDelta += dod
+		d.prevSeq += d.seqDelta
+
+		if err := d.readXOR(); err != nil {
+			return Point{}, err
+		}
+	}
+
+	return Point{
+		Timestamp: time.UnixMilli(d.prevTime),
+		Seq:       uint64(d.prevSeq),
+		Value:     math.Float64frombits(d.prevValue),
+	}, nil
+}
+
+// readDoD reads a delta-of-delta written by writeDoD
+func (d *decoder) readDoD() (int64, error) {
+	bit, err := d.r.readBit()
+	if err != nil || !bit {
+		return 0, err
+	}
+
+	for _, width := range dodBuckets {
+		bit, err := d.r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		if !bit {
+			v, err := d.r.readBits(width)
+			if err != nil {
+				return 0, err
+			}
+			// Sign-extend the two's complement value
+			return int64(v<<(64-width)) >> (64 - width), nil
+		}
+	}
+
+	v, err := d.r.readBits(64)
+	return int64(v), err
+}
+
+// readXOR reads a value written by writeXOR
+func (d *decoder) readXOR() error {
+	changed, err := d.r.readBit()
+	if err != nil || !changed {
+		return err
+	}
+
+	fresh, err := d.r.readBit()
+	if err != nil {
+		return err
+	}
+	if fresh {
+		leading, err := d.r.readBits(5)
+		if err != nil {
+			return err
+		}
+		meaningful, err := d.r.readBits(6)
+		if err != nil {
+			return err
+		}
+		if meaningful == 0 {
+			meaningful = 64
+		}
+		d.prevLeading = int(leading)
+		d.prevTrailing = 64 - int(leading) - int(meaningful)
+	} else if d.prevLeading < 0 {
+		return fmt.Errorf("gorilla: reused XOR window before one was set")
+	}
+
+	width := 64 - d.prevLeading - d.prevTrailing
+	xor, err := d.r.readBits(width)
+	if err != nil {
+		return err
+	}
+	d.prevValue ^= xor << d.prevTrailing
+	return nil
+}
+
+// errChecksum reports a block whose stored checksum does not match its contents
+var errChecksum = errors.New("gorilla: block checksum mismatch")
+
+// WriteTo writes the block framed by its length and CRC32 so it can be read
+// back from a file with ReadBlock
+func (b Block) WriteTo(w io.Writer) (int64, error) {
+	header := make([]byte, 8)
+	binary.LittleEndian.PutUint32(header[0:4], uint32(len(b)))
+	binary.LittleEndian.PutUint32(header[4:8], crc32.ChecksumIEEE(b))
+
+	n, err := w.Write(header)
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := w.Write(b)
+	return int64(n + m), err
+}
+
+// ReadBlock reads a block written by WriteTo
+func ReadBlock(r io.Reader) (Block, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	block := make(Block, binary.LittleEndian.Uint32(header[0:4]))
+	if _, err := io.ReadFull(r, block); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(block) != binary.LittleEndian.Uint32(header[4:8]) {
+		return nil, errChecksum
+	}
+	return block, nil
+}
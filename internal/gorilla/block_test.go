@@ -0,0 +1,193 @@
+package gorilla
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pricePoints generates a realistic series: 5-second polling with jitter,
+// sequence numbers interleaved with other symbols and a random walk price
+func pricePoints(n int) []Point {
+	rng := rand.New(rand.NewSource(42))
+	points := make([]Point, n)
+
+	t := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	seq := uint64(1)
+	price := 42000.0
+	for i := range points {
+		points[i] = Point{Timestamp: t, Seq: seq, Value: price}
+
+		t = t.Add(5*time.Second + time.Duration(rng.Intn(20)-10)*time.Millisecond)
+		seq += 7
+		if rng.Intn(3) > 0 {
+			price = math.Round((price+rng.NormFloat64()*15)*100) / 100
+		}
+	}
+	return points
+}
+
+func encode(points []Point) Block {
+	encoder := NewEncoder()
+	for _, p := range points {
+		encoder.Append(p)
+	}
+	return encoder.Block()
+}
+
+func TestBitsRoundTrip(t *testing.T) {
+	var w bitWriter
+	w.writeBit(true)
+	w.writeBits(0x5, 3)
+	w.writeBits(math.MaxUint64, 64)
+	w.writeBits(0x1234, 13)
+
+	r := bitReader{data: w.buf}
+	bit, err := r.readBit()
+	require.NoError(t, err)
+	assert.True(t, bit)
+
+	for _, expected := range []struct {
+		value uint64
+		width int
+	}{{0x5, 3}, {math.MaxUint64, 64}, {0x1234, 13}} {
+		v, err := r.readBits(expected.width)
+		require.NoError(t, err)
+		assert.Equal(t, expected.value, v)
+	}
+
+	_, err = r.readBits(8)
+	assert.Error(t, err)
+}
+
+func TestBlockRoundTrip(t *testing.T) {
+	points := pricePoints(1000)
+	block := encode(points)
+
+	decoded, err := block.Points()
+	require.NoError(t, err)
+	require.Len(t, decoded, len(points))
+	for i := range points {
+		assert.True(t, points[i].Timestamp.Equal(decoded[i].Timestamp), "timestamp %d", i)
+		assert.Equal(t, points[i].Seq, decoded[i].Seq)
+		assert.Equal(t, points[i].Value, decoded[i].Value)
+	}
+
+	assert.Equal(t, 1000, block.Len())
+	// Raw points take 32 bytes each (plus the symbol string when stored)
+	assert.Less(t, len(block), 1000*8, "expected under 8 bytes per point")
+}
+
+func TestBlockEdgeCases(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := []Point{
+		{Timestamp: base, Seq: 1, Value: 1.5},
+		// Large jumps in time and sequence, and special float values
+		{Timestamp: base.Add(240 * time.Hour), Seq: 1 << 40, Value: math.Inf(1)},
+		{Timestamp: base.Add(240 * time.Hour), Seq: 1<<40 + 1, Value: -0.0},
+		{Timestamp: base.Add(240*time.Hour + time.Millisecond), Seq: 1<<40 + 2, Value: math.MaxFloat64},
+		{Timestamp: base.Add(240*time.Hour + time.Millisecond), Seq: 1<<40 + 3, Value: math.SmallestNonzeroFloat64},
+	}
+
+	decoded, err := encode(points).Points()
+	require.NoError(t, err)
+	require.Len(t, decoded, len(points))
+	for i := range points {
+		assert.True(t, points[i].Timestamp.Equal(decoded[i].Timestamp))
+		assert.Equal(t, points[i].Seq, decoded[i].Seq)
+		assert.Equal(t, math.Float64bits(points[i].Value), math.Float64bits(decoded[i].Value))
+	}
+
+	empty, err := NewEncoder().Block().Points()
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestBlockTruncated(t *testing.T) {
+	block := encode(pricePoints(10))
+
+	_, err := block[:len(block)-2].Points()
+	assert.Error(t, err)
+}
+
+func TestBlockFraming(t *testing.T) {
+	var buf bytes.Buffer
+	first := encode(pricePoints(10))
+	second := encode(pricePoints(20))
+
+	_, err := first.WriteTo(&buf)
+	require.NoError(t, err)
+	_, err = second.WriteTo(&buf)
+	require.NoError(t, err)
+
+	data := buf.Bytes()
+	reader := bytes.NewReader(data)
+
+	block, err := ReadBlock(reader)
+	require.NoError(t, err)
+	assert.Equal(t, first, block)
+	block, err = ReadBlock(reader)
+	require.NoError(t, err)
+	assert.Equal(t, 20, block.Len())
+	_, err = ReadBlock(reader)
+	assert.Equal(t, io.EOF, err)
+
+	// Corruption and torn writes are detected
+	data[10] ^= 0xff
+	_, err = ReadBlock(bytes.NewReader(data))
+	assert.Error(t, err)
+	_, err = ReadBlock(bytes.NewReader(data[:12]))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestSeries(t *testing.T) {
+	series := NewSeries(100, 3)
+	points := pricePoints(450)
+	for _, p := range points {
+		series.Append(p)
+	}
+
+	// Three sealed blocks plus 50 points in the active block
+	assert.Equal(t, 350, series.Len())
+	assert.Len(t, series.Blocks(), 4)
+
+	decoded, err := series.Points()
+	require.NoError(t, err)
+	require.Len(t, decoded, 350)
+	assert.Equal(t, points[100].Seq, decoded[0].Seq)
+	assert.Equal(t, points[449].Value, decoded[349].Value)
+	assert.Positive(t, series.Size())
+
+	// The first block was dropped when the fourth was sealed
+	dropped, ok := series.Dropped()
+	require.True(t, ok)
+	assert.Equal(t, points[99].Seq, dropped.Seq)
+	require.Len(t, series.Sealed(), 3)
+
+	// The index bounds every block without decoding it
+	index := series.Index()
+	require.Len(t, index, 3)
+	assert.Equal(t, points[100].Seq, index[0].First.Seq)
+	assert.Equal(t, points[399].Seq, index[2].Last.Seq)
+	assert.True(t, points[399].Timestamp.Truncate(time.Millisecond).Equal(index[2].Last.Timestamp))
+	active, ok := series.Active()
+	require.True(t, ok)
+	assert.Equal(t, points[400].Seq, active.First.Seq)
+	assert.Equal(t, 50, active.Block.Len())
+
+	// Sealed blocks restore into a new series, e.g. after reading them from disk
+	restored := NewSeries(100, 3)
+	for _, block := range series.Sealed() {
+		require.NoError(t, restored.AppendBlock(block))
+	}
+	assert.Equal(t, 300, restored.Len())
+	assert.Equal(t, index[0].Last, restored.Index()[0].Last)
+	_, ok = restored.Dropped()
+	assert.False(t, ok)
+}
@@ -0,0 +1,215 @@
+package gorilla
+
+import (
+	"sync"
+	"time"
+)
+
+// BlockInfo is a block with its first and last points, so reads can pick
+// the blocks covering a range without decoding the others
+type BlockInfo struct {
+	Block Block
+	First Point
+	Last  Point
+}
+
+// Series is an in-memory compressed price series split into blocks of
+// blockSize points. Once maxBlocks sealed blocks exist the oldest is
+// dropped, so the series behaves like a ring buffer of whole blocks
+type Series struct {
+	blockSize   int
+	maxBlocks   int
+	sealed      []BlockInfo
+	active      *Encoder
+	activeFirst Point
+	activeLast  Point
+	dropped     Point
+	hasDropped  bool
+	mutex       sync.RWMutex
+}
+
+// NewSeries creates an empty series
+func NewSeries(blockSize, maxBlocks int) *Series {
+	return &Series{
+		blockSize: blockSize,
+		maxBlocks: maxBlocks,
+		active:    NewEncoder(),
+	}
+}
+
+// Append adds a point, sealing the active block when it is full. It returns
+// the block sealed by this point, or nil if the active block has room left
+func (s *Series) Append(p Point) Block {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Keep the point as it decodes, at millisecond precision
+	p.Timestamp = time.UnixMilli(p.Timestamp.UnixMilli())
+	if s.active.Len() == 0 {
+		s.activeFirst = p
+	}
+	s.activeLast = p
+
+	s.active.Append(p)
+	if s.active.Len() < s.blockSize {
+		return nil
+	}
+
+	block := s.active.Block()
+	s.seal(BlockInfo{Block: block, First: s.activeFirst, Last: s.activeLast})
+	s.active = NewEncoder()
+	return block
+}
+
+// AppendBlock adds an already sealed block, such as one read back from disk
+// with ReadBlock. Its points must follow those already in the series. Empty
+// or undecodable blocks are skipped
+func (s *Series) AppendBlock(block Block) error {
+	info := BlockInfo{Block: block}
+	count := 0
+	if err := block.Iterate(func(p Point) bool {
+		if count == 0 {
+			info.First = p
+		}
+		info.Last = p
+		count++
+		return true
+	}); err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.seal(info)
+	return nil
+}
+
+// seal appends a block to the sealed ones, dropping the oldest beyond
+// maxBlocks. Blocks are never modified once sealed, so slices of sealed
+// handed out by Index stay valid. Callers must hold the lock
+func (s *Series) seal(info BlockInfo) {
+	s.sealed = append(s.sealed, info)
+	if len(s.sealed) <= s.maxBlocks {
+		return
+	}
+
+	excess := len(s.sealed) - s.maxBlocks
+	s.dropped, s.hasDropped = s.sealed[excess-1].Last, true
+	s.sealed = append([]BlockInfo(nil), s.sealed[excess:]...)
+}
+
+// Len returns the number of points in the series
+func (s *Series) Len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	count := s.active.Len()
+	for _, info := range s.sealed {
+		count += info.Block.Len()
+	}
+	return count
+}
+
+// Size returns the number of bytes of compressed data held by the series
+func (s *Series) Size() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	size := s.active.Size()
+	for _, info := range s.sealed {
+		size += len(info.Block)
+	}
+	return size
+}
+
+// Sealed returns the sealed blocks, oldest first
+func (s *Series) Sealed() []Block {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.sealedBlocks()
+}
+
+// sealedBlocks copies the sealed blocks. Callers must hold the lock
+func (s *Series) sealedBlocks() []Block {
+	blocks := make([]Block, len(s.sealed))
+	for i, info := range s.sealed {
+		blocks[i] = info.Block
+	}
+	return blocks
+}
+
+// Blocks returns the sealed blocks followed by a snapshot of the active one
+func (s *Series) Blocks() []Block {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	blocks := s.sealedBlocks()
+	if s.active.Len() > 0 {
+		blocks = append(blocks, s.active.Block())
+	}
+	return blocks
+}
+
+// Index returns the sealed blocks, oldest first, without copying them. The
+// slice must not be modified
+func (s *Series) Index() []BlockInfo {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.sealed[:len(s.sealed):len(s.sealed)]
+}
+
+// Active returns a snapshot of the block being filled, if it holds any points
+func (s *Series) Active() (BlockInfo, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.active.Len() == 0 {
+		return BlockInfo{}, false
+	}
+	return BlockInfo{Block: s.active.Block(), First: s.activeFirst, Last: s.activeLast}, true
+}
+
+// Dropped returns the newest point dropped with the oldest block, if any
+// block was dropped
+func (s *Series) Dropped() (Point, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.dropped, s.hasDropped
+}
+
+// Iterate decodes points oldest first, calling fn until it returns false
+func (s *Series) Iterate(fn func(Point) bool) error {
+	stopped := false
+	for _, block := range s.Blocks() {
+		if err := block.Iterate(func(p Point) bool {
+			stopped = !fn(p)
+			return !stopped
+		}); err != nil {
+			return err
+		}
+		if stopped {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Points decodes every point in the series, oldest first
+func (s *Series) Points() ([]Point, error) {
+	var points []Point
+	err := s.Iterate(func(p Point) bool {
+		points = append(points, p)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return points, nil
+}
@@ -0,0 +1,68 @@
+package grpcapi
+
+import (
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// timestampToProto converts a time, leaving the zero time unset
+func timestampToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// timeFromProto converts a timestamp, mapping an unset one to the zero time
+func timeFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// priceToProto converts a stored price update to its message
+func priceToProto(update models.PriceUpdate) *PriceUpdate {
+	return &PriceUpdate{
+		Seq:               update.Seq,
+		Timestamp:         timestampToProto(update.Timestamp),
+		Price:             update.Price,
+		Symbol:            update.Symbol,
+		Name:              update.Name,
+		Change_24H:        update.Change24h,
+		ChangePercent_24H: update.ChangePercent24h,
+		MarketCap:         update.MarketCap,
+		Volume_24H:        update.Volume24h,
+		Source:            update.Source,
+	}
+}
+
+// gapToProto converts a gap notice to its message
+func gapToProto(gap models.GapNotice) *GapNotice {
+	message := &GapNotice{
+		Symbol:           gap.Symbol,
+		RequestedSeq:     gap.RequestedSeq,
+		MissedThroughSeq: gap.MissedThroughSeq,
+		MissedThrough:    timestampToProto(gap.MissedThrough),
+		ReplayFromSeq:    gap.ReplayFromSeq,
+	}
+	if gap.RequestedSince != nil {
+		message.RequestedSince = timestamppb.New(*gap.RequestedSince)
+	}
+	if gap.ReplayFrom != nil {
+		message.ReplayFrom = timestamppb.New(*gap.ReplayFrom)
+	}
+	return message
+}
+
+// resetToProto converts a reset notice to its message
+func resetToProto(reset models.ResetNotice) *ResetNotice {
+	return &ResetNotice{
+		Reason:       reset.Reason,
+		RequestedSeq: reset.RequestedSeq,
+		LastSeq:      reset.LastSeq,
+	}
+}
@@ -0,0 +1,5 @@
+package grpcapi
+
+// The messages and service stubs are generated from proto/price_streamer.proto
+// with protoc-gen-go and protoc-gen-go-grpc
+//go:generate protoc -I ../../proto --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative price_streamer.proto
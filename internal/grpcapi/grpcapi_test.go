@@ -0,0 +1,242 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/service"
+	"bitcoin-price-streamer/internal/storage"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// mockProvider is a PriceProvider returning a fixed BTC quote
+type mockProvider struct {
+	price float64
+}
+
+func (m *mockProvider) Name() string {
+	return "mock"
+}
+
+func (m *mockProvider) SupportedSymbols() []string {
+	return []string{"BTC"}
+}
+
+func (m *mockProvider) FetchPrices(ctx context.Context, symbols []string) ([]models.PriceUpdate, error) {
+	return []models.PriceUpdate{
+		{Timestamp: time.Now(), Price: m.price, Symbol: "BTC", Name: "Bitcoin"},
+	}, nil
+}
+
+func TestMessagesRoundTrip(t *testing.T) {
+	now := time.Unix(1700000000, 123456789)
+	since := now.Add(-time.Hour)
+
+	update := models.PriceUpdate{
+		Seq:              7,
+		Timestamp:        now,
+		Price:            50000.5,
+		Symbol:           "BTC",
+		Name:             "Bitcoin",
+		Change24h:        -120.25,
+		ChangePercent24h: -0.24,
+		MarketCap:        9.8e11,
+		Volume24h:        2.5e10,
+		Source:           "coindesk",
+	}
+	data, err := proto.Marshal(&StreamPricesResponse{Event: &StreamPricesResponse_Price{Price: priceToProto(update)}})
+	require.NoError(t, err)
+
+	var decoded StreamPricesResponse
+	require.NoError(t, proto.Unmarshal(data, &decoded))
+	price := decoded.GetPrice()
+	require.NotNil(t, price)
+	assert.True(t, now.Equal(price.Timestamp.AsTime()))
+	assert.Equal(t, update.Seq, price.Seq)
+	assert.Equal(t, update.Price, price.Price)
+	assert.Equal(t, update.Change24h, price.Change_24H)
+	assert.Equal(t, update.ChangePercent24h, price.ChangePercent_24H)
+	assert.Equal(t, update.MarketCap, price.MarketCap)
+	assert.Equal(t, update.Volume24h, price.Volume_24H)
+	assert.Equal(t, update.Source, price.Source)
+
+	gap := gapToProto(models.GapNotice{
+		Symbol:           "ETH",
+		RequestedSince:   &since,
+		MissedThroughSeq: 3,
+		MissedThrough:    now,
+		ReplayFromSeq:    4,
+	})
+	assert.True(t, since.Equal(gap.RequestedSince.AsTime()))
+	assert.Nil(t, gap.ReplayFrom)
+	assert.Equal(t, uint64(3), gap.MissedThroughSeq)
+
+	// Unset timestamps map to the zero time and back
+	assert.Nil(t, timestampToProto(time.Time{}))
+	assert.True(t, timeFromProto(nil).IsZero())
+	assert.True(t, since.Equal(timeFromProto(timestampToProto(since))))
+
+	// A field of the wrong wire type is kept as unknown rather than decoded
+	// as garbage, and a truncated one is an error
+	var raw []byte
+	raw = protowire.AppendTag(raw, 1, protowire.VarintType)
+	raw = protowire.AppendVarint(raw, 1)
+	var request StreamPricesRequest
+	require.NoError(t, proto.Unmarshal(raw, &request))
+	assert.Empty(t, request.Symbols)
+	assert.NotEmpty(t, request.ProtoReflect().GetUnknown())
+
+	raw = protowire.AppendTag(nil, 1, protowire.BytesType)
+	raw = protowire.AppendVarint(raw, 10)
+	assert.Error(t, proto.Unmarshal(raw, &request))
+}
+
+func TestServerKeepsDefaultCodec(t *testing.T) {
+	server := NewGRPCServer(nil, nil, logrus.New())
+	defer server.Stop()
+
+	// Other services, such as reflection or health, can share the server
+	info := server.GetServiceInfo()
+	require.Contains(t, info, PriceStreamer_ServiceDesc.ServiceName)
+	assert.Equal(t, "price_streamer.proto", info[PriceStreamer_ServiceDesc.ServiceName].Metadata)
+}
+
+// newTestClient serves a PriceStreamer over an in-memory listener
+func newTestClient(t *testing.T, priceService *service.PriceService, store storage.Store) PriceStreamerClient {
+	listener := bufconn.Listen(1 << 20)
+	server := NewGRPCServer(priceService, store, logrus.New())
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return NewPriceStreamerClient(conn)
+}
+
+func TestGetCurrentPriceAndHistory(t *testing.T) {
+	logger := logrus.New()
+	store := storage.NewSymbolStorage(context.Background(), 100, logger)
+	priceService := service.NewPriceService(store, &mockProvider{price: 1}, logger)
+	client := newTestClient(t, priceService, store)
+	ctx := context.Background()
+
+	_, err := client.GetCurrentPrice(ctx, &GetCurrentPriceRequest{})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	start := time.Unix(1700000000, 0)
+	for i := range 5 {
+		store.Add(models.PriceUpdate{Timestamp: start.Add(time.Duration(i) * time.Second), Price: float64(100 + i), Symbol: "BTC", Name: "Bitcoin"})
+	}
+
+	price, err := client.GetCurrentPrice(ctx, &GetCurrentPriceRequest{Symbol: "btc"})
+	require.NoError(t, err)
+	assert.Equal(t, 104.0, price.Price)
+	assert.Equal(t, uint64(5), price.Seq)
+
+	// Without an order the newest updates come oldest first
+	history, err := client.GetHistory(ctx, &GetHistoryRequest{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, "BTC", history.Symbol)
+	require.Len(t, history.Updates, 2)
+	assert.Equal(t, 103.0, history.Updates[0].Price)
+	assert.Equal(t, 104.0, history.Updates[1].Price)
+
+	// Ascending pages continue through the cursor
+	history, err = client.GetHistory(ctx, &GetHistoryRequest{Limit: 3, Order: Order_ORDER_ASC})
+	require.NoError(t, err)
+	require.Len(t, history.Updates, 3)
+	require.NotEmpty(t, history.NextCursor)
+
+	history, err = client.GetHistory(ctx, &GetHistoryRequest{Limit: 3, Cursor: history.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, history.Updates, 2)
+	assert.Equal(t, 103.0, history.Updates[0].Price)
+	assert.Empty(t, history.NextCursor)
+
+	history, err = client.GetHistory(ctx, &GetHistoryRequest{Until: timestamppb.New(start.Add(time.Second)), Order: Order_ORDER_DESC})
+	require.NoError(t, err)
+	require.Len(t, history.Updates, 2)
+	assert.Equal(t, 101.0, history.Updates[0].Price)
+
+	_, err = client.GetHistory(ctx, &GetHistoryRequest{Cursor: "bogus"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestStreamPrices(t *testing.T) {
+	logger := logrus.New()
+	store := storage.NewSymbolStorage(context.Background(), 100, logger)
+	priceService := service.NewPriceService(store, &mockProvider{price: 200}, logger)
+	client := newTestClient(t, priceService, store)
+
+	now := time.Now()
+	store.Add(models.PriceUpdate{Timestamp: now, Price: 100, Symbol: "BTC", Name: "Bitcoin"})
+	store.Add(models.PriceUpdate{Timestamp: now, Price: 10, Symbol: "ETH", Name: "Ethereum"})
+	store.Add(models.PriceUpdate{Timestamp: now, Price: 101, Symbol: "BTC", Name: "Bitcoin"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	t.Run("Resume And Live", func(t *testing.T) {
+		stream, err := client.StreamPrices(ctx, &StreamPricesRequest{Symbols: []string{"btc"}, AfterSeq: 1})
+		require.NoError(t, err)
+
+		// Only BTC updates after sequence 1 are replayed
+		response, err := stream.Recv()
+		require.NoError(t, err)
+		require.NotNil(t, response.GetPrice())
+		assert.Equal(t, uint64(3), response.GetPrice().Seq)
+		assert.Equal(t, 101.0, response.GetPrice().Price)
+
+		// Wait for the subscription, then fetch a live update
+		time.Sleep(50 * time.Millisecond)
+		pollCtx, cancelPoll := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancelPoll()
+		go priceService.StartPolling(pollCtx)
+
+		response, err = stream.Recv()
+		require.NoError(t, err)
+		require.NotNil(t, response.GetPrice())
+		assert.Equal(t, 200.0, response.GetPrice().Price)
+		assert.Equal(t, uint64(4), response.GetPrice().Seq)
+	})
+
+	t.Run("Invalid Throttle", func(t *testing.T) {
+		stream, err := client.StreamPrices(ctx, &StreamPricesRequest{ThrottleMs: uint32(2 * service.MaxThrottle.Milliseconds())})
+		require.NoError(t, err)
+
+		_, err = stream.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		stream, err := client.StreamPrices(ctx, &StreamPricesRequest{AfterSeq: 99})
+		require.NoError(t, err)
+
+		response, err := stream.Recv()
+		require.NoError(t, err)
+		require.NotNil(t, response.GetReset_())
+		assert.Equal(t, uint64(99), response.GetReset_().RequestedSeq)
+		assert.Equal(t, store.LastSeq(), response.GetReset_().LastSeq)
+	})
+}
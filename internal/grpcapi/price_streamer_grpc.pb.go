@@ -0,0 +1,213 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: price_streamer.proto
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PriceStreamer_GetCurrentPrice_FullMethodName = "/pricestreamer.v1.PriceStreamer/GetCurrentPrice"
+	PriceStreamer_GetHistory_FullMethodName      = "/pricestreamer.v1.PriceStreamer/GetHistory"
+	PriceStreamer_StreamPrices_FullMethodName    = "/pricestreamer.v1.PriceStreamer/StreamPrices"
+)
+
+// PriceStreamerClient is the client API for PriceStreamer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PriceStreamer serves the same prices as the REST, SSE and WebSocket APIs
+type PriceStreamerClient interface {
+	// GetCurrentPrice returns the latest price of a symbol, or NOT_FOUND
+	GetCurrentPrice(ctx context.Context, in *GetCurrentPriceRequest, opts ...grpc.CallOption) (*PriceUpdate, error)
+	// GetHistory returns a page of stored price updates
+	GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (*GetHistoryResponse, error)
+	// StreamPrices replays missed updates from the resume position, preceded
+	// by gap or reset notices, then streams live updates
+	StreamPrices(ctx context.Context, in *StreamPricesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamPricesResponse], error)
+}
+
+type priceStreamerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPriceStreamerClient(cc grpc.ClientConnInterface) PriceStreamerClient {
+	return &priceStreamerClient{cc}
+}
+
+func (c *priceStreamerClient) GetCurrentPrice(ctx context.Context, in *GetCurrentPriceRequest, opts ...grpc.CallOption) (*PriceUpdate, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PriceUpdate)
+	err := c.cc.Invoke(ctx, PriceStreamer_GetCurrentPrice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *priceStreamerClient) GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (*GetHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetHistoryResponse)
+	err := c.cc.Invoke(ctx, PriceStreamer_GetHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *priceStreamerClient) StreamPrices(ctx context.Context, in *StreamPricesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamPricesResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PriceStreamer_ServiceDesc.Streams[0], PriceStreamer_StreamPrices_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamPricesRequest, StreamPricesResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PriceStreamer_StreamPricesClient = grpc.ServerStreamingClient[StreamPricesResponse]
+
+// PriceStreamerServer is the server API for PriceStreamer service.
+// All implementations must embed UnimplementedPriceStreamerServer
+// for forward compatibility.
+//
+// PriceStreamer serves the same prices as the REST, SSE and WebSocket APIs
+type PriceStreamerServer interface {
+	// GetCurrentPrice returns the latest price of a symbol, or NOT_FOUND
+	GetCurrentPrice(context.Context, *GetCurrentPriceRequest) (*PriceUpdate, error)
+	// GetHistory returns a page of stored price updates
+	GetHistory(context.Context, *GetHistoryRequest) (*GetHistoryResponse, error)
+	// StreamPrices replays missed updates from the resume position, preceded
+	// by gap or reset notices, then streams live updates
+	StreamPrices(*StreamPricesRequest, grpc.ServerStreamingServer[StreamPricesResponse]) error
+	mustEmbedUnimplementedPriceStreamerServer()
+}
+
+// UnimplementedPriceStreamerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPriceStreamerServer struct{}
+
+func (UnimplementedPriceStreamerServer) GetCurrentPrice(context.Context, *GetCurrentPriceRequest) (*PriceUpdate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCurrentPrice not implemented")
+}
+func (UnimplementedPriceStreamerServer) GetHistory(context.Context, *GetHistoryRequest) (*GetHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHistory not implemented")
+}
+func (UnimplementedPriceStreamerServer) StreamPrices(*StreamPricesRequest, grpc.ServerStreamingServer[StreamPricesResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamPrices not implemented")
+}
+func (UnimplementedPriceStreamerServer) mustEmbedUnimplementedPriceStreamerServer() {}
+func (UnimplementedPriceStreamerServer) testEmbeddedByValue()                       {}
+
+// UnsafePriceStreamerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PriceStreamerServer will
+// result in compilation errors.
+type UnsafePriceStreamerServer interface {
+	mustEmbedUnimplementedPriceStreamerServer()
+}
+
+func RegisterPriceStreamerServer(s grpc.ServiceRegistrar, srv PriceStreamerServer) {
+	// If the following call pancis, it indicates UnimplementedPriceStreamerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PriceStreamer_ServiceDesc, srv)
+}
+
+func _PriceStreamer_GetCurrentPrice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCurrentPriceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PriceStreamerServer).GetCurrentPrice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PriceStreamer_GetCurrentPrice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PriceStreamerServer).GetCurrentPrice(ctx, req.(*GetCurrentPriceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PriceStreamer_GetHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PriceStreamerServer).GetHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PriceStreamer_GetHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PriceStreamerServer).GetHistory(ctx, req.(*GetHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PriceStreamer_StreamPrices_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamPricesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PriceStreamerServer).StreamPrices(m, &grpc.GenericServerStream[StreamPricesRequest, StreamPricesResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PriceStreamer_StreamPricesServer = grpc.ServerStreamingServer[StreamPricesResponse]
+
+// PriceStreamer_ServiceDesc is the grpc.ServiceDesc for PriceStreamer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PriceStreamer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pricestreamer.v1.PriceStreamer",
+	HandlerType: (*PriceStreamerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCurrentPrice",
+			Handler:    _PriceStreamer_GetCurrentPrice_Handler,
+		},
+		{
+			MethodName: "GetHistory",
+			Handler:    _PriceStreamer_GetHistory_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamPrices",
+			Handler:       _PriceStreamer_StreamPrices_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "price_streamer.proto",
+}
@@ -0,0 +1,195 @@
+package grpcapi
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"bitcoin-price-streamer/internal/service"
+	"bitcoin-price-streamer/internal/storage"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultSymbol is the asset served when a request does not specify one
+const defaultSymbol = "BTC"
+
+// defaultHistoryLimit is the page size of GetHistory when no limit is given
+const defaultHistoryLimit = 100
+
+// Server implements the PriceStreamer service on top of the price service,
+// serving live updates from priceService and history from store
+type Server struct {
+	UnimplementedPriceStreamerServer
+
+	priceService *service.PriceService
+	store        storage.Store
+	logger       *logrus.Logger
+}
+
+// NewServer creates a PriceStreamer implementation
+func NewServer(priceService *service.PriceService, store storage.Store, logger *logrus.Logger) *Server {
+	return &Server{
+		priceService: priceService,
+		store:        store,
+		logger:       logger,
+	}
+}
+
+// NewGRPCServer creates a gRPC server with the PriceStreamer service registered
+func NewGRPCServer(priceService *service.PriceService, store storage.Store, logger *logrus.Logger) *grpc.Server {
+	server := grpc.NewServer()
+	RegisterPriceStreamerServer(server, NewServer(priceService, store, logger))
+	return server
+}
+
+// normalizeSymbol upper-cases a symbol, defaulting to BTC
+func normalizeSymbol(symbol string) string {
+	if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+		return symbol
+	}
+	return defaultSymbol
+}
+
+// GetCurrentPrice returns the latest price of a symbol
+func (s *Server) GetCurrentPrice(ctx context.Context, req *GetCurrentPriceRequest) (*PriceUpdate, error) {
+	price, exists := s.store.Latest(normalizeSymbol(req.Symbol))
+	if !exists {
+		return nil, status.Error(codes.NotFound, "no price data available")
+	}
+	return priceToProto(price), nil
+}
+
+// GetHistory returns a page of raw price history. Without an order or cursor
+// it returns the newest matching updates oldest first; ASC and DESC page from
+// the oldest or newest match, and next_cursor continues from where the page
+// ended, as in the REST API
+func (s *Server) GetHistory(ctx context.Context, req *GetHistoryRequest) (*GetHistoryResponse, error) {
+	symbol := normalizeSymbol(req.Symbol)
+
+	limit := defaultHistoryLimit
+	if req.Limit > 0 {
+		limit = int(req.Limit)
+	}
+
+	order := storage.OrderAsc
+	query := storage.Query{
+		Since: timeFromProto(req.Since),
+		Until: timeFromProto(req.Until),
+		Limit: limit,
+		Order: storage.OrderDesc,
+	}
+	switch req.Order {
+	case Order_ORDER_UNSPECIFIED:
+	case Order_ORDER_ASC:
+		query.Order = storage.OrderAsc
+	case Order_ORDER_DESC:
+		order, query.Order = storage.OrderDesc, storage.OrderDesc
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown order: %d", req.Order)
+	}
+
+	// A cursor continues in the direction of the page that returned it
+	if req.Cursor != "" {
+		cursor, err := storage.DecodeCursor(req.Cursor)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		query.AfterSeq, query.BeforeSeq = cursor.AfterSeq, cursor.BeforeSeq
+		query.Order = storage.OrderAsc
+		if cursor.BeforeSeq > 0 {
+			query.Order = storage.OrderDesc
+		}
+	}
+
+	page := s.store.Query(symbol, query)
+	if query.Order != order {
+		slices.Reverse(page.Updates)
+	}
+
+	updates := make([]*PriceUpdate, len(page.Updates))
+	for i, update := range page.Updates {
+		updates[i] = priceToProto(update)
+	}
+	return &GetHistoryResponse{
+		Symbol:     symbol,
+		Updates:    updates,
+		NextCursor: page.NextCursor,
+	}, nil
+}
+
+// StreamPrices replays the updates missed since the resume position, preceded
+// by reset or gap notices, then streams live updates until the client goes
+// away, throttled if requested. Symbols default to BTC, and "*" streams every
+// symbol
+func (s *Server) StreamPrices(req *StreamPricesRequest, stream grpc.ServerStreamingServer[StreamPricesResponse]) error {
+	var symbols []string
+	for _, symbol := range req.Symbols {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		symbols = []string{defaultSymbol}
+	}
+
+	// Subscribe before replaying so no update falls between replay and live stream
+	sub := s.priceService.Subscribe(symbols...)
+	defer s.priceService.Unsubscribe(sub)
+	if throttle := time.Duration(req.ThrottleMs) * time.Millisecond; throttle > 0 {
+		if err := s.priceService.SetThrottle(sub, throttle); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid throttle_ms: %v", err)
+		}
+	}
+
+	s.logger.Infof("New gRPC stream for %v", symbols)
+
+	missed := service.BuildReplay(s.store, symbols, req.AfterSeq, timeFromProto(req.Since))
+	if missed.Reset != nil {
+		reset := &StreamPricesResponse_Reset_{Reset_: resetToProto(*missed.Reset)}
+		if err := stream.Send(&StreamPricesResponse{Event: reset}); err != nil {
+			return err
+		}
+	}
+	for _, gap := range missed.Gaps {
+		event := &StreamPricesResponse_Gap{Gap: gapToProto(gap)}
+		if err := stream.Send(&StreamPricesResponse{Event: event}); err != nil {
+			return err
+		}
+	}
+
+	var lastSeq uint64
+	for _, update := range missed.Updates {
+		event := &StreamPricesResponse_Price{Price: priceToProto(update)}
+		if err := stream.Send(&StreamPricesResponse{Event: event}); err != nil {
+			return err
+		}
+		lastSeq = update.Seq
+	}
+
+	for {
+		select {
+		case price, ok := <-sub.C:
+			if !ok {
+				notice := sub.CloseNotice()
+				return status.Errorf(codes.Unavailable, "stream closed by server: %s: %s", notice.Reason, notice.Message)
+			}
+			// Skip live updates already sent during replay
+			if price.Seq <= lastSeq {
+				continue
+			}
+			event := &StreamPricesResponse_Price{Price: priceToProto(price)}
+			if err := stream.Send(&StreamPricesResponse{Event: event}); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			s.logger.Info("gRPC stream closed by client")
+			return nil
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"bitcoin-price-streamer/internal/alerts"
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetAlerts enables the alert rule API and alert stream events, served from
+// engine
+func (h *Handlers) SetAlerts(engine *alerts.Engine) {
+	h.alerts = engine
+}
+
+// alertFeed subscribes a streaming client to alerts if it asked for them with
+// 'alerts=true'. Without alerts the returned channel is nil, so selecting on
+// it blocks forever; the returned function ends the subscription
+func (h *Handlers) alertFeed(c *gin.Context) (<-chan models.Alert, func()) {
+	enabled, _ := strconv.ParseBool(c.Query("alerts"))
+	if !enabled || h.alerts == nil {
+		return nil, func() {}
+	}
+
+	sub := h.alerts.Subscribe()
+	return sub.C, func() { h.alerts.Unsubscribe(sub) }
+}
+
+// handleAlerts returns the most recently fired alerts, optionally only for
+// the 'symbol' parameter
+func (h *Handlers) handleAlerts(c *gin.Context) {
+	limit := 100
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	fired := h.alerts.Recent(strings.ToUpper(c.Query("symbol")), limit)
+	if fired == nil {
+		fired = []models.Alert{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"alerts": fired,
+		"count":  len(fired),
+	})
+}
+
+// handleAlertRules lists the registered alert rules
+func (h *Handlers) handleAlertRules(c *gin.Context) {
+	rules := h.alerts.Rules()
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules": rules,
+		"count": len(rules),
+	})
+}
+
+// handleCreateAlertRule registers the alert rule in the request body
+func (h *Handlers) handleCreateAlertRule(c *gin.Context) {
+	var rule models.AlertRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid alert rule: " + err.Error()})
+		return
+	}
+
+	rule, err := h.alerts.AddRule(rule)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// handleAlertRule returns one alert rule, including when it last fired
+func (h *Handlers) handleAlertRule(c *gin.Context) {
+	rule, exists := h.alerts.Rule(c.Param("id"))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alert rule not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// handleDeleteAlertRule removes an alert rule
+func (h *Handlers) handleDeleteAlertRule(c *gin.Context) {
+	if !h.alerts.DeleteRule(c.Param("id")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alert rule not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"bitcoin-price-streamer/internal/candles"
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// SetCandles enables the candle endpoints, served from builder
+func (h *Handlers) SetCandles(builder *candles.Builder) {
+	h.candles = builder
+}
+
+// intervalParam parses the 'interval' parameter, defaulting to 1m and
+// responding with 400 Bad Request if it is unsupported
+func intervalParam(c *gin.Context) (candles.Interval, bool) {
+	interval, err := candles.ParseInterval(c.DefaultQuery("interval", "1m"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return candles.Interval{}, false
+	}
+	return interval, true
+}
+
+// handleCandles returns the candles of a symbol starting between the optional
+// 'from' and 'to' Unix timestamps
+func (h *Handlers) handleCandles(c *gin.Context) {
+	interval, ok := intervalParam(c)
+	if !ok {
+		return
+	}
+
+	symbol := symbolParam(c)
+	bars := h.candles.Candles(symbol, interval, timeParam(c, "from"), timeParam(c, "to"))
+	if bars == nil {
+		bars = []models.Candle{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":   symbol,
+		"interval": interval.Name,
+		"candles":  bars,
+		"count":    len(bars),
+	})
+}
+
+// handleCandleStream streams the in-progress and closed candles of a symbol
+// as Server-Sent Events, starting with the bar currently being built
+func (h *Handlers) handleCandleStream(c *gin.Context) {
+	interval, ok := intervalParam(c)
+	if !ok {
+		return
+	}
+
+	// Set headers for SSE
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("Access-Control-Allow-Origin", "*")
+
+	symbol := symbolParam(c)
+	sub := h.candles.Subscribe(symbol, interval)
+	defer h.candles.Unsubscribe(sub)
+
+	// Send the current bar so charts don't wait for the next tick
+	if current, exists := h.candles.Current(symbol, interval); exists {
+		h.writeSSECandle(c, current)
+	}
+	c.Writer.Flush()
+
+	for {
+		select {
+		case candle, ok := <-sub.C:
+			if !ok {
+				h.writeSSEJSON(c, "disconnect", sub.CloseNotice())
+				c.Writer.Flush()
+				return
+			}
+			h.writeSSECandle(c, candle)
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			h.logger.Info("Candle stream context cancelled")
+			return
+		}
+	}
+}
+
+// writeSSECandle writes a candle as an SSE event, identified by its start time
+func (h *Handlers) writeSSECandle(c *gin.Context, candle models.Candle) {
+	data, err := json.Marshal(candle)
+	if err != nil {
+		h.logger.Errorf("Failed to marshal candle: %v", err)
+		return
+	}
+
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatInt(candle.Start.Unix(), 10),
+		Event: "candle",
+		Data:  string(data),
+	})
+}
@@ -2,31 +2,58 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"bitcoin-price-streamer/internal/alerts"
+	"bitcoin-price-streamer/internal/candles"
+	"bitcoin-price-streamer/internal/indicators"
+	"bitcoin-price-streamer/internal/metrics"
 	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/provider"
+	"bitcoin-price-streamer/internal/rollup"
 	"bitcoin-price-streamer/internal/service"
+	"bitcoin-price-streamer/internal/storage"
 	"bitcoin-price-streamer/internal/utils"
+	"bitcoin-price-streamer/internal/webhooks"
 
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 )
 
+// defaultSymbol is the asset served when a request does not specify one
+const defaultSymbol = "BTC"
+
 // Handlers manages HTTP request handlers
 type Handlers struct {
 	priceService *service.PriceService
+	store        storage.Store
+	candles      *candles.Builder
+	rollups      *rollup.Rollups
+	indicators   *indicators.Calculator
+	alerts       *alerts.Engine
+	webhooks     *webhooks.Dispatcher
+	metrics      *metrics.Registry
+	wsMetrics    wsMetrics
+	ws           wsOptions
 	logger       *logrus.Logger
 	upgrader     websocket.Upgrader
 }
 
-// NewHandlers creates new HTTP handlers
-func NewHandlers(priceService *service.PriceService, logger *logrus.Logger) *Handlers {
-	return &Handlers{
+// NewHandlers creates new HTTP handlers serving live updates from priceService
+// and history from store
+func NewHandlers(priceService *service.PriceService, store storage.Store, logger *logrus.Logger) *Handlers {
+	h := &Handlers{
 		priceService: priceService,
+		store:        store,
+		ws:           wsOptionsFromEnv(),
 		logger:       logger,
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
@@ -34,6 +61,15 @@ func NewHandlers(priceService *service.PriceService, logger *logrus.Logger) *Han
 			},
 		},
 	}
+	h.SetMetrics(metrics.NewRegistry())
+	return h
+}
+
+// SetMetrics makes the handlers record their metrics in registry and serve
+// it at /metrics
+func (h *Handlers) SetMetrics(registry *metrics.Registry) {
+	h.metrics = registry
+	h.wsMetrics = newWSMetrics(registry)
 }
 
 // SetupRoutes configures all the routes for the application
@@ -44,62 +80,250 @@ func (h *Handlers) SetupRoutes(router *gin.Engine) {
 		api.GET("/price/stream", h.handleSSE)
 		api.GET("/price/current", h.handleCurrentPrice)
 		api.GET("/price/history", h.handlePriceHistory)
+		api.GET("/price/symbols", h.handleSymbols)
+		api.GET("/providers", h.handleProviders)
+		api.GET("/clients", h.handleClients)
 		api.GET("/ws", h.handleWebSocket)
+
+		if h.candles != nil {
+			api.GET("/candles", h.handleCandles)
+			api.GET("/candles/stream", h.handleCandleStream)
+		}
+		if h.indicators != nil {
+			api.GET("/indicators", h.handleIndicators)
+			api.GET("/indicators/history", h.handleIndicatorHistory)
+		}
+		if h.alerts != nil {
+			api.GET("/alerts", h.handleAlerts)
+			api.GET("/alerts/rules", h.handleAlertRules)
+			api.POST("/alerts/rules", h.handleCreateAlertRule)
+			api.GET("/alerts/rules/:id", h.handleAlertRule)
+			api.DELETE("/alerts/rules/:id", h.handleDeleteAlertRule)
+		}
+		if h.webhooks != nil {
+			api.GET("/webhooks", h.handleWebhooks)
+			api.POST("/webhooks", h.handleCreateWebhook)
+			api.GET("/webhooks/deliveries", h.handleWebhookDeliveries)
+			api.GET("/webhooks/dead-letters", h.handleWebhookDeadLetters)
+			api.POST("/webhooks/dead-letters/:id/redeliver", h.handleRedeliverWebhook)
+			api.GET("/webhooks/:id", h.handleWebhook)
+			api.DELETE("/webhooks/:id", h.handleDeleteWebhook)
+		}
 	}
 
+	router.GET("/metrics", h.handleMetrics)
+
 	// Serve the main page
 	router.GET("/", h.handleIndex)
 }
 
+// handleMetrics serves the metrics in the Prometheus text format
+func (h *Handlers) handleMetrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4")
+	c.Status(http.StatusOK)
+	h.metrics.WriteTo(c.Writer)
+}
+
 // handleIndex serves the main HTML page
 func (h *Handlers) handleIndex(c *gin.Context) {
 	staticPath := utils.GetEnvString("STATIC_PATH", "./static")
 	c.File(filepath.Join(staticPath, "index.html"))
 }
 
-// handleSSE handles Server-Sent Events for real-time price streaming
+// symbolParam returns the requested asset symbol, defaulting to BTC
+func symbolParam(c *gin.Context) string {
+	return strings.ToUpper(c.DefaultQuery("symbol", defaultSymbol))
+}
+
+// symbolsParam returns the symbols a streaming client subscribes to, taken from
+// the comma-separated 'symbols' parameter or the single 'symbol' parameter
+func symbolsParam(c *gin.Context) []string {
+	if symbols := normalizeSymbols(strings.Split(c.Query("symbols"), ",")); len(symbols) > 0 {
+		return symbols
+	}
+	return []string{symbolParam(c)}
+}
+
+// fieldsParam parses the optional 'fields' selector, responding with 400 Bad
+// Request and returning false if it names an unknown field
+func fieldsParam(c *gin.Context) (models.FieldSet, bool) {
+	fields, err := models.ParseFields(c.Query("fields"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return fields, true
+}
+
+// slowConsumerParam parses the optional 'slow_consumer' policy, responding
+// with 400 Bad Request and returning false if it is unknown. An empty policy
+// means the server default
+func slowConsumerParam(c *gin.Context) (service.SlowConsumerPolicy, bool) {
+	value := c.Query("slow_consumer")
+	if value == "" {
+		return "", true
+	}
+	policy, err := service.ParseSlowConsumerPolicy(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return policy, true
+}
+
+// throttleParam parses the optional 'throttle' interval, such as 1s,
+// responding with 400 Bad Request and returning false if it is invalid
+func throttleParam(c *gin.Context) (time.Duration, bool) {
+	value := c.Query("throttle")
+	if value == "" {
+		return 0, true
+	}
+	throttle, err := time.ParseDuration(value)
+	if err != nil || throttle < 0 || throttle > service.MaxThrottle {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("throttle must be a duration between 0 and %s, such as 1s", service.MaxThrottle),
+		})
+		return 0, false
+	}
+	return throttle, true
+}
+
+// subscribe subscribes a streaming client to symbols, overriding the server's
+// slow-consumer policy if policy is set and throttling its updates if
+// throttle is positive
+func (h *Handlers) subscribe(symbols []string, policy service.SlowConsumerPolicy, throttle time.Duration) *service.Subscription {
+	sub := h.priceService.Subscribe(symbols...)
+	if policy != "" {
+		sub.SetPolicy(policy)
+	}
+	if throttle > 0 {
+		// Already validated by throttleParam
+		h.priceService.SetThrottle(sub, throttle)
+	}
+	return sub
+}
+
+// normalizeSymbols upper-cases symbols and drops empty entries
+func normalizeSymbols(symbols []string) []string {
+	var normalized []string
+	for _, symbol := range symbols {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			normalized = append(normalized, symbol)
+		}
+	}
+	return normalized
+}
+
+// writeSSEPrice writes a price update as an SSE event with its sequence number as the event ID
+func (h *Handlers) writeSSEPrice(c *gin.Context, update models.PriceUpdate, fields models.FieldSet) {
+	data, err := json.Marshal(update.Select(fields))
+	if err != nil {
+		h.logger.Errorf("Failed to marshal price update: %v", err)
+		return
+	}
+
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatUint(update.Seq, 10),
+		Event: "price",
+		Data:  string(data),
+	})
+}
+
+// writeSSEJSON writes a JSON-encoded SSE event without an event ID
+func (h *Handlers) writeSSEJSON(c *gin.Context, event string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		h.logger.Errorf("Failed to marshal %s event: %v", event, err)
+		return
+	}
+	c.SSEvent(event, string(data))
+}
+
+// writeSSEIndicators writes the indicators computed for a price update, if any
+func (h *Handlers) writeSSEIndicators(c *gin.Context, update models.PriceUpdate) {
+	if indicators, exists := h.indicatorsFor(update); exists {
+		h.writeSSEJSON(c, "indicators", indicators)
+	}
+}
+
+// handleSSE handles Server-Sent Events for real-time price streaming. Clients
+// resume exactly with the standard Last-Event-ID header, or approximately
+// with a 'since' Unix timestamp. With 'indicators=true' each price event is
+// followed by an 'indicators' event for the same update, and with
+// 'alerts=true' alerts for the subscribed symbols arrive as 'alert' events. A
+// client the server drops gets a final 'disconnect' event saying why
 func (h *Handlers) handleSSE(c *gin.Context) {
+	fields, ok := fieldsParam(c)
+	if !ok {
+		return
+	}
+	policy, ok := slowConsumerParam(c)
+	if !ok {
+		return
+	}
+	throttle, ok := throttleParam(c)
+	if !ok {
+		return
+	}
+	withIndicators := h.indicatorsParam(c)
+
 	// Set headers for SSE
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
 	c.Header("Access-Control-Allow-Origin", "*")
-	c.Header("Access-Control-Allow-Headers", "Cache-Control")
+	c.Header("Access-Control-Allow-Headers", "Cache-Control, Last-Event-ID")
 
-	// Get the 'since' parameter for missed updates
-	sinceParam := c.Query("since")
-	var since time.Time
-	if sinceParam != "" {
-		if timestamp, err := strconv.ParseInt(sinceParam, 10, 64); err == nil {
-			since = time.Unix(timestamp, 0)
-		}
-	}
+	symbols := symbolsParam(c)
 
-	// Send missed updates if 'since' parameter is provided
-	if !since.IsZero() {
-		storage := h.priceService.GetStorage()
-		missedUpdates := storage.GetUpdatesSince(since)
+	// Subscribe before replaying so no update falls between replay and live stream
+	sub := h.subscribe(symbols, policy, throttle)
+	defer h.priceService.Unsubscribe(sub)
+	alertC, unsubscribeAlerts := h.alertFeed(c)
+	defer unsubscribeAlerts()
 
-		for _, update := range missedUpdates {
-			data, _ := json.Marshal(update)
-			c.SSEvent("price", string(data))
-		}
+	// Send missed updates if 'Last-Event-ID' or 'since' is provided, preceded
+	// by notices for any part of the history that is no longer available
+	missed := h.buildReplay(symbols, resumeParam(c, c.GetHeader("Last-Event-ID")))
+	if missed.Reset != nil {
+		h.writeSSEJSON(c, "reset", missed.Reset)
+	}
+	for _, gap := range missed.Gaps {
+		h.writeSSEJSON(c, "gap", gap)
 	}
 
-	// Subscribe to real-time updates
-	clientChan := h.priceService.Subscribe()
-	defer h.priceService.Unsubscribe(clientChan)
+	var lastSeq uint64
+	for _, update := range missed.Updates {
+		h.writeSSEPrice(c, update, fields)
+		if withIndicators {
+			h.writeSSEIndicators(c, update)
+		}
+		lastSeq = update.Seq
+	}
+	c.Writer.Flush()
 
 	for {
 		select {
-		case price := <-clientChan:
-			data, err := json.Marshal(price)
-			if err != nil {
-				h.logger.Errorf("Failed to marshal price update: %v", err)
+		case price, ok := <-sub.C:
+			if !ok {
+				h.writeSSEJSON(c, "disconnect", sub.CloseNotice())
+				c.Writer.Flush()
+				return
+			}
+			// Skip live updates already sent during replay
+			if price.Seq <= lastSeq {
 				continue
 			}
-			c.SSEvent("price", string(data))
+			h.writeSSEPrice(c, price, fields)
+			if withIndicators {
+				h.writeSSEIndicators(c, price)
+			}
 			c.Writer.Flush()
+		case alert := <-alertC:
+			if sub.Matches(alert.Symbol) {
+				h.writeSSEJSON(c, "alert", alert)
+				c.Writer.Flush()
+			}
 		case <-c.Request.Context().Done():
 			h.logger.Info("Request context cancelled")
 			return
@@ -107,34 +331,54 @@ func (h *Handlers) handleSSE(c *gin.Context) {
 	}
 }
 
-// handleCurrentPrice returns the current Bitcoin price
+// handleCurrentPrice returns the current price of the requested symbol
 func (h *Handlers) handleCurrentPrice(c *gin.Context) {
-	storage := h.priceService.GetStorage()
-	price, exists := storage.GetLatest()
+	fields, ok := fieldsParam(c)
+	if !ok {
+		return
+	}
+
+	price, exists := h.store.Latest(symbolParam(c))
 
 	if !exists {
 		c.JSON(http.StatusNotFound, gin.H{"error": "No price data available"})
 		return
 	}
 
-	c.JSON(http.StatusOK, price)
+	c.JSON(http.StatusOK, price.Select(fields))
 }
 
-// handlePriceHistory returns price history with optional filtering
+// timeParam parses an optional Unix timestamp query parameter, ignoring
+// invalid values
+func timeParam(c *gin.Context, key string) time.Time {
+	if timestamp, err := strconv.ParseInt(c.Query(key), 10, 64); err == nil {
+		return time.Unix(timestamp, 0)
+	}
+	return time.Time{}
+}
+
+// handlePriceHistory returns a page of price history with optional filtering.
+// Without 'order' or 'cursor' it returns the newest matching updates oldest
+// first; 'order' pages from the oldest (asc) or newest (desc) match, and
+// 'next_cursor' continues from where the page ended. Raw updates are served
+// unless 'resolution' asks for rollup bars of a given size, or for 'auto' to
+// pick bars when the range reaches beyond the raw updates
 func (h *Handlers) handlePriceHistory(c *gin.Context) {
-	storage := h.priceService.GetStorage()
+	fields, ok := fieldsParam(c)
+	if !ok {
+		return
+	}
+
+	order, err := storage.ParseOrder(c.Query("order"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Get query parameters
-	sinceParam := c.Query("since")
+	symbol := symbolParam(c)
 	limitParam := c.Query("limit")
 
-	var since time.Time
-	if sinceParam != "" {
-		if timestamp, err := strconv.ParseInt(sinceParam, 10, 64); err == nil {
-			since = time.Unix(timestamp, 0)
-		}
-	}
-
 	limit := 100 // default limit
 	if limitParam != "" {
 		if l, err := strconv.Atoi(limitParam); err == nil && l > 0 {
@@ -142,69 +386,310 @@ func (h *Handlers) handlePriceHistory(c *gin.Context) {
 		}
 	}
 
-	var updates []models.PriceUpdate
-	if !since.IsZero() {
-		updates = storage.GetUpdatesSince(since)
-	} else {
-		updates = storage.GetAllUpdates()
+	query := storage.Query{
+		Since: timeParam(c, "since"),
+		Until: timeParam(c, "until"),
+		Limit: limit,
+		Order: storage.OrderDesc,
+	}
+	if c.Query("order") != "" {
+		query.Order = order
 	}
 
-	// Apply limit
-	if len(updates) > limit {
-		updates = updates[len(updates)-limit:]
+	// Cursors address raw updates; otherwise pick the requested resolution.
+	// Raw is the default so existing clients keep getting updates, not candles
+	cursorParam := c.Query("cursor")
+	if cursorParam == "" {
+		resolution, err := h.resolveResolution(c.DefaultQuery("resolution", resolutionRaw), symbol, query.Since)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if resolution != resolutionRaw {
+			h.writeRollupHistory(c, resolution, symbol, query, order)
+			return
+		}
+	}
+
+	page, err := h.queryRawHistory(symbol, query, order, cursorParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	updates := page.Updates
+	if updates == nil {
+		updates = []models.PriceUpdate{}
+	}
+
+	response := gin.H{
+		"symbol":     symbol,
+		"resolution": resolutionRaw,
+		"updates":    models.SelectAll(updates, fields),
+		"count":      len(updates),
+	}
+	if page.NextCursor != "" {
+		response["next_cursor"] = page.NextCursor
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// queryRawHistory runs a history query against the raw updates, returning
+// the page in the given order. A cursor overrides the query's position and
+// continues in the direction of the page that returned it
+func (h *Handlers) queryRawHistory(symbol string, query storage.Query, order storage.Order, cursorParam string) (storage.Page, error) {
+	if cursorParam != "" {
+		cursor, err := storage.DecodeCursor(cursorParam)
+		if err != nil {
+			return storage.Page{}, err
+		}
+
+		query.AfterSeq, query.BeforeSeq = cursor.AfterSeq, cursor.BeforeSeq
+		query.Order = storage.OrderAsc
+		if cursor.BeforeSeq > 0 {
+			query.Order = storage.OrderDesc
+		}
+	}
+
+	page := h.store.Query(symbol, query)
+	if query.Order != order {
+		slices.Reverse(page.Updates)
+	}
+	return page, nil
+}
+
+// handleSymbols returns the symbols with stored price data
+func (h *Handlers) handleSymbols(c *gin.Context) {
+	symbols := h.store.Symbols()
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbols": symbols,
+		"count":   len(symbols),
+	})
+}
+
+// handleProviders returns the health of the upstream price providers
+func (h *Handlers) handleProviders(c *gin.Context) {
+	health := h.priceService.ProviderHealth()
+	if health == nil {
+		health = []provider.ProviderHealth{}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"updates": updates,
-		"count":   len(updates),
+		"providers": health,
+		"count":     len(health),
 	})
 }
 
-// handleWebSocket handles WebSocket connections for real-time price updates
+// handleClients returns the streaming clients with their slow-consumer policy
+// and how many updates each has dropped
+func (h *Handlers) handleClients(c *gin.Context) {
+	clients := h.priceService.Clients()
+
+	c.JSON(http.StatusOK, gin.H{
+		"clients": clients,
+		"count":   len(clients),
+	})
+}
+
+// handleWebSocket handles WebSocket connections for real-time price updates.
+// With 'indicators=true' each price is followed by an 'indicators' event, and
+// with 'alerts=true' alerts for the subscribed symbols arrive as 'alert' events.
+// Clients control their feed with versioned JSON commands (see wsprotocol.go),
+// and a client the server drops gets a final 'disconnect' event saying why
 func (h *Handlers) handleWebSocket(c *gin.Context) {
-	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	fields, ok := fieldsParam(c)
+	if !ok {
+		return
+	}
+	policy, ok := slowConsumerParam(c)
+	if !ok {
+		return
+	}
+	throttle, ok := throttleParam(c)
+	if !ok {
+		return
+	}
+	withIndicators := h.indicatorsParam(c)
+
+	upgraded, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		h.logger.Errorf("Failed to upgrade connection to WebSocket: %v", err)
 		return
 	}
+	conn := &wsConn{Conn: upgraded, writeTimeout: h.ws.writeTimeout}
 	defer conn.Close()
 
+	h.wsMetrics.opened.Inc()
+	h.wsMetrics.connections.Inc()
 	h.logger.Info("New WebSocket connection established")
 
-	// Subscribe to price updates
-	clientChan := h.priceService.Subscribe()
-	defer h.priceService.Unsubscribe(clientChan)
+	// Record why the connection ended once it is torn down
+	reason := wsCloseCancelled
+	defer func() {
+		h.wsMetrics.connections.Dec()
+		h.wsMetrics.closed.With(reason).Inc()
+		h.logger.WithField("reason", reason).Info("WebSocket connection closed")
+	}()
+
+	// writeFailed records a failed write as the reason the connection ends
+	writeFailed := func(err error) {
+		reason = wsWriteCloseReason(err)
+		h.logger.Errorf("Failed to send WebSocket message: %v", err)
+	}
+
+	// Any message, including a pong, proves the peer is alive
+	conn.SetReadLimit(h.ws.maxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(h.ws.pongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(h.ws.pongTimeout))
+	})
+
+	// Subscribe to price updates for the requested symbols
+	symbols := symbolsParam(c)
+	sub := h.subscribe(symbols, policy, throttle)
+	defer h.priceService.Unsubscribe(sub)
+	alertC, unsubscribeAlerts := h.alertFeed(c)
+	defer unsubscribeAlerts()
 
-	// Handle WebSocket messages (for future features)
+	// Replay missed updates if 'last_seq' or 'since' is provided
+	missed := h.buildReplay(symbols, resumeParam(c, c.Query("last_seq")))
+	if missed.Reset != nil {
+		if err := conn.WriteJSON(wsEvent{Type: "reset", Data: missed.Reset}); err != nil {
+			writeFailed(err)
+			return
+		}
+	}
+	for _, gap := range missed.Gaps {
+		if err := conn.WriteJSON(wsEvent{Type: "gap", Data: gap}); err != nil {
+			writeFailed(err)
+			return
+		}
+	}
+
+	var lastSeq uint64
+	for _, update := range missed.Updates {
+		if err := conn.WriteJSON(update.Select(fields)); err != nil {
+			writeFailed(err)
+			return
+		}
+		if withIndicators {
+			if err := h.writeWSIndicators(conn, update); err != nil {
+				writeFailed(err)
+				return
+			}
+		}
+		lastSeq = update.Seq
+	}
+
+	// Read client commands, leaving all writes to the loop below. Reads fail
+	// once the peer stops answering pings or sends an oversized message
+	commands := make(chan []byte)
+	readDone := make(chan struct{})
+	var readErr error
 	go func() {
+		defer close(readDone)
 		for {
 			_, message, err := conn.ReadMessage()
 			if err != nil {
-				h.logger.Debugf("WebSocket read error: %v", err)
+				readErr = err
+				return
+			}
+			conn.SetReadDeadline(time.Now().Add(h.ws.pongTimeout))
+			select {
+			case commands <- message:
+			case <-c.Request.Context().Done():
 				return
 			}
-			h.logger.Debugf("Received WebSocket message: %s", string(message))
 		}
 	}()
 
-	// Send price updates to WebSocket client
+	pingTicker := time.NewTicker(h.ws.pingInterval)
+	defer pingTicker.Stop()
+
+	session := &wsSession{sub: sub, fields: fields}
+
+	// sendPrice writes a price update, followed by its indicators if requested
+	sendPrice := func(price models.PriceUpdate) error {
+		data, err := json.Marshal(price.Select(fields))
+		if err != nil {
+			h.logger.Errorf("Failed to marshal price update: %v", err)
+			return nil
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			return err
+		}
+		if withIndicators {
+			return h.writeWSIndicators(conn, price)
+		}
+		return nil
+	}
+
+	// Send price updates and command replies to WebSocket client
 	for {
 		select {
-		case price := <-clientChan:
-			data, err := json.Marshal(price)
-			if err != nil {
-				h.logger.Errorf("Failed to marshal price update: %v", err)
+		case price, ok := <-sub.C:
+			if !ok {
+				notice := sub.CloseNotice()
+				reason = notice.Reason
+				if err := conn.WriteJSON(wsEvent{Type: "disconnect", Data: notice}); err == nil {
+					conn.closeWith(websocket.ClosePolicyViolation, notice.Reason)
+				}
+				return
+			}
+			// Skip live updates already sent during replay
+			if price.Seq <= lastSeq {
 				continue
 			}
-
-			err = conn.WriteMessage(websocket.TextMessage, data)
-			if err != nil {
-				h.logger.Errorf("Failed to send WebSocket message: %v", err)
+			if err := sendPrice(price); err != nil {
+				writeFailed(err)
 				return
 			}
+		case message := <-commands:
+			cmd, reply := parseWSCommand(message)
+			if reply == nil {
+				reply = h.handleWSCommand(session, cmd)
+			}
+			if reply != nil {
+				if err := conn.WriteJSON(reply); err != nil {
+					writeFailed(err)
+					return
+				}
+			}
+		case alert := <-alertC:
+			if !sub.Matches(alert.Symbol) {
+				continue
+			}
+			if err := conn.WriteJSON(wsEvent{Type: "alert", Data: alert}); err != nil {
+				writeFailed(err)
+				return
+			}
+		case <-pingTicker.C:
+			if err := conn.ping(); err != nil {
+				writeFailed(err)
+				return
+			}
+		case <-readDone:
+			reason = wsReadCloseReason(readErr)
+			h.logger.Debugf("WebSocket read error: %v", readErr)
+			return
 		case <-c.Request.Context().Done():
-			h.logger.Info("WebSocket context cancelled")
+			conn.closeWith(websocket.CloseGoingAway, "request cancelled")
 			return
 		}
 	}
 }
+
+// wsEvent is a non-price message sent to WebSocket clients
+type wsEvent struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// writeWSIndicators sends the indicators computed for a price update, if any
+func (h *Handlers) writeWSIndicators(conn *wsConn, update models.PriceUpdate) error {
+	indicators, exists := h.indicatorsFor(update)
+	if !exists {
+		return nil
+	}
+	return conn.WriteJSON(wsEvent{Type: "indicators", Data: indicators})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"bitcoin-price-streamer/internal/indicators"
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetIndicators enables the indicator endpoints and stream events, served
+// from calculator
+func (h *Handlers) SetIndicators(calculator *indicators.Calculator) {
+	h.indicators = calculator
+}
+
+// indicatorsParam reports whether a streaming client asked for indicator
+// events with 'indicators=true'
+func (h *Handlers) indicatorsParam(c *gin.Context) bool {
+	enabled, _ := strconv.ParseBool(c.Query("indicators"))
+	return enabled && h.indicators != nil
+}
+
+// indicatorsFor returns the indicators computed for a streamed price update
+func (h *Handlers) indicatorsFor(update models.PriceUpdate) (models.Indicators, bool) {
+	return h.indicators.At(update.Symbol, update.Seq)
+}
+
+// handleIndicators returns the latest indicators of a symbol
+func (h *Handlers) handleIndicators(c *gin.Context) {
+	latest, exists := h.indicators.Latest(symbolParam(c))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No indicator data available"})
+		return
+	}
+
+	c.JSON(http.StatusOK, latest)
+}
+
+// handleIndicatorHistory returns the most recent indicators of a symbol,
+// oldest first, along with the periods they are computed over
+func (h *Handlers) handleIndicatorHistory(c *gin.Context) {
+	limit := 100
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = l
+	}
+
+	symbol := symbolParam(c)
+	history := h.indicators.History(symbol, limit)
+	if history == nil {
+		history = []models.Indicators{}
+	}
+
+	options := h.indicators.Options()
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":     symbol,
+		"indicators": history,
+		"count":      len(history),
+		"periods": gin.H{
+			"sma":         options.SMAPeriod,
+			"ema":         options.EMAPeriod,
+			"rsi":         options.RSIPeriod,
+			"bollinger":   options.BollingerPeriod,
+			"bollinger_k": options.BollingerK,
+			"macd_fast":   options.MACDFast,
+			"macd_slow":   options.MACDSlow,
+			"macd_signal": options.MACDSignal,
+			"volatility":  options.VolatilityPeriod,
+		},
+	})
+}
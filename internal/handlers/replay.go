@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"bitcoin-price-streamer/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resumePosition is where a reconnecting client wants its replay to start
+type resumePosition struct {
+	afterSeq uint64
+	since    time.Time
+}
+
+// resumeParam reads the resume position from a sequence number, preferring it
+// over the 'since' Unix timestamp query parameter
+func resumeParam(c *gin.Context, seqValue string) resumePosition {
+	if seq, err := strconv.ParseUint(seqValue, 10, 64); err == nil && seq > 0 {
+		return resumePosition{afterSeq: seq}
+	}
+	return resumePosition{since: timeParam(c, "since")}
+}
+
+// buildReplay collects the stored updates for symbols after the resume
+// position, along with notices for history the server can no longer provide
+func (h *Handlers) buildReplay(symbols []string, pos resumePosition) service.Replay {
+	return service.BuildReplay(h.store, symbols, pos.afterSeq, pos.since)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/rollup"
+	"bitcoin-price-streamer/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// resolutionRaw serves history from the stored price updates
+	resolutionRaw = "raw"
+	// resolutionAuto picks the finest resolution covering the requested range
+	resolutionAuto = "auto"
+)
+
+// SetRollups enables downsampled history resolutions, served from rollups
+func (h *Handlers) SetRollups(rollups *rollup.Rollups) {
+	h.rollups = rollups
+}
+
+// resolveResolution validates the requested history resolution, resolving
+// 'auto' to raw updates while they still cover since, and otherwise to the
+// finest rollup tier whose retention reaches back to since
+func (h *Handlers) resolveResolution(requested, symbol string, since time.Time) (string, error) {
+	switch {
+	case requested == resolutionRaw:
+		return resolutionRaw, nil
+	case requested != resolutionAuto:
+		if h.rollups != nil {
+			for _, tier := range h.rollups.Tiers() {
+				if tier.Name == requested {
+					return requested, nil
+				}
+			}
+		}
+		return "", fmt.Errorf("unknown resolution: %s", requested)
+	case h.rollups == nil || since.IsZero() || h.rawCovers(symbol, since):
+		return resolutionRaw, nil
+	}
+
+	tiers := h.rollups.Tiers()
+	now := time.Now()
+	for _, tier := range tiers {
+		if !since.Before(now.Add(-tier.Retention)) {
+			return tier.Name, nil
+		}
+	}
+	return tiers[len(tiers)-1].Name, nil
+}
+
+// rawCovers reports whether no stored update of symbol after since has been evicted
+func (h *Handlers) rawCovers(symbol string, since time.Time) bool {
+	if detector, ok := h.store.(storage.GapDetector); ok {
+		return detector.DetectGap(symbol, 0, since) == nil
+	}
+
+	covered := false
+	h.store.Iterate(symbol, func(oldest models.PriceUpdate) bool {
+		covered = !oldest.Timestamp.After(since)
+		return false
+	})
+	return covered
+}
+
+// writeRollupHistory responds with up to limit bars of a rollup tier. Without
+// an explicit order the newest bars are returned oldest first
+func (h *Handlers) writeRollupHistory(c *gin.Context, resolution, symbol string, query storage.Query, order storage.Order) {
+	bars, err := h.rollups.Query(resolution, symbol, query.Since, query.Until)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(bars) > query.Limit {
+		if query.Order == storage.OrderAsc {
+			bars = bars[:query.Limit]
+		} else {
+			bars = bars[len(bars)-query.Limit:]
+		}
+	}
+	if order == storage.OrderDesc {
+		slices.Reverse(bars)
+	}
+	if bars == nil {
+		bars = []models.Candle{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":     symbol,
+		"resolution": resolution,
+		"candles":    bars,
+		"count":      len(bars),
+	})
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetWebhooks enables the webhook management API, served from dispatcher
+func (h *Handlers) SetWebhooks(dispatcher *webhooks.Dispatcher) {
+	h.webhooks = dispatcher
+}
+
+// handleWebhooks lists the registered webhook endpoints
+func (h *Handlers) handleWebhooks(c *gin.Context) {
+	endpoints := h.webhooks.Endpoints()
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": endpoints,
+		"count":    len(endpoints),
+	})
+}
+
+// handleCreateWebhook registers the webhook endpoint in the request body. The
+// response is the only one that includes the signing secret
+func (h *Handlers) handleCreateWebhook(c *gin.Context) {
+	var endpoint models.WebhookEndpoint
+	if err := c.ShouldBindJSON(&endpoint); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook: " + err.Error()})
+		return
+	}
+
+	endpoint, err := h.webhooks.AddEndpoint(endpoint)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, endpoint)
+}
+
+// handleWebhook returns one webhook endpoint
+func (h *Handlers) handleWebhook(c *gin.Context) {
+	endpoint, exists := h.webhooks.Endpoint(c.Param("id"))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// handleDeleteWebhook removes a webhook endpoint
+func (h *Handlers) handleDeleteWebhook(c *gin.Context) {
+	if !h.webhooks.DeleteEndpoint(c.Param("id")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// webhookLimitParam parses the 'limit' parameter of the webhook logs
+func webhookLimitParam(c *gin.Context) int {
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		return l
+	}
+	return 100
+}
+
+// handleWebhookDeliveries returns the most recent delivery attempts,
+// optionally only to the 'endpoint' parameter
+func (h *Handlers) handleWebhookDeliveries(c *gin.Context) {
+	deliveries := h.webhooks.Deliveries(c.Query("endpoint"), webhookLimitParam(c))
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
+
+// handleWebhookDeadLetters returns the events that exhausted their delivery
+// attempts, optionally only to the 'endpoint' parameter
+func (h *Handlers) handleWebhookDeadLetters(c *gin.Context) {
+	letters := h.webhooks.DeadLetters(c.Query("endpoint"), webhookLimitParam(c))
+	if letters == nil {
+		letters = []models.WebhookDeadLetter{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dead_letters": letters,
+		"count":        len(letters),
+	})
+}
+
+// handleRedeliverWebhook queues a dead-lettered event for delivery again
+func (h *Handlers) handleRedeliverWebhook(c *gin.Context) {
+	err := h.webhooks.Redeliver(c.Param("id"))
+	switch {
+	case errors.Is(err, webhooks.ErrDeadLetterNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusAccepted, gin.H{"id": c.Param("id"), "status": "queued"})
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"bitcoin-price-streamer/internal/metrics"
+	"bitcoin-price-streamer/internal/utils"
+
+	"github.com/gorilla/websocket"
+)
+
+// Reasons a WebSocket connection was closed, reported in logs and metrics
+const (
+	wsCloseClient       = "client_closed"
+	wsClosePongTimeout  = "pong_timeout"
+	wsCloseMessageSize  = "message_too_large"
+	wsCloseReadError    = "read_error"
+	wsCloseWriteTimeout = "write_timeout"
+	wsCloseWriteError   = "write_error"
+	wsCloseCancelled    = "cancelled"
+)
+
+// wsOptions are the keepalive and limit settings of WebSocket connections
+type wsOptions struct {
+	pingInterval   time.Duration
+	pongTimeout    time.Duration
+	writeTimeout   time.Duration
+	maxMessageSize int64
+}
+
+// wsOptionsFromEnv reads the WebSocket settings. The server pings every
+// WS_PING_INTERVAL_MS and drops peers that send nothing, not even a pong,
+// for WS_PONG_TIMEOUT_MS
+func wsOptionsFromEnv() wsOptions {
+	options := wsOptions{
+		pingInterval:   time.Duration(utils.GetEnvInt("WS_PING_INTERVAL_MS", 30000)) * time.Millisecond,
+		pongTimeout:    time.Duration(utils.GetEnvInt("WS_PONG_TIMEOUT_MS", 60000)) * time.Millisecond,
+		writeTimeout:   time.Duration(utils.GetEnvInt("WS_WRITE_TIMEOUT_MS", 10000)) * time.Millisecond,
+		maxMessageSize: int64(utils.GetEnvInt("WS_MAX_MESSAGE_SIZE", 4096)),
+	}
+
+	// A peer must have a chance to answer a ping before it is dropped
+	options.pongTimeout = max(options.pongTimeout, options.pingInterval+options.pingInterval/2)
+	return options
+}
+
+// wsMetrics counts WebSocket connections and why they were closed
+type wsMetrics struct {
+	connections *metrics.Gauge
+	opened      *metrics.Counter
+	closed      *metrics.CounterVec
+}
+
+// newWSMetrics registers the WebSocket metrics with registry
+func newWSMetrics(registry *metrics.Registry) wsMetrics {
+	return wsMetrics{
+		connections: registry.Gauge("websocket_connections", "Open WebSocket connections"),
+		opened:      registry.Counter("websocket_connections_total", "WebSocket connections accepted"),
+		closed:      registry.CounterVec("websocket_disconnects_total", "WebSocket connections closed, by reason", "reason"),
+	}
+}
+
+// wsConn is a WebSocket connection whose data writes time out after
+// writeTimeout, so a peer that stopped reading cannot stall its handler
+type wsConn struct {
+	*websocket.Conn
+	writeTimeout time.Duration
+}
+
+// WriteJSON writes v as a JSON text message within the write timeout
+func (c *wsConn) WriteJSON(v interface{}) error {
+	c.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	return c.Conn.WriteJSON(v)
+}
+
+// WriteMessage writes a data message within the write timeout
+func (c *wsConn) WriteMessage(messageType int, data []byte) error {
+	c.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	return c.Conn.WriteMessage(messageType, data)
+}
+
+// ping sends a ping control message within the write timeout
+func (c *wsConn) ping() error {
+	return c.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.writeTimeout))
+}
+
+// closeWith sends a close frame, ignoring failures since the connection is
+// being torn down anyway
+func (c *wsConn) closeWith(code int, text string) {
+	c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(c.writeTimeout))
+}
+
+// wsReadCloseReason classifies the error that ended a connection's reads
+func wsReadCloseReason(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, websocket.ErrReadLimit):
+		return wsCloseMessageSize
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return wsClosePongTimeout
+	case websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived):
+		return wsCloseClient
+	default:
+		return wsCloseReadError
+	}
+}
+
+// wsWriteCloseReason classifies the error that ended a connection's writes
+func wsWriteCloseReason(err error) string {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return wsCloseWriteTimeout
+	}
+	return wsCloseWriteError
+}
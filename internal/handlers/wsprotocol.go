@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/service"
+	"bitcoin-price-streamer/internal/storage"
+)
+
+// wsProtocolVersion is the version of the WebSocket command protocol. Commands
+// carry it in 'v'; messages without it are legacy subscribe/unsubscribe
+// messages, which are applied without a reply
+const wsProtocolVersion = 1
+
+// WebSocket commands
+const (
+	wsCommandSubscribe   = "subscribe"
+	wsCommandUnsubscribe = "unsubscribe"
+	wsCommandSnapshot    = "snapshot"
+	wsCommandHistory     = "history"
+	wsCommandPing        = "ping"
+	wsCommandSetThrottle = "set_throttle"
+)
+
+// WebSocket command error codes
+const (
+	wsErrInvalidMessage     = "invalid_message"
+	wsErrUnsupportedVersion = "unsupported_version"
+	wsErrUnknownCommand     = "unknown_command"
+	wsErrInvalidArgument    = "invalid_argument"
+)
+
+// wsCommand is a control message sent by WebSocket clients. Which fields are
+// used depends on the command type
+type wsCommand struct {
+	V        int      `json:"v"`
+	ID       string   `json:"id"`
+	Type     string   `json:"type"`
+	Symbols  []string `json:"symbols"`
+	Symbol   string   `json:"symbol"`
+	Since    int64    `json:"since"`
+	Until    int64    `json:"until"`
+	Limit    int      `json:"limit"`
+	Order    string   `json:"order"`
+	Cursor   string   `json:"cursor"`
+	Interval string   `json:"interval"`
+}
+
+// wsReply answers a versioned command, with the command's result in Data or
+// the reason it failed in Error
+type wsReply struct {
+	Type    string      `json:"type"`
+	V       int         `json:"v"`
+	ID      string      `json:"id,omitempty"`
+	Command string      `json:"command,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+	Error   *wsError    `json:"error,omitempty"`
+}
+
+// wsError is a typed command failure
+type wsError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// wsSession is the per-connection state WebSocket commands act on
+type wsSession struct {
+	sub    *service.Subscription
+	fields models.FieldSet
+}
+
+// parseWSCommand decodes a client message, returning an error reply if it is
+// malformed or of an unsupported version
+func parseWSCommand(message []byte) (wsCommand, *wsReply) {
+	var cmd wsCommand
+	if err := json.Unmarshal(message, &cmd); err != nil {
+		return cmd, wsErrorReply(cmd, wsErrInvalidMessage, "message is not a valid JSON command")
+	}
+	if cmd.V > wsProtocolVersion || cmd.V < 0 {
+		return cmd, wsErrorReply(cmd, wsErrUnsupportedVersion,
+			fmt.Sprintf("protocol version %d is not supported, the server speaks version %d", cmd.V, wsProtocolVersion))
+	}
+	return cmd, nil
+}
+
+// wsErrorReply builds an error reply to cmd
+func wsErrorReply(cmd wsCommand, code, message string) *wsReply {
+	return &wsReply{
+		Type:    "error",
+		V:       wsProtocolVersion,
+		ID:      cmd.ID,
+		Command: cmd.Type,
+		Error:   &wsError{Code: code, Message: message},
+	}
+}
+
+// wsOKReply builds a successful reply to cmd
+func wsOKReply(cmd wsCommand, data interface{}) *wsReply {
+	return &wsReply{
+		Type:    "response",
+		V:       wsProtocolVersion,
+		ID:      cmd.ID,
+		Command: cmd.Type,
+		Data:    data,
+	}
+}
+
+// handleWSCommand applies a client command to its session, returning the
+// reply to send if any
+func (h *Handlers) handleWSCommand(session *wsSession, cmd wsCommand) *wsReply {
+	// Legacy messages only ever changed the subscription and got no reply
+	if cmd.V == 0 {
+		switch cmd.Type {
+		case wsCommandSubscribe:
+			session.sub.AddSymbols(normalizeSymbols(cmd.Symbols)...)
+		case wsCommandUnsubscribe:
+			session.sub.RemoveSymbols(normalizeSymbols(cmd.Symbols)...)
+		default:
+			h.logger.Debugf("Ignoring unknown WebSocket message type: %s", cmd.Type)
+			return nil
+		}
+		h.logger.Debugf("WebSocket client now subscribed to %v", session.sub.Symbols())
+		return nil
+	}
+
+	switch cmd.Type {
+	case wsCommandSubscribe, wsCommandUnsubscribe:
+		symbols := normalizeSymbols(cmd.Symbols)
+		if len(symbols) == 0 {
+			return wsErrorReply(cmd, wsErrInvalidArgument, "symbols is required")
+		}
+		if cmd.Type == wsCommandSubscribe {
+			session.sub.AddSymbols(symbols...)
+		} else {
+			session.sub.RemoveSymbols(symbols...)
+		}
+		h.logger.Debugf("WebSocket client now subscribed to %v", session.sub.Symbols())
+		return wsOKReply(cmd, map[string]interface{}{"symbols": session.sub.Symbols()})
+	case wsCommandSnapshot:
+		return h.wsSnapshot(session, cmd)
+	case wsCommandHistory:
+		return h.wsHistory(session, cmd)
+	case wsCommandPing:
+		return wsOKReply(cmd, map[string]interface{}{"time": time.Now()})
+	case wsCommandSetThrottle:
+		return h.wsSetThrottle(session, cmd)
+	default:
+		return wsErrorReply(cmd, wsErrUnknownCommand, fmt.Sprintf("unknown command: %s", cmd.Type))
+	}
+}
+
+// wsSnapshot returns the latest price of the requested symbols, or of the
+// subscribed symbols if none are given. Symbols without data are omitted
+func (h *Handlers) wsSnapshot(session *wsSession, cmd wsCommand) *wsReply {
+	symbols := normalizeSymbols(cmd.Symbols)
+	if len(symbols) == 0 {
+		symbols = session.sub.Symbols()
+	}
+	for _, symbol := range symbols {
+		if symbol == service.AllSymbols {
+			symbols = h.store.Symbols()
+			break
+		}
+	}
+
+	prices := []models.PriceUpdate{}
+	for _, symbol := range symbols {
+		if price, exists := h.store.Latest(symbol); exists {
+			prices = append(prices, price.Select(session.fields))
+		}
+	}
+
+	return wsOKReply(cmd, map[string]interface{}{
+		"prices": prices,
+		"count":  len(prices),
+	})
+}
+
+// wsHistory returns a page of raw price history with the same semantics as
+// the REST endpoint
+func (h *Handlers) wsHistory(session *wsSession, cmd wsCommand) *wsReply {
+	order, err := storage.ParseOrder(cmd.Order)
+	if err != nil {
+		return wsErrorReply(cmd, wsErrInvalidArgument, err.Error())
+	}
+	if cmd.Limit < 0 {
+		return wsErrorReply(cmd, wsErrInvalidArgument, "limit must not be negative")
+	}
+
+	symbol := defaultSymbol
+	if symbols := normalizeSymbols([]string{cmd.Symbol}); len(symbols) > 0 {
+		symbol = symbols[0]
+	}
+
+	query := storage.Query{
+		Limit: cmd.Limit,
+		Order: storage.OrderDesc,
+	}
+	if query.Limit == 0 {
+		query.Limit = 100
+	}
+	if cmd.Since > 0 {
+		query.Since = time.Unix(cmd.Since, 0)
+	}
+	if cmd.Until > 0 {
+		query.Until = time.Unix(cmd.Until, 0)
+	}
+	if cmd.Order != "" {
+		query.Order = order
+	}
+
+	page, err := h.queryRawHistory(symbol, query, order, cmd.Cursor)
+	if err != nil {
+		return wsErrorReply(cmd, wsErrInvalidArgument, err.Error())
+	}
+	updates := page.Updates
+	if updates == nil {
+		updates = []models.PriceUpdate{}
+	}
+
+	data := map[string]interface{}{
+		"symbol":  symbol,
+		"updates": models.SelectAll(updates, session.fields),
+		"count":   len(updates),
+	}
+	if page.NextCursor != "" {
+		data["next_cursor"] = page.NextCursor
+	}
+	return wsOKReply(cmd, data)
+}
+
+// wsSetThrottle changes the minimum interval between price updates of each
+// symbol, where "0" sends every update. Updates held back under the previous
+// interval are released
+func (h *Handlers) wsSetThrottle(session *wsSession, cmd wsCommand) *wsReply {
+	interval, err := time.ParseDuration(cmd.Interval)
+	if err != nil {
+		return wsErrorReply(cmd, wsErrInvalidArgument, "interval must be a duration such as 500ms or 1s")
+	}
+	if err := h.priceService.SetThrottle(session.sub, interval); err != nil {
+		return wsErrorReply(cmd, wsErrInvalidArgument, err.Error())
+	}
+	return wsOKReply(cmd, map[string]interface{}{"interval": interval.String()})
+}
@@ -0,0 +1,295 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/storage"
+	"bitcoin-price-streamer/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// secondsPerYear annualizes realized volatility
+const secondsPerYear = 365 * 24 * 60 * 60
+
+// Options configures the indicator periods, counted in price updates
+type Options struct {
+	SMAPeriod        int
+	EMAPeriod        int
+	RSIPeriod        int
+	BollingerPeriod  int
+	BollingerK       float64
+	MACDFast         int
+	MACDSlow         int
+	MACDSignal       int
+	VolatilityPeriod int
+	// History is the number of past values kept per symbol
+	History int
+}
+
+// DefaultOptions returns the conventional indicator periods
+func DefaultOptions() Options {
+	return Options{
+		SMAPeriod:        20,
+		EMAPeriod:        20,
+		RSIPeriod:        14,
+		BollingerPeriod:  20,
+		BollingerK:       2,
+		MACDFast:         12,
+		MACDSlow:         26,
+		MACDSignal:       9,
+		VolatilityPeriod: 30,
+		History:          1000,
+	}
+}
+
+// symbolState is the incremental state of one symbol's indicators
+type symbolState struct {
+	lastSeq   uint64
+	lastTime  time.Time
+	lastPrice float64
+	count     int
+
+	sma       *window
+	ema       *ema
+	gains     *wilder
+	losses    *wilder
+	bollinger *window
+	macdFast  *ema
+	macdSlow  *ema
+	signal    *ema
+	// returns holds squared log returns and intervals the seconds between
+	// the prices of each return
+	returns   *window
+	intervals *window
+
+	history []models.Indicators
+}
+
+// Calculator updates technical indicators incrementally with each stored
+// price update
+type Calculator struct {
+	options Options
+	symbols map[string]*symbolState
+	mutex   sync.RWMutex
+	logger  *logrus.Logger
+}
+
+// New creates an indicator calculator
+func New(options Options, logger *logrus.Logger) (*Calculator, error) {
+	periods := []int{options.SMAPeriod, options.EMAPeriod, options.RSIPeriod, options.BollingerPeriod,
+		options.MACDFast, options.MACDSlow, options.MACDSignal, options.VolatilityPeriod, options.History}
+	for _, period := range periods {
+		if period <= 0 {
+			return nil, fmt.Errorf("indicator periods must be positive")
+		}
+	}
+	if options.MACDFast >= options.MACDSlow {
+		return nil, fmt.Errorf("MACD fast period %d must be shorter than slow period %d",
+			options.MACDFast, options.MACDSlow)
+	}
+
+	return &Calculator{
+		options: options,
+		symbols: make(map[string]*symbolState),
+		logger:  logger,
+	}, nil
+}
+
+// NewFromEnv creates an indicator calculator with periods taken from
+// INDICATOR_SMA_PERIOD, INDICATOR_EMA_PERIOD, INDICATOR_RSI_PERIOD,
+// INDICATOR_BOLLINGER_PERIOD, INDICATOR_MACD_FAST, INDICATOR_MACD_SLOW,
+// INDICATOR_MACD_SIGNAL, INDICATOR_VOLATILITY_PERIOD and INDICATOR_HISTORY
+func NewFromEnv(logger *logrus.Logger) (*Calculator, error) {
+	options := DefaultOptions()
+	options.SMAPeriod = utils.GetEnvInt("INDICATOR_SMA_PERIOD", options.SMAPeriod)
+	options.EMAPeriod = utils.GetEnvInt("INDICATOR_EMA_PERIOD", options.EMAPeriod)
+	options.RSIPeriod = utils.GetEnvInt("INDICATOR_RSI_PERIOD", options.RSIPeriod)
+	options.BollingerPeriod = utils.GetEnvInt("INDICATOR_BOLLINGER_PERIOD", options.BollingerPeriod)
+	options.MACDFast = utils.GetEnvInt("INDICATOR_MACD_FAST", options.MACDFast)
+	options.MACDSlow = utils.GetEnvInt("INDICATOR_MACD_SLOW", options.MACDSlow)
+	options.MACDSignal = utils.GetEnvInt("INDICATOR_MACD_SIGNAL", options.MACDSignal)
+	options.VolatilityPeriod = utils.GetEnvInt("INDICATOR_VOLATILITY_PERIOD", options.VolatilityPeriod)
+	options.History = utils.GetEnvInt("INDICATOR_HISTORY", options.History)
+	return New(options, logger)
+}
+
+// Options returns the configured periods
+func (c *Calculator) Options() Options {
+	return c.options
+}
+
+// newSymbolState creates empty indicator state
+func (c *Calculator) newSymbolState() *symbolState {
+	return &symbolState{
+		sma:       newWindow(c.options.SMAPeriod),
+		ema:       newEMA(c.options.EMAPeriod),
+		gains:     &wilder{period: c.options.RSIPeriod},
+		losses:    &wilder{period: c.options.RSIPeriod},
+		bollinger: newWindow(c.options.BollingerPeriod),
+		macdFast:  newEMA(c.options.MACDFast),
+		macdSlow:  newEMA(c.options.MACDSlow),
+		signal:    newEMA(c.options.MACDSignal),
+		returns:   newWindow(c.options.VolatilityPeriod),
+		intervals: newWindow(c.options.VolatilityPeriod),
+	}
+}
+
+// Add folds a price update into its symbol's indicators. Updates already
+// seen or older than the last one are ignored, so backfilling is idempotent
+func (c *Calculator) Add(update models.PriceUpdate) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	s, exists := c.symbols[update.Symbol]
+	if !exists {
+		s = c.newSymbolState()
+		c.symbols[update.Symbol] = s
+	}
+	if s.count > 0 && (update.Seq != 0 && update.Seq <= s.lastSeq || update.Timestamp.Before(s.lastTime)) {
+		c.logger.Debugf("Ignoring stale %s update %d for indicators", update.Symbol, update.Seq)
+		return
+	}
+
+	price := update.Price
+	result := models.Indicators{
+		Symbol:    update.Symbol,
+		Seq:       update.Seq,
+		Timestamp: update.Timestamp,
+		Price:     price,
+	}
+
+	s.sma.add(price)
+	if s.sma.full {
+		result.SMA = float(s.sma.mean())
+	}
+	if s.ema.add(price) {
+		result.EMA = float(s.ema.value)
+	}
+
+	s.bollinger.add(price)
+	if s.bollinger.full {
+		middle, spread := s.bollinger.mean(), c.options.BollingerK*s.bollinger.stddev()
+		result.Bollinger = &models.BollingerBands{Upper: middle + spread, Middle: middle, Lower: middle - spread}
+	}
+
+	fastReady, slowReady := s.macdFast.add(price), s.macdSlow.add(price)
+	if fastReady && slowReady {
+		macd := s.macdFast.value - s.macdSlow.value
+		if s.signal.add(macd) {
+			result.MACD = &models.MACD{MACD: macd, Signal: s.signal.value, Histogram: macd - s.signal.value}
+		}
+	}
+
+	if s.count > 0 {
+		change := price - s.lastPrice
+		gainsReady := s.gains.add(max(change, 0))
+		lossesReady := s.losses.add(max(-change, 0))
+		if gainsReady && lossesReady {
+			result.RSI = float(rsi(s.gains.value, s.losses.value))
+		}
+
+		if price > 0 && s.lastPrice > 0 {
+			r := math.Log(price / s.lastPrice)
+			s.returns.add(r * r)
+			s.intervals.add(update.Timestamp.Sub(s.lastTime).Seconds())
+			if s.returns.full && s.intervals.sum > 0 {
+				result.Volatility = float(math.Sqrt(max(s.returns.sum, 0) * secondsPerYear / s.intervals.sum))
+			}
+		}
+	}
+
+	s.lastSeq = update.Seq
+	s.lastTime = update.Timestamp
+	s.lastPrice = price
+	s.count++
+
+	s.history = append(s.history, result)
+	if len(s.history) > c.options.History {
+		s.history = s.history[len(s.history)-c.options.History:]
+	}
+}
+
+// rsi converts average gains and losses into the relative strength index
+func rsi(gain, loss float64) float64 {
+	if loss == 0 {
+		if gain == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+gain/loss)
+}
+
+// float returns a pointer to v for optional JSON fields
+func float(v float64) *float64 {
+	return &v
+}
+
+// Backfill computes indicators over the updates already held in store, e.g.
+// after history was restored from disk
+func (c *Calculator) Backfill(store storage.Store) {
+	count := 0
+	for _, symbol := range store.Symbols() {
+		store.Iterate(symbol, func(update models.PriceUpdate) bool {
+			c.Add(update)
+			count++
+			return true
+		})
+	}
+	c.logger.Infof("Backfilled indicators from %d stored updates", count)
+}
+
+// Latest returns the most recent indicators of symbol
+func (c *Calculator) Latest(symbol string) (models.Indicators, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	s, exists := c.symbols[symbol]
+	if !exists || len(s.history) == 0 {
+		return models.Indicators{}, false
+	}
+	return s.history[len(s.history)-1], true
+}
+
+// At returns the indicators of symbol as of the update with sequence number seq
+func (c *Calculator) At(symbol string, seq uint64) (models.Indicators, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	s, exists := c.symbols[symbol]
+	if !exists {
+		return models.Indicators{}, false
+	}
+
+	i := sort.Search(len(s.history), func(i int) bool {
+		return s.history[i].Seq >= seq
+	})
+	if i == len(s.history) || s.history[i].Seq != seq {
+		return models.Indicators{}, false
+	}
+	return s.history[i], true
+}
+
+// History returns up to limit of the most recent indicators of symbol,
+// oldest first
+func (c *Calculator) History(symbol string, limit int) []models.Indicators {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	s, exists := c.symbols[symbol]
+	if !exists {
+		return nil
+	}
+
+	history := s.history
+	if limit > 0 && len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	return append([]models.Indicators(nil), history...)
+}
@@ -0,0 +1,167 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var base = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestCalculator(t *testing.T, options Options) *Calculator {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	c, err := New(options, logger)
+	require.NoError(t, err)
+	return c
+}
+
+func addPrices(c *Calculator, prices ...float64) {
+	for i, price := range prices {
+		c.Add(models.PriceUpdate{
+			Seq:       uint64(i + 1),
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			Price:     price,
+			Symbol:    "BTC",
+		})
+	}
+}
+
+func TestNewValidatesOptions(t *testing.T) {
+	options := DefaultOptions()
+	options.RSIPeriod = 0
+	_, err := New(options, logrus.New())
+	assert.Error(t, err)
+
+	options = DefaultOptions()
+	options.MACDFast = options.MACDSlow
+	_, err = New(options, logrus.New())
+	assert.Error(t, err)
+}
+
+func TestMovingAverages(t *testing.T) {
+	options := DefaultOptions()
+	options.SMAPeriod, options.EMAPeriod = 3, 3
+	c := newTestCalculator(t, options)
+
+	addPrices(c, 1, 2)
+	latest, ok := c.Latest("BTC")
+	require.True(t, ok)
+	assert.Nil(t, latest.SMA, "SMA should be omitted while warming up")
+	assert.Nil(t, latest.EMA)
+
+	addPrices(c, 1, 2, 3, 4, 5)
+	latest, _ = c.Latest("BTC")
+	require.NotNil(t, latest.SMA)
+	assert.InDelta(t, 4.0, *latest.SMA, 1e-9)
+
+	// Seeded with the average of 1, 2, 3 then alpha = 0.5
+	require.NotNil(t, latest.EMA)
+	assert.InDelta(t, 4.0, *latest.EMA, 1e-9)
+}
+
+func TestRSI(t *testing.T) {
+	options := DefaultOptions()
+	options.RSIPeriod = 2
+	c := newTestCalculator(t, options)
+
+	addPrices(c, 10, 11, 12)
+	latest, _ := c.Latest("BTC")
+	require.NotNil(t, latest.RSI)
+	assert.Equal(t, 100.0, *latest.RSI)
+
+	// Average gain 1 smoothed to 0.5, average loss 0 smoothed to 0.5
+	c.Add(models.PriceUpdate{Seq: 4, Timestamp: base.Add(3 * time.Second), Price: 11, Symbol: "BTC"})
+	latest, _ = c.Latest("BTC")
+	assert.InDelta(t, 50.0, *latest.RSI, 1e-9)
+}
+
+func TestBollingerBands(t *testing.T) {
+	options := DefaultOptions()
+	options.BollingerPeriod = 4
+	c := newTestCalculator(t, options)
+
+	addPrices(c, 2, 4, 4, 6)
+	latest, _ := c.Latest("BTC")
+	require.NotNil(t, latest.Bollinger)
+
+	// Mean 4, population standard deviation sqrt(2)
+	assert.InDelta(t, 4.0, latest.Bollinger.Middle, 1e-9)
+	assert.InDelta(t, 4+2*math.Sqrt2, latest.Bollinger.Upper, 1e-9)
+	assert.InDelta(t, 4-2*math.Sqrt2, latest.Bollinger.Lower, 1e-9)
+}
+
+func TestMACD(t *testing.T) {
+	options := DefaultOptions()
+	options.MACDFast, options.MACDSlow, options.MACDSignal = 2, 3, 2
+	c := newTestCalculator(t, options)
+
+	addPrices(c, 1, 2, 3)
+	latest, _ := c.Latest("BTC")
+	assert.Nil(t, latest.MACD, "signal line needs two MACD values")
+
+	c.Add(models.PriceUpdate{Seq: 4, Timestamp: base.Add(3 * time.Second), Price: 4, Symbol: "BTC"})
+	latest, _ = c.Latest("BTC")
+	require.NotNil(t, latest.MACD)
+
+	// Fast EMA: 1.5, 2.5, 3.5; slow EMA: 2, 3. MACD: 0.5, 0.5
+	assert.InDelta(t, 0.5, latest.MACD.MACD, 1e-9)
+	assert.InDelta(t, 0.5, latest.MACD.Signal, 1e-9)
+	assert.InDelta(t, 0.0, latest.MACD.Histogram, 1e-9)
+}
+
+func TestVolatility(t *testing.T) {
+	options := DefaultOptions()
+	options.VolatilityPeriod = 2
+	c := newTestCalculator(t, options)
+
+	addPrices(c, 100, 100, 100)
+	latest, _ := c.Latest("BTC")
+	require.NotNil(t, latest.Volatility)
+	assert.Equal(t, 0.0, *latest.Volatility)
+
+	c.Add(models.PriceUpdate{Seq: 4, Timestamp: base.Add(3 * time.Second), Price: 110, Symbol: "BTC"})
+	latest, _ = c.Latest("BTC")
+	r := math.Log(1.1)
+	assert.InDelta(t, math.Sqrt(r*r*secondsPerYear/2), *latest.Volatility, 1e-6)
+}
+
+func TestAddIgnoresStaleUpdates(t *testing.T) {
+	c := newTestCalculator(t, DefaultOptions())
+	addPrices(c, 1, 2, 3)
+
+	// Replaying the same updates, e.g. a backfill after live updates, is a no-op
+	addPrices(c, 1, 2, 3)
+	assert.Len(t, c.History("BTC", 0), 3)
+
+	indicators, ok := c.At("BTC", 2)
+	require.True(t, ok)
+	assert.Equal(t, 2.0, indicators.Price)
+
+	_, ok = c.At("BTC", 9)
+	assert.False(t, ok)
+	_, ok = c.Latest("ETH")
+	assert.False(t, ok)
+}
+
+func TestHistoryLimit(t *testing.T) {
+	options := DefaultOptions()
+	options.History = 3
+	c := newTestCalculator(t, options)
+	addPrices(c, 1, 2, 3, 4, 5)
+
+	history := c.History("BTC", 0)
+	require.Len(t, history, 3)
+	assert.Equal(t, uint64(3), history[0].Seq)
+
+	history = c.History("BTC", 2)
+	require.Len(t, history, 2)
+	assert.Equal(t, uint64(4), history[0].Seq)
+}
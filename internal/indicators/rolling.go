@@ -0,0 +1,102 @@
+package indicators
+
+import (
+	"math"
+)
+
+// ema is an exponential moving average seeded with the simple average of its
+// first period values
+type ema struct {
+	period int
+	alpha  float64
+	count  int
+	value  float64
+}
+
+// newEMA creates an exponential moving average over period values
+func newEMA(period int) *ema {
+	return &ema{period: period, alpha: 2 / float64(period+1)}
+}
+
+// add folds in a value and reports whether the average is warmed up
+func (e *ema) add(v float64) bool {
+	e.count++
+	switch {
+	case e.count < e.period:
+		e.value += v
+	case e.count == e.period:
+		e.value = (e.value + v) / float64(e.period)
+	default:
+		e.value += e.alpha * (v - e.value)
+	}
+	return e.ready()
+}
+
+// ready reports whether period values have been seen
+func (e *ema) ready() bool {
+	return e.count >= e.period
+}
+
+// window is a fixed-size ring of the most recent values with a running sum
+type window struct {
+	values []float64
+	next   int
+	full   bool
+	sum    float64
+}
+
+// newWindow creates a window over the last size values
+func newWindow(size int) *window {
+	return &window{values: make([]float64, size)}
+}
+
+// add pushes a value, evicting the oldest once the window is full
+func (w *window) add(v float64) {
+	if w.full {
+		w.sum -= w.values[w.next]
+	}
+	w.values[w.next] = v
+	w.sum += v
+
+	w.next++
+	if w.next == len(w.values) {
+		w.next = 0
+		w.full = true
+	}
+}
+
+// mean returns the average of the window
+func (w *window) mean() float64 {
+	return w.sum / float64(len(w.values))
+}
+
+// stddev returns the population standard deviation of the window. It is
+// computed from the values rather than a running sum of squares, which loses
+// precision for prices in the tens of thousands
+func (w *window) stddev() float64 {
+	mean := w.mean()
+	var squares float64
+	for _, v := range w.values {
+		squares += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(squares / float64(len(w.values)))
+}
+
+// wilder is the smoothed average used by RSI: a simple average of the first
+// period values, then (previous*(period-1) + value) / period
+type wilder struct {
+	period int
+	count  int
+	value  float64
+}
+
+// add folds in a value and reports whether the average is warmed up
+func (w *wilder) add(v float64) bool {
+	w.count++
+	if w.count <= w.period {
+		w.value += (v - w.value) / float64(w.count)
+	} else {
+		w.value = (w.value*float64(w.period-1) + v) / float64(w.period)
+	}
+	return w.count >= w.period
+}
@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing count
+type Counter struct {
+	value atomic.Int64
+}
+
+// Inc adds one to the counter
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add adds n to the counter
+func (c *Counter) Add(n int64) {
+	c.value.Add(n)
+}
+
+// Value returns the current count
+func (c *Counter) Value() int64 {
+	return c.value.Load()
+}
+
+// Gauge is a value that goes up and down
+type Gauge struct {
+	value atomic.Int64
+}
+
+// Inc adds one to the gauge
+func (g *Gauge) Inc() {
+	g.value.Add(1)
+}
+
+// Dec subtracts one from the gauge
+func (g *Gauge) Dec() {
+	g.value.Add(-1)
+}
+
+// Set sets the gauge to v
+func (g *Gauge) Set(v int64) {
+	g.value.Store(v)
+}
+
+// Value returns the current value
+func (g *Gauge) Value() int64 {
+	return g.value.Load()
+}
+
+// CounterVec is a family of counters told apart by the value of one label
+type CounterVec struct {
+	label    string
+	counters map[string]*Counter
+	mutex    sync.RWMutex
+}
+
+// With returns the counter for a label value, creating it on first use
+func (v *CounterVec) With(value string) *Counter {
+	v.mutex.RLock()
+	counter, exists := v.counters[value]
+	v.mutex.RUnlock()
+	if exists {
+		return counter
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if counter, exists = v.counters[value]; !exists {
+		counter = &Counter{}
+		v.counters[value] = counter
+	}
+	return counter
+}
+
+// Values returns the count of every label value seen
+func (v *CounterVec) Values() map[string]int64 {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	values := make(map[string]int64, len(v.counters))
+	for value, counter := range v.counters {
+		values[value] = counter.Value()
+	}
+	return values
+}
+
+// metric is a registered metric with its help text
+type metric struct {
+	help  string
+	value interface{}
+}
+
+// Registry holds named metrics and renders them in the Prometheus text format
+type Registry struct {
+	metrics map[string]metric
+	mutex   sync.Mutex
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// Counter returns the counter registered as name, registering it if needed
+func (r *Registry) Counter(name, help string) *Counter {
+	return register(r, name, help, func() *Counter { return &Counter{} })
+}
+
+// Gauge returns the gauge registered as name, registering it if needed
+func (r *Registry) Gauge(name, help string) *Gauge {
+	return register(r, name, help, func() *Gauge { return &Gauge{} })
+}
+
+// CounterVec returns the counter family registered as name, registering it
+// with label if needed
+func (r *Registry) CounterVec(name, help, label string) *CounterVec {
+	return register(r, name, help, func() *CounterVec {
+		return &CounterVec{label: label, counters: make(map[string]*Counter)}
+	})
+}
+
+// register returns the metric registered as name, or registers a new one.
+// Registering a name twice with different kinds of metric is a programming
+// error and panics
+func register[T any](r *Registry, name, help string, create func() T) T {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if m, exists := r.metrics[name]; exists {
+		value, ok := m.value.(T)
+		if !ok {
+			panic(fmt.Sprintf("metrics: %s is already registered as %T", name, m.value))
+		}
+		return value
+	}
+
+	value := create()
+	r.metrics[name] = metric{help: help, value: value}
+	return value
+}
+
+// WriteTo writes every metric in the Prometheus text exposition format,
+// sorted by name
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mutex.Lock()
+	names := make([]string, 0, len(r.metrics))
+	metrics := make(map[string]metric, len(r.metrics))
+	for name, m := range r.metrics {
+		names = append(names, name)
+		metrics[name] = m
+	}
+	r.mutex.Unlock()
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		m := metrics[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n", name, m.help)
+		switch value := m.value.(type) {
+		case *Counter:
+			fmt.Fprintf(&b, "# TYPE %s counter\n%s %d\n", name, name, value.Value())
+		case *Gauge:
+			fmt.Fprintf(&b, "# TYPE %s gauge\n%s %d\n", name, name, value.Value())
+		case *CounterVec:
+			fmt.Fprintf(&b, "# TYPE %s counter\n", name)
+			values := value.Values()
+			labels := make([]string, 0, len(values))
+			for label := range values {
+				labels = append(labels, label)
+			}
+			sort.Strings(labels)
+			for _, label := range labels {
+				fmt.Fprintf(&b, "%s{%s=%s} %d\n", name, value.label, strconv.Quote(label), values[label])
+			}
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
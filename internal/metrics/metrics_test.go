@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounterAndGauge(t *testing.T) {
+	registry := NewRegistry()
+
+	counter := registry.Counter("requests_total", "Requests served")
+	counter.Inc()
+	counter.Add(2)
+	assert.Equal(t, int64(3), counter.Value())
+
+	// Registering the same name returns the same metric
+	assert.Same(t, counter, registry.Counter("requests_total", "Requests served"))
+
+	gauge := registry.Gauge("connections", "Open connections")
+	gauge.Inc()
+	gauge.Inc()
+	gauge.Dec()
+	assert.Equal(t, int64(1), gauge.Value())
+	gauge.Set(7)
+	assert.Equal(t, int64(7), gauge.Value())
+
+	assert.Panics(t, func() {
+		registry.Gauge("requests_total", "Requests served")
+	})
+}
+
+func TestCounterVecConcurrentUse(t *testing.T) {
+	registry := NewRegistry()
+	vec := registry.CounterVec("disconnects_total", "Disconnects", "reason")
+
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				vec.With("timeout").Inc()
+			} else {
+				vec.With("closed").Inc()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, map[string]int64{"timeout": 50, "closed": 50}, vec.Values())
+}
+
+func TestWriteTo(t *testing.T) {
+	registry := NewRegistry()
+	registry.Gauge("b_connections", "Open connections").Set(2)
+	registry.Counter("a_total", "Things").Inc()
+	vec := registry.CounterVec("c_disconnects_total", "Disconnects", "reason")
+	vec.With("timeout").Add(3)
+	vec.With(`say "bye"`).Inc()
+
+	var b strings.Builder
+	_, err := registry.WriteTo(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, `# HELP a_total Things
+# TYPE a_total counter
+a_total 1
+# HELP b_connections Open connections
+# TYPE b_connections gauge
+b_connections 2
+# HELP c_disconnects_total Disconnects
+# TYPE c_disconnects_total counter
+c_disconnects_total{reason="say \"bye\""} 1
+c_disconnects_total{reason="timeout"} 3
+`, b.String())
+}
@@ -1,13 +1,292 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
 
-// PriceUpdate represents a Bitcoin price update
+// PriceUpdate represents an asset price update. Only seq, timestamp, price,
+// symbol and name are sent to clients by default, the market data fields are opt-in
 type PriceUpdate struct {
-	Timestamp time.Time `json:"timestamp"`
+	Seq              uint64        `json:"seq,omitempty"`
+	Timestamp        time.Time     `json:"timestamp"`
+	Price            float64       `json:"price"`
+	Symbol           string        `json:"symbol"`
+	Name             string        `json:"name"`
+	Change24h        float64       `json:"change_24h,omitempty"`
+	ChangePercent24h float64       `json:"change_percent_24h,omitempty"`
+	MarketCap        float64       `json:"market_cap,omitempty"`
+	Volume24h        float64       `json:"volume_24h,omitempty"`
+	Source           string        `json:"source,omitempty"`
+	Sources          []SourceQuote `json:"sources,omitempty"`
+}
+
+// Optional PriceUpdate fields that can be requested with a fields selector
+const (
+	FieldChange24h        = "change_24h"
+	FieldChangePercent24h = "change_percent_24h"
+	FieldMarketCap        = "market_cap"
+	FieldVolume24h        = "volume_24h"
+	FieldSource           = "source"
+	FieldSources          = "sources"
+)
+
+// optionalFields lists every selectable field
+var optionalFields = []string{
+	FieldChange24h,
+	FieldChangePercent24h,
+	FieldMarketCap,
+	FieldVolume24h,
+	FieldSource,
+	FieldSources,
+}
+
+// FieldSet is a set of optional PriceUpdate fields to include in payloads
+type FieldSet map[string]bool
+
+// ParseFields parses a comma-separated fields selector, where "all" selects
+// every optional field and an empty selector selects none
+func ParseFields(selector string) (FieldSet, error) {
+	fields := make(FieldSet)
+	for _, field := range strings.Split(selector, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		switch {
+		case field == "":
+			continue
+		case field == "all":
+			for _, f := range optionalFields {
+				fields[f] = true
+			}
+		case isOptionalField(field):
+			fields[field] = true
+		default:
+			return nil, fmt.Errorf("unknown field: %s", field)
+		}
+	}
+	return fields, nil
+}
+
+// isOptionalField reports whether field is a selectable field
+func isOptionalField(field string) bool {
+	for _, f := range optionalFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns a copy of the update with the optional fields that are not
+// in fields cleared, so they are omitted from the JSON payload
+func (u PriceUpdate) Select(fields FieldSet) PriceUpdate {
+	if !fields[FieldChange24h] {
+		u.Change24h = 0
+	}
+	if !fields[FieldChangePercent24h] {
+		u.ChangePercent24h = 0
+	}
+	if !fields[FieldMarketCap] {
+		u.MarketCap = 0
+	}
+	if !fields[FieldVolume24h] {
+		u.Volume24h = 0
+	}
+	if !fields[FieldSource] {
+		u.Source = ""
+	}
+	if !fields[FieldSources] {
+		u.Sources = nil
+	}
+	return u
+}
+
+// SelectAll applies Select to every update
+func SelectAll(updates []PriceUpdate, fields FieldSet) []PriceUpdate {
+	selected := make([]PriceUpdate, len(updates))
+	for i, update := range updates {
+		selected[i] = update.Select(fields)
+	}
+	return selected
+}
+
+// SourceQuote represents a single provider quote contributing to a consensus price
+type SourceQuote struct {
+	Source    string    `json:"source"`
 	Price     float64   `json:"price"`
+	Volume24h float64   `json:"volume_24h,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Candle is an OHLC bar of a symbol's price over one interval. The upstream
+// providers only report rolling 24h volume, so bars carry its latest value
+// rather than volume traded within the bar
+type Candle struct {
+	Symbol    string    `json:"symbol"`
+	Interval  string    `json:"interval"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Open      float64   `json:"open"`
+	High      float64   `json:"high"`
+	Low       float64   `json:"low"`
+	Close     float64   `json:"close"`
+	Volume24h float64   `json:"volume_24h,omitempty"`
+	Ticks     int       `json:"ticks"`
+	Closed    bool      `json:"closed"`
+}
+
+// Indicators are the technical indicators of a symbol's price as of one
+// update. Indicators still warming up (fewer updates than their period) are
+// omitted
+type Indicators struct {
+	Symbol     string          `json:"symbol"`
+	Seq        uint64          `json:"seq,omitempty"`
+	Timestamp  time.Time       `json:"timestamp"`
+	Price      float64         `json:"price"`
+	SMA        *float64        `json:"sma,omitempty"`
+	EMA        *float64        `json:"ema,omitempty"`
+	RSI        *float64        `json:"rsi,omitempty"`
+	Bollinger  *BollingerBands `json:"bollinger,omitempty"`
+	MACD       *MACD           `json:"macd,omitempty"`
+	Volatility *float64        `json:"volatility,omitempty"`
+}
+
+// BollingerBands are a moving average with bands k standard deviations away
+type BollingerBands struct {
+	Upper  float64 `json:"upper"`
+	Middle float64 `json:"middle"`
+	Lower  float64 `json:"lower"`
+}
+
+// MACD is the difference of a fast and slow EMA with its signal line
+type MACD struct {
+	MACD      float64 `json:"macd"`
+	Signal    float64 `json:"signal"`
+	Histogram float64 `json:"histogram"`
+}
+
+// Alert rule types
+const (
+	AlertPriceCross      = "price_cross"
+	AlertPercentMove     = "percent_move"
+	AlertNewHigh         = "new_high"
+	AlertNewLow          = "new_low"
+	AlertVolatilitySpike = "volatility_spike"
+)
+
+// AlertRule is a user-registered condition evaluated against every price
+// update of a symbol. Threshold is a price for price_cross, a percentage for
+// percent_move and an annualized volatility for volatility_spike. After
+// firing, a rule re-arms once the value retreats Hysteresis past the
+// threshold, and never fires again within its cooldown
+type AlertRule struct {
+	ID              string     `json:"id"`
+	Symbol          string     `json:"symbol"`
+	Type            string     `json:"type"`
+	Direction       string     `json:"direction,omitempty"`
+	Threshold       float64    `json:"threshold,omitempty"`
+	WindowSeconds   int        `json:"window_seconds,omitempty"`
+	Hysteresis      float64    `json:"hysteresis,omitempty"`
+	CooldownSeconds int        `json:"cooldown_seconds,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	LastTriggered   *time.Time `json:"last_triggered,omitempty"`
+	TriggerCount    int        `json:"trigger_count"`
+}
+
+// Alert is a firing of an alert rule. Value is the observed quantity compared
+// against the threshold: the price, percent change, previous 24h extreme or
+// volatility
+type Alert struct {
+	RuleID    string    `json:"rule_id"`
 	Symbol    string    `json:"symbol"`
-	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Seq       uint64    `json:"seq,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Price     float64   `json:"price"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold,omitempty"`
+	Message   string    `json:"message"`
+}
+
+// Webhook event types
+const (
+	WebhookEventPrice = "price"
+	WebhookEventAlert = "alert"
+)
+
+// WebhookEndpoint is an operator-registered URL receiving events as signed
+// JSON POSTs. Price events are only sent for Symbols (all when empty) and,
+// with MinChangePercent, once the price moved that much since the last one
+// sent to the endpoint. The secret is only returned when the endpoint is created
+type WebhookEndpoint struct {
+	ID               string    `json:"id"`
+	URL              string    `json:"url"`
+	Secret           string    `json:"secret,omitempty"`
+	Events           []string  `json:"events"`
+	Symbols          []string  `json:"symbols,omitempty"`
+	MinChangePercent float64   `json:"min_change_percent,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Webhook delivery attempt outcomes
+const (
+	DeliveryDelivered = "delivered"
+	DeliveryRetrying  = "retrying"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one attempt to deliver an event to a webhook endpoint
+type WebhookDelivery struct {
+	ID          string     `json:"id"`
+	EndpointID  string     `json:"endpoint_id"`
+	Event       string     `json:"event"`
+	Attempt     int        `json:"attempt"`
+	Status      string     `json:"status"`
+	StatusCode  int        `json:"status_code,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	DurationMs  int64      `json:"duration_ms"`
+	Timestamp   time.Time  `json:"timestamp"`
+	NextAttempt *time.Time `json:"next_attempt,omitempty"`
+}
+
+// WebhookDeadLetter is an event that could not be delivered after all retries
+type WebhookDeadLetter struct {
+	ID         string          `json:"id"`
+	EndpointID string          `json:"endpoint_id"`
+	Event      string          `json:"event"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempts   int             `json:"attempts"`
+	LastError  string          `json:"last_error"`
+	FailedAt   time.Time       `json:"failed_at"`
+}
+
+// GapNotice tells a reconnecting client that updates after its resume position
+// were evicted from storage, so the replay that follows is truncated
+type GapNotice struct {
+	Symbol           string     `json:"symbol"`
+	RequestedSeq     uint64     `json:"requested_seq,omitempty"`
+	RequestedSince   *time.Time `json:"requested_since,omitempty"`
+	MissedThroughSeq uint64     `json:"missed_through_seq"`
+	MissedThrough    time.Time  `json:"missed_through"`
+	ReplayFromSeq    uint64     `json:"replay_from_seq,omitempty"`
+	ReplayFrom       *time.Time `json:"replay_from,omitempty"`
+}
+
+// ResetNotice tells a reconnecting client that its resume position is unknown
+// to the server (e.g. history was lost in a restart) and it should re-fetch
+type ResetNotice struct {
+	Reason       string `json:"reason"`
+	RequestedSeq uint64 `json:"requested_seq"`
+	LastSeq      uint64 `json:"last_seq"`
+}
+
+// DisconnectNotice tells a client why the server closed its stream, along
+// with how many updates it had dropped
+type DisconnectNotice struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	Dropped uint64 `json:"dropped,omitempty"`
 }
 
 // CoinDeskResponse represents the response from the new CoinDesk API
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func enrichedUpdate() PriceUpdate {
+	return PriceUpdate{
+		Seq:              42,
+		Timestamp:        time.Now(),
+		Price:            50000.0,
+		Symbol:           "BTC",
+		Name:             "Bitcoin",
+		Change24h:        1200.0,
+		ChangePercent24h: 2.4,
+		MarketCap:        980000000000.0,
+		Volume24h:        25000000000.0,
+		Source:           "cadli",
+		Sources:          []SourceQuote{{Source: "coindesk", Price: 50000.0}},
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	fields, err := ParseFields("")
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+
+	fields, err = ParseFields("market_cap, Volume_24h")
+	require.NoError(t, err)
+	assert.Equal(t, FieldSet{FieldMarketCap: true, FieldVolume24h: true}, fields)
+
+	fields, err = ParseFields("all")
+	require.NoError(t, err)
+	assert.Len(t, fields, len(optionalFields))
+
+	_, err = ParseFields("market_cap,bogus")
+	assert.EqualError(t, err, "unknown field: bogus")
+}
+
+func TestSelectCompactByDefault(t *testing.T) {
+	data, err := json.Marshal(enrichedUpdate().Select(nil))
+	require.NoError(t, err)
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &payload))
+
+	// Only the compact fields are present
+	assert.Len(t, payload, 5)
+	assert.Contains(t, payload, "seq")
+	assert.Contains(t, payload, "timestamp")
+	assert.Contains(t, payload, "price")
+	assert.Contains(t, payload, "symbol")
+	assert.Contains(t, payload, "name")
+}
+
+func TestSelectRequestedFields(t *testing.T) {
+	update := enrichedUpdate()
+
+	selected := update.Select(FieldSet{FieldChange24h: true, FieldSource: true})
+
+	assert.Equal(t, update.Price, selected.Price)
+	assert.Equal(t, 1200.0, selected.Change24h)
+	assert.Equal(t, "cadli", selected.Source)
+	assert.Zero(t, selected.ChangePercent24h)
+	assert.Zero(t, selected.MarketCap)
+	assert.Zero(t, selected.Volume24h)
+	assert.Nil(t, selected.Sources)
+
+	// The original update is left untouched
+	assert.Equal(t, 980000000000.0, update.MarketCap)
+
+	all, _ := ParseFields("all")
+	assert.Equal(t, update, update.Select(all))
+}
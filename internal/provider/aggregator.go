@@ -0,0 +1,257 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ConsensusMethod selects how quotes from several providers are combined
+type ConsensusMethod string
+
+const (
+	ConsensusMedian      ConsensusMethod = "median"
+	ConsensusTrimmedMean ConsensusMethod = "trimmed_mean"
+	ConsensusVWAP        ConsensusMethod = "vwap"
+)
+
+// trimRatio is the fraction of quotes dropped from each end for the trimmed mean
+const trimRatio = 0.2
+
+// ParseConsensusMethod parses a consensus method name
+func ParseConsensusMethod(name string) (ConsensusMethod, error) {
+	switch method := ConsensusMethod(name); method {
+	case ConsensusMedian, ConsensusTrimmedMean, ConsensusVWAP:
+		return method, nil
+	default:
+		return "", fmt.Errorf("unknown consensus method: %s", name)
+	}
+}
+
+// Aggregator polls several providers concurrently and combines their quotes
+// into a single consensus price
+type Aggregator struct {
+	providers  []PriceProvider
+	method     ConsensusMethod
+	minSources int
+	logger     *logrus.Logger
+}
+
+// NewAggregator creates a new aggregator over the given providers
+func NewAggregator(providers []PriceProvider, method ConsensusMethod, minSources int, logger *logrus.Logger) *Aggregator {
+	if minSources < 1 {
+		minSources = 1
+	}
+
+	return &Aggregator{
+		providers:  providers,
+		method:     method,
+		minSources: minSources,
+		logger:     logger,
+	}
+}
+
+// Name returns the provider identifier, listing the underlying sources
+func (a *Aggregator) Name() string {
+	names := make([]string, len(a.providers))
+	for i, p := range a.providers {
+		names[i] = p.Name()
+	}
+	return fmt.Sprintf("%s(%s)", a.method, strings.Join(names, ","))
+}
+
+// SupportedSymbols returns the union of symbols supported by the providers
+func (a *Aggregator) SupportedSymbols() []string {
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, p := range a.providers {
+		for _, symbol := range p.SupportedSymbols() {
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	return symbols
+}
+
+// FetchPrices fetches symbols from every supporting provider concurrently and
+// returns one consensus price per symbol with the individual quotes attached
+func (a *Aggregator) FetchPrices(ctx context.Context, symbols []string) ([]models.PriceUpdate, error) {
+	var (
+		wg     sync.WaitGroup
+		mutex  sync.Mutex
+		quotes = make(map[string][]models.PriceUpdate)
+		order  []string
+		quoted = make(map[string][]string)
+	)
+
+	for _, p := range a.providers {
+		requested, ok := supportedOf(p, symbols)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func(p PriceProvider) {
+			defer wg.Done()
+
+			updates, err := p.FetchPrices(ctx, requested)
+			if err != nil {
+				if !errors.Is(err, ErrCircuitOpen) {
+					a.logger.Warnf("Provider %s failed to quote prices: %v", p.Name(), err)
+				}
+				return
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			for _, update := range updates {
+				if _, seen := quotes[update.Symbol]; !seen {
+					order = append(order, update.Symbol)
+				}
+				quotes[update.Symbol] = append(quotes[update.Symbol], update)
+				quoted[update.Symbol] = append(quoted[update.Symbol], p.Name())
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	sort.Strings(order)
+	var result []models.PriceUpdate
+	for _, symbol := range order {
+		if len(quotes[symbol]) < a.minSources {
+			a.logger.Debugf("Only %d of %d required sources quoted %s", len(quotes[symbol]), a.minSources, symbol)
+			continue
+		}
+		result = append(result, a.consensus(symbol, quotes[symbol], quoted[symbol]))
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no symbol was quoted by at least %d sources", a.minSources)
+	}
+	return result, nil
+}
+
+// consensus combines the quotes for one symbol into a single price update
+func (a *Aggregator) consensus(symbol string, quotes []models.PriceUpdate, quoted []string) models.PriceUpdate {
+	sources := make([]models.SourceQuote, len(quotes))
+	update := models.PriceUpdate{Symbol: symbol, Source: a.Name()}
+	for i, quote := range quotes {
+		sources[i] = models.SourceQuote{
+			Source:    quoted[i],
+			Price:     quote.Price,
+			Volume24h: quote.Volume24h,
+			Timestamp: quote.Timestamp,
+		}
+		if update.Name == "" {
+			update.Name = quote.Name
+		}
+		if update.MarketCap == 0 {
+			update.MarketCap = quote.MarketCap
+		}
+		if update.Change24h == 0 && update.ChangePercent24h == 0 {
+			update.Change24h = quote.Change24h
+			update.ChangePercent24h = quote.ChangePercent24h
+		}
+		if quote.Timestamp.After(update.Timestamp) {
+			update.Timestamp = quote.Timestamp
+		}
+		update.Volume24h += quote.Volume24h
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Source < sources[j].Source })
+
+	update.Price = Consensus(a.method, sources)
+	update.Sources = sources
+	if update.Timestamp.IsZero() {
+		update.Timestamp = time.Now()
+	}
+
+	return update
+}
+
+// Health returns the health of every underlying provider that tracks it
+func (a *Aggregator) Health() []ProviderHealth {
+	var health []ProviderHealth
+	for _, p := range a.providers {
+		if reporter, ok := p.(HealthReporter); ok {
+			health = append(health, reporter.Health()...)
+		}
+	}
+	return health
+}
+
+// Consensus combines source quotes into one price using the given method
+func Consensus(method ConsensusMethod, quotes []models.SourceQuote) float64 {
+	if len(quotes) == 0 {
+		return 0
+	}
+
+	switch method {
+	case ConsensusTrimmedMean:
+		return trimmedMean(quotes)
+	case ConsensusVWAP:
+		return vwap(quotes)
+	default:
+		return median(quotes)
+	}
+}
+
+// sortedPrices returns the quote prices in ascending order
+func sortedPrices(quotes []models.SourceQuote) []float64 {
+	prices := make([]float64, len(quotes))
+	for i, q := range quotes {
+		prices[i] = q.Price
+	}
+	sort.Float64s(prices)
+	return prices
+}
+
+// median returns the median quote price
+func median(quotes []models.SourceQuote) float64 {
+	prices := sortedPrices(quotes)
+	mid := len(prices) / 2
+	if len(prices)%2 == 0 {
+		return (prices[mid-1] + prices[mid]) / 2
+	}
+	return prices[mid]
+}
+
+// trimmedMean returns the mean price after dropping outliers from both ends
+func trimmedMean(quotes []models.SourceQuote) float64 {
+	prices := sortedPrices(quotes)
+	trim := int(math.Floor(float64(len(prices)) * trimRatio))
+	prices = prices[trim : len(prices)-trim]
+
+	var sum float64
+	for _, p := range prices {
+		sum += p
+	}
+	return sum / float64(len(prices))
+}
+
+// vwap returns the volume-weighted average price, falling back to the median
+// when no source reports volume
+func vwap(quotes []models.SourceQuote) float64 {
+	var weighted, volume float64
+	for _, q := range quotes {
+		if q.Volume24h > 0 {
+			weighted += q.Price * q.Volume24h
+			volume += q.Volume24h
+		}
+	}
+
+	if volume == 0 {
+		return median(quotes)
+	}
+	return weighted / volume
+}
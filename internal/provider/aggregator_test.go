@@ -0,0 +1,216 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubProvider is a PriceProvider returning a fixed quote or error
+type stubProvider struct {
+	name    string
+	price   float64
+	volume  float64
+	symbols []string
+	err     error
+	calls   int32
+}
+
+func (s *stubProvider) Name() string {
+	return s.name
+}
+
+func (s *stubProvider) SupportedSymbols() []string {
+	if s.symbols == nil {
+		return []string{"BTC"}
+	}
+	return s.symbols
+}
+
+func (s *stubProvider) FetchPrices(ctx context.Context, symbols []string) ([]models.PriceUpdate, error) {
+	atomic.AddInt32(&s.calls, 1)
+	if s.err != nil {
+		return nil, s.err
+	}
+	if len(symbols) == 0 {
+		symbols = s.SupportedSymbols()
+	}
+
+	updates := make([]models.PriceUpdate, len(symbols))
+	for i, symbol := range symbols {
+		updates[i] = models.PriceUpdate{
+			Timestamp: time.Now(),
+			Price:     s.price,
+			Symbol:    symbol,
+			Name:      assetNames[symbol],
+			Volume24h: s.volume,
+		}
+	}
+	return updates, nil
+}
+
+func TestConsensusMedian(t *testing.T) {
+	quotes := []models.SourceQuote{{Price: 100}, {Price: 300}, {Price: 200}}
+	assert.Equal(t, 200.0, Consensus(ConsensusMedian, quotes))
+
+	// Even number of quotes averages the middle two
+	quotes = append(quotes, models.SourceQuote{Price: 400})
+	assert.Equal(t, 250.0, Consensus(ConsensusMedian, quotes))
+}
+
+func TestConsensusTrimmedMean(t *testing.T) {
+	// One quote is trimmed from each end of five
+	quotes := []models.SourceQuote{{Price: 1}, {Price: 100}, {Price: 101}, {Price: 102}, {Price: 1000}}
+	assert.Equal(t, 101.0, Consensus(ConsensusTrimmedMean, quotes))
+
+	// Too few quotes to trim falls back to the plain mean
+	quotes = []models.SourceQuote{{Price: 100}, {Price: 200}}
+	assert.Equal(t, 150.0, Consensus(ConsensusTrimmedMean, quotes))
+}
+
+func TestConsensusVWAP(t *testing.T) {
+	quotes := []models.SourceQuote{
+		{Price: 100, Volume24h: 3},
+		{Price: 200, Volume24h: 1},
+	}
+	assert.Equal(t, 125.0, Consensus(ConsensusVWAP, quotes))
+
+	// Without volume the median is used
+	quotes = []models.SourceQuote{{Price: 100}, {Price: 200}, {Price: 300}}
+	assert.Equal(t, 200.0, Consensus(ConsensusVWAP, quotes))
+}
+
+func TestParseConsensusMethod(t *testing.T) {
+	method, err := ParseConsensusMethod("vwap")
+	assert.NoError(t, err)
+	assert.Equal(t, ConsensusVWAP, method)
+
+	_, err = ParseConsensusMethod("mode")
+	assert.Error(t, err)
+}
+
+func TestAggregatorFetchPrice(t *testing.T) {
+	aggregator := NewAggregator([]PriceProvider{
+		&stubProvider{name: "b", price: 101},
+		&stubProvider{name: "a", price: 100},
+		&stubProvider{name: "c", price: 150},
+		&stubProvider{name: "eth-only", price: 1, symbols: []string{"ETH"}},
+	}, ConsensusMedian, 2, logrus.New())
+
+	prices, err := aggregator.FetchPrices(context.Background(), []string{"BTC"})
+
+	require.NoError(t, err)
+	require.Len(t, prices, 1)
+	price := prices[0]
+	assert.Equal(t, "BTC", price.Symbol)
+	assert.Equal(t, "Bitcoin", price.Name)
+	assert.Equal(t, 101.0, price.Price)
+	assert.Equal(t, "median(b,a,c,eth-only)", price.Source)
+	require.Len(t, price.Sources, 3)
+	assert.Equal(t, "a", price.Sources[0].Source)
+	assert.Equal(t, 100.0, price.Sources[0].Price)
+	assert.Equal(t, "c", price.Sources[2].Source)
+
+	// The ETH-only provider is not asked for BTC
+	assert.Equal(t, int32(0), aggregator.providers[3].(*stubProvider).calls)
+}
+
+func TestAggregatorFetchPricesPerSymbol(t *testing.T) {
+	aggregator := NewAggregator([]PriceProvider{
+		&stubProvider{name: "a", price: 100, symbols: []string{"BTC", "ETH"}},
+		&stubProvider{name: "b", price: 200, symbols: []string{"BTC", "ETH", "SOL"}},
+		&stubProvider{name: "c", price: 300, symbols: []string{"BTC"}},
+	}, ConsensusMedian, 2, logrus.New())
+
+	// SOL is only quoted by one source and is dropped
+	prices, err := aggregator.FetchPrices(context.Background(), nil)
+
+	require.NoError(t, err)
+	require.Len(t, prices, 2)
+	assert.Equal(t, "BTC", prices[0].Symbol)
+	assert.Equal(t, 200.0, prices[0].Price)
+	assert.Len(t, prices[0].Sources, 3)
+	assert.Equal(t, "ETH", prices[1].Symbol)
+	assert.Equal(t, 150.0, prices[1].Price)
+	assert.Len(t, prices[1].Sources, 2)
+}
+
+func TestAggregatorToleratesFailingProvider(t *testing.T) {
+	aggregator := NewAggregator([]PriceProvider{
+		&stubProvider{name: "a", price: 100},
+		&stubProvider{name: "b", err: errors.New("timeout")},
+	}, ConsensusMedian, 1, logrus.New())
+
+	prices, err := aggregator.FetchPrices(context.Background(), []string{"BTC"})
+
+	require.NoError(t, err)
+	require.Len(t, prices, 1)
+	assert.Equal(t, 100.0, prices[0].Price)
+	assert.Len(t, prices[0].Sources, 1)
+}
+
+func TestAggregatorMinSources(t *testing.T) {
+	aggregator := NewAggregator([]PriceProvider{
+		&stubProvider{name: "a", price: 100},
+		&stubProvider{name: "b", err: errors.New("timeout")},
+	}, ConsensusMedian, 2, logrus.New())
+
+	prices, err := aggregator.FetchPrices(context.Background(), []string{"BTC"})
+
+	assert.Error(t, err)
+	assert.Nil(t, prices)
+	assert.Contains(t, err.Error(), "at least 2 sources")
+}
+
+func TestAggregatorSupportedSymbols(t *testing.T) {
+	aggregator := NewAggregator([]PriceProvider{
+		&stubProvider{name: "a", symbols: []string{"BTC", "ETH"}},
+		&stubProvider{name: "b", symbols: []string{"ETH", "SOL"}},
+	}, ConsensusMedian, 1, logrus.New())
+
+	assert.Equal(t, []string{"BTC", "ETH", "SOL"}, aggregator.SupportedSymbols())
+	assert.Equal(t, "median(a,b)", aggregator.Name())
+}
+
+func TestNewFromEnv(t *testing.T) {
+	logger := logrus.New()
+
+	// Single provider is used directly, with health tracking
+	p, err := NewFromEnv(logger)
+	require.NoError(t, err)
+	assert.IsType(t, &MonitoredProvider{}, p)
+	assert.Equal(t, "coindesk", p.Name())
+
+	// Several providers are aggregated
+	os.Setenv("PRICE_PROVIDERS", "coindesk,binance,coinbase")
+	defer os.Unsetenv("PRICE_PROVIDERS")
+	os.Setenv("CONSENSUS_METHOD", "vwap")
+	defer os.Unsetenv("CONSENSUS_METHOD")
+
+	p, err = NewFromEnv(logger)
+	require.NoError(t, err)
+	assert.Equal(t, "vwap(coindesk,binance,coinbase)", p.Name())
+	assert.Len(t, p.(HealthReporter).Health(), 3)
+
+	// Failover strategy
+	os.Setenv("PROVIDER_STRATEGY", "failover")
+	defer os.Unsetenv("PROVIDER_STRATEGY")
+
+	p, err = NewFromEnv(logger)
+	require.NoError(t, err)
+	assert.Equal(t, "failover(coindesk,binance,coinbase)", p.Name())
+
+	// Unknown providers are rejected
+	os.Setenv("PRICE_PROVIDERS", "coindesk,unknown")
+	_, err = NewFromEnv(logger)
+	assert.Error(t, err)
+}
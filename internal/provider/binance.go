@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// binanceTicker represents a Binance 24hr ticker entry
+type binanceTicker struct {
+	Symbol             string `json:"symbol"`
+	LastPrice          string `json:"lastPrice"`
+	PriceChange        string `json:"priceChange"`
+	PriceChangePercent string `json:"priceChangePercent"`
+	QuoteVolume        string `json:"quoteVolume"`
+	CloseTime          int64  `json:"closeTime"`
+}
+
+// BinanceProvider fetches prices from the Binance 24hr ticker API
+type BinanceProvider struct {
+	httpClient *http.Client
+	apiURL     string
+	logger     *logrus.Logger
+}
+
+// NewBinanceProvider creates a new Binance provider
+func NewBinanceProvider(logger *logrus.Logger) *BinanceProvider {
+	apiURL := utils.GetEnvString("BINANCE_API_URL", "https://api.binance.com/api/v3/ticker/24hr")
+
+	return &BinanceProvider{
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		apiURL: apiURL,
+		logger: logger,
+	}
+}
+
+// Name returns the provider identifier
+func (p *BinanceProvider) Name() string {
+	return "binance"
+}
+
+// SupportedSymbols returns the symbols this provider can quote
+func (p *BinanceProvider) SupportedSymbols() []string {
+	return []string{"BTC", "ETH", "SOL", "XRP", "BNB", "DOGE", "ADA"}
+}
+
+// FetchPrices fetches the latest USDT prices for symbols from the Binance API
+func (p *BinanceProvider) FetchPrices(ctx context.Context, symbols []string) ([]models.PriceUpdate, error) {
+	if len(symbols) == 0 {
+		symbols = p.SupportedSymbols()
+	}
+
+	pairs := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		pairs[i] = symbol + "USDT"
+	}
+	pairList, _ := json.Marshal(pairs)
+
+	var tickers []binanceTicker
+	requestURL := fmt.Sprintf("%s?symbols=%s", p.apiURL, url.QueryEscape(string(pairList)))
+	if err := fetchJSON(ctx, p.httpClient, requestURL, &tickers); err != nil {
+		return nil, err
+	}
+
+	updates := make([]models.PriceUpdate, 0, len(tickers))
+	for _, ticker := range tickers {
+		price, err := strconv.ParseFloat(ticker.LastPrice, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price %q for %s: %w", ticker.LastPrice, ticker.Symbol, err)
+		}
+
+		// Market data is informational only, so malformed values are treated as unknown
+		volume, _ := strconv.ParseFloat(ticker.QuoteVolume, 64)
+		change, _ := strconv.ParseFloat(ticker.PriceChange, 64)
+		changePercent, _ := strconv.ParseFloat(ticker.PriceChangePercent, 64)
+
+		timestamp := time.Now()
+		if ticker.CloseTime > 0 {
+			timestamp = time.UnixMilli(ticker.CloseTime)
+		}
+
+		symbol := strings.TrimSuffix(ticker.Symbol, "USDT")
+		updates = append(updates, models.PriceUpdate{
+			Timestamp:        timestamp,
+			Price:            price,
+			Symbol:           symbol,
+			Name:             assetNames[symbol],
+			Change24h:        change,
+			ChangePercent24h: changePercent,
+			Volume24h:        volume,
+			Source:           p.Name(),
+		})
+	}
+
+	return updates, nil
+}
+
+// SetAPIURL overrides the Binance API endpoint
+func (p *BinanceProvider) SetAPIURL(url string) {
+	p.apiURL = url
+}
@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinanceFetchPrice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, `["BTCUSDT","ETHUSDT"]`, r.URL.Query().Get("symbols"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[
+			{"symbol":"BTCUSDT","lastPrice":"50000.50","quoteVolume":"1234567.5","closeTime":1700000000000},
+			{"symbol":"ETHUSDT","lastPrice":"3000.25","quoteVolume":"7654321","closeTime":1700000000000}
+		]`))
+	}))
+	defer server.Close()
+
+	provider := NewBinanceProvider(logrus.New())
+	provider.SetAPIURL(server.URL)
+
+	prices, err := provider.FetchPrices(context.Background(), []string{"BTC", "ETH"})
+
+	require.NoError(t, err)
+	require.Len(t, prices, 2)
+	assert.Equal(t, "BTC", prices[0].Symbol)
+	assert.Equal(t, "Bitcoin", prices[0].Name)
+	assert.Equal(t, 50000.50, prices[0].Price)
+	assert.Equal(t, 1234567.5, prices[0].Volume24h)
+	assert.Equal(t, int64(1700000000000), prices[0].Timestamp.UnixMilli())
+	assert.Equal(t, "ETH", prices[1].Symbol)
+	assert.Equal(t, "Ethereum", prices[1].Name)
+}
+
+func TestBinanceFetchPriceInvalidPrice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"symbol":"BTCUSDT","lastPrice":"n/a"}]`))
+	}))
+	defer server.Close()
+
+	provider := NewBinanceProvider(logrus.New())
+	provider.SetAPIURL(server.URL)
+
+	prices, err := provider.FetchPrices(context.Background(), []string{"BTC"})
+
+	assert.Error(t, err)
+	assert.Nil(t, prices)
+	assert.Contains(t, err.Error(), "invalid price")
+}
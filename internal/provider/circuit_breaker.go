@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a circuit breaker
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// CircuitBreaker stops calls to a provider after repeated failures and lets a
+// single trial call through once the open timeout has elapsed
+type CircuitBreaker struct {
+	failureThreshold    int
+	openTimeout         time.Duration
+	state               CircuitState
+	consecutiveFailures int
+	openedAt            time.Time
+	mutex               sync.Mutex
+}
+
+// NewCircuitBreaker creates a closed circuit breaker
+func NewCircuitBreaker(failureThreshold int, openTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		state:            CircuitClosed,
+	}
+}
+
+// Allow reports whether a call may proceed, moving an expired open circuit to half-open
+func (cb *CircuitBreaker) Allow() bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	switch cb.state {
+	case CircuitOpen:
+		if time.Since(cb.openedAt) < cb.openTimeout {
+			return false
+		}
+		// Let one trial call through
+		cb.state = CircuitHalfOpen
+		return true
+	case CircuitHalfOpen:
+		// A trial call is already in flight
+		return false
+	default:
+		return true
+	}
+}
+
+// RecordSuccess closes the circuit and resets the failure count
+func (cb *CircuitBreaker) RecordSuccess() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.state = CircuitClosed
+	cb.consecutiveFailures = 0
+}
+
+// RecordFailure counts a failure and reports whether it opened the circuit
+func (cb *CircuitBreaker) RecordFailure() bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.consecutiveFailures++
+	if cb.state == CircuitHalfOpen || cb.consecutiveFailures >= cb.failureThreshold {
+		opened := cb.state != CircuitOpen
+		cb.state = CircuitOpen
+		cb.openedAt = time.Now()
+		return opened
+	}
+	return false
+}
+
+// State returns the current circuit state
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	return cb.state
+}
@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	cb := NewCircuitBreaker(3, time.Minute)
+
+	assert.Equal(t, CircuitClosed, cb.State())
+	assert.False(t, cb.RecordFailure())
+	assert.False(t, cb.RecordFailure())
+	assert.True(t, cb.RecordFailure(), "Third failure should open the circuit")
+
+	assert.Equal(t, CircuitOpen, cb.State())
+	assert.False(t, cb.Allow())
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	cb := NewCircuitBreaker(2, time.Minute)
+
+	cb.RecordFailure()
+	cb.RecordSuccess()
+	assert.False(t, cb.RecordFailure(), "Failure count should reset after success")
+	assert.Equal(t, CircuitClosed, cb.State())
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	cb := NewCircuitBreaker(1, 10*time.Millisecond)
+
+	cb.RecordFailure()
+	assert.False(t, cb.Allow())
+
+	time.Sleep(20 * time.Millisecond)
+
+	// Only one trial call is let through
+	assert.True(t, cb.Allow())
+	assert.Equal(t, CircuitHalfOpen, cb.State())
+	assert.False(t, cb.Allow())
+
+	// A failed trial reopens the circuit
+	assert.True(t, cb.RecordFailure())
+	assert.Equal(t, CircuitOpen, cb.State())
+
+	time.Sleep(20 * time.Millisecond)
+
+	// A successful trial closes it
+	assert.True(t, cb.Allow())
+	cb.RecordSuccess()
+	assert.Equal(t, CircuitClosed, cb.State())
+	assert.True(t, cb.Allow())
+}
@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// coinbaseTicker represents the Coinbase Exchange product ticker response
+type coinbaseTicker struct {
+	Price  string    `json:"price"`
+	Volume string    `json:"volume"`
+	Time   time.Time `json:"time"`
+}
+
+// CoinbaseProvider fetches prices from the Coinbase Exchange ticker API
+type CoinbaseProvider struct {
+	httpClient *http.Client
+	apiURL     string
+	logger     *logrus.Logger
+}
+
+// NewCoinbaseProvider creates a new Coinbase provider
+func NewCoinbaseProvider(logger *logrus.Logger) *CoinbaseProvider {
+	apiURL := utils.GetEnvString("COINBASE_API_URL", "https://api.exchange.coinbase.com/products")
+
+	return &CoinbaseProvider{
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		apiURL: apiURL,
+		logger: logger,
+	}
+}
+
+// Name returns the provider identifier
+func (p *CoinbaseProvider) Name() string {
+	return "coinbase"
+}
+
+// SupportedSymbols returns the symbols this provider can quote
+func (p *CoinbaseProvider) SupportedSymbols() []string {
+	return []string{"BTC", "ETH", "SOL", "XRP", "DOGE", "ADA"}
+}
+
+// FetchPrices fetches the latest USD prices for symbols from the Coinbase API,
+// one product ticker per symbol
+func (p *CoinbaseProvider) FetchPrices(ctx context.Context, symbols []string) ([]models.PriceUpdate, error) {
+	if len(symbols) == 0 {
+		symbols = p.SupportedSymbols()
+	}
+
+	var updates []models.PriceUpdate
+	var lastErr error
+	for _, symbol := range symbols {
+		update, err := p.fetchPrice(ctx, symbol)
+		if err != nil {
+			p.logger.Debugf("Coinbase failed to quote %s: %v", symbol, err)
+			lastErr = err
+			continue
+		}
+		updates = append(updates, *update)
+	}
+
+	if len(updates) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return updates, nil
+}
+
+// fetchPrice fetches the ticker for a single symbol
+func (p *CoinbaseProvider) fetchPrice(ctx context.Context, symbol string) (*models.PriceUpdate, error) {
+	var ticker coinbaseTicker
+	url := fmt.Sprintf("%s/%s-USD/ticker", p.apiURL, symbol)
+	if err := fetchJSON(ctx, p.httpClient, url, &ticker); err != nil {
+		return nil, err
+	}
+
+	price, err := strconv.ParseFloat(ticker.Price, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price %q: %w", ticker.Price, err)
+	}
+
+	// Coinbase reports volume in the base asset, convert it to USD
+	volume, _ := strconv.ParseFloat(ticker.Volume, 64)
+
+	timestamp := ticker.Time
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	return &models.PriceUpdate{
+		Timestamp: timestamp,
+		Price:     price,
+		Symbol:    symbol,
+		Name:      assetNames[symbol],
+		Volume24h: volume * price,
+		Source:    p.Name(),
+	}, nil
+}
+
+// SetAPIURL overrides the Coinbase API endpoint
+func (p *CoinbaseProvider) SetAPIURL(url string) {
+	p.apiURL = url
+}
@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoinbaseFetchPrice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/BTC-USD/ticker", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"price":"50000.00","volume":"10","time":"2024-01-15T10:30:00Z"}`))
+	}))
+	defer server.Close()
+
+	provider := NewCoinbaseProvider(logrus.New())
+	provider.SetAPIURL(server.URL)
+
+	prices, err := provider.FetchPrices(context.Background(), []string{"BTC"})
+
+	require.NoError(t, err)
+	require.Len(t, prices, 1)
+	assert.Equal(t, "BTC", prices[0].Symbol)
+	assert.Equal(t, 50000.0, prices[0].Price)
+	// Base volume is converted to USD
+	assert.Equal(t, 500000.0, prices[0].Volume24h)
+	assert.Equal(t, "2024-01-15T10:30:00Z", prices[0].Timestamp.UTC().Format("2006-01-02T15:04:05Z"))
+}
+
+func TestCoinbaseFetchPricesPartial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/BTC-USD/ticker" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"price":"50000.00","volume":"10","time":"2024-01-15T10:30:00Z"}`))
+	}))
+	defer server.Close()
+
+	provider := NewCoinbaseProvider(logrus.New())
+	provider.SetAPIURL(server.URL)
+
+	// Products that fail are skipped
+	prices, err := provider.FetchPrices(context.Background(), []string{"BTC", "ETH"})
+
+	require.NoError(t, err)
+	require.Len(t, prices, 1)
+	assert.Equal(t, "BTC", prices[0].Symbol)
+}
+
+func TestCoinbaseFetchPriceAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	provider := NewCoinbaseProvider(logrus.New())
+	provider.SetAPIURL(server.URL)
+
+	prices, err := provider.FetchPrices(context.Background(), []string{"BTC"})
+
+	assert.Error(t, err)
+	assert.Nil(t, prices)
+	assert.Contains(t, err.Error(), "status code: 404")
+}
@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CoinDeskProvider fetches prices from the CoinDesk top list API
+type CoinDeskProvider struct {
+	httpClient *http.Client
+	apiURL     string
+	symbols    []string
+	mutex      sync.RWMutex
+	logger     *logrus.Logger
+}
+
+// NewCoinDeskProvider creates a new CoinDesk provider
+func NewCoinDeskProvider(logger *logrus.Logger) *CoinDeskProvider {
+	apiURL := utils.GetEnvString("COINDESK_API_URL", "https://data-api.coindesk.com/asset/v1/top/list")
+
+	return &CoinDeskProvider{
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		apiURL: apiURL,
+		logger: logger,
+	}
+}
+
+// Name returns the provider identifier
+func (p *CoinDeskProvider) Name() string {
+	return "coindesk"
+}
+
+// SupportedSymbols returns the symbols listed in the last API response, or
+// nil before the first successful fetch
+func (p *CoinDeskProvider) SupportedSymbols() []string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.symbols
+}
+
+// FetchPrices fetches the latest prices for symbols from the CoinDesk top list
+func (p *CoinDeskProvider) FetchPrices(ctx context.Context, symbols []string) ([]models.PriceUpdate, error) {
+	var apiResponse models.CoinDeskResponse
+	if err := fetchJSON(ctx, p.httpClient, p.apiURL, &apiResponse); err != nil {
+		return nil, err
+	}
+
+	wanted := symbolSet(symbols)
+	listed := make([]string, 0, len(apiResponse.Data.List))
+	var updates []models.PriceUpdate
+
+	for _, asset := range apiResponse.Data.List {
+		listed = append(listed, asset.Symbol)
+		if len(wanted) > 0 && !wanted[asset.Symbol] {
+			continue
+		}
+		updates = append(updates, convertAsset(asset))
+	}
+
+	p.mutex.Lock()
+	p.symbols = listed
+	p.mutex.Unlock()
+
+	if len(updates) == 0 {
+		if len(symbols) == 0 {
+			return nil, fmt.Errorf("no asset data in API response")
+		}
+		return nil, fmt.Errorf("%s data not found in API response", strings.Join(symbols, ","))
+	}
+
+	return updates, nil
+}
+
+// convertAsset converts CoinDesk asset data to a price update
+func convertAsset(asset models.AssetData) models.PriceUpdate {
+	// Convert timestamp from Unix timestamp to time.Time
+	timestamp := time.Unix(asset.PriceUSDLastUpdateTS, 0)
+
+	// Use current time if the API timestamp is too old (more than 1 hour)
+	if time.Since(timestamp) > time.Hour {
+		timestamp = time.Now()
+	}
+
+	return models.PriceUpdate{
+		Timestamp:        timestamp,
+		Price:            asset.PriceUSD,
+		Symbol:           asset.Symbol,
+		Name:             asset.Name,
+		Change24h:        asset.SpotMoving24HourChangeUSD,
+		ChangePercent24h: asset.SpotMoving24HourChangePercentageUSD,
+		MarketCap:        asset.CirculatingMktCapUSD,
+		Volume24h:        asset.SpotMoving24HourQuoteVolumeUSD,
+		Source:           asset.PriceUSDSource,
+	}
+}
+
+// SetAPIURL overrides the CoinDesk API endpoint
+func (p *CoinDeskProvider) SetAPIURL(url string) {
+	p.apiURL = url
+}
@@ -0,0 +1,181 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCoinDeskServer creates a mock CoinDesk API returning the given assets
+func newCoinDeskServer(assets []models.AssetData) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response models.CoinDeskResponse
+		response.Data.List = assets
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+}
+
+func TestNewCoinDeskProvider(t *testing.T) {
+	logger := logrus.New()
+
+	provider := NewCoinDeskProvider(logger)
+
+	assert.NotNil(t, provider)
+	assert.NotNil(t, provider.httpClient)
+	assert.Equal(t, "https://data-api.coindesk.com/asset/v1/top/list", provider.apiURL)
+	assert.Equal(t, "coindesk", provider.Name())
+	assert.Nil(t, provider.SupportedSymbols())
+}
+
+func TestCoinDeskFetchPrice(t *testing.T) {
+	server := newCoinDeskServer([]models.AssetData{
+		{
+			Symbol:                              "BTC",
+			Name:                                "Bitcoin",
+			PriceUSD:                            50000.0,
+			PriceUSDSource:                      "cadli",
+			PriceUSDLastUpdateTS:                time.Now().Unix(),
+			SpotMoving24HourChangeUSD:           1200.0,
+			SpotMoving24HourChangePercentageUSD: 2.4,
+			CirculatingMktCapUSD:                980000000000.0,
+			SpotMoving24HourQuoteVolumeUSD:      25000000000.0,
+		},
+	})
+	defer server.Close()
+
+	provider := NewCoinDeskProvider(logrus.New())
+	provider.SetAPIURL(server.URL)
+
+	prices, err := provider.FetchPrices(context.Background(), []string{"BTC"})
+
+	assert.NoError(t, err)
+	require.Len(t, prices, 1)
+	assert.Equal(t, "BTC", prices[0].Symbol)
+	assert.Equal(t, "Bitcoin", prices[0].Name)
+	assert.Equal(t, 50000.0, prices[0].Price)
+
+	// Market data is carried through
+	assert.Equal(t, 1200.0, prices[0].Change24h)
+	assert.Equal(t, 2.4, prices[0].ChangePercent24h)
+	assert.Equal(t, 980000000000.0, prices[0].MarketCap)
+	assert.Equal(t, 25000000000.0, prices[0].Volume24h)
+	assert.Equal(t, "cadli", prices[0].Source)
+}
+
+func TestCoinDeskFetchAllPrices(t *testing.T) {
+	server := newCoinDeskServer([]models.AssetData{
+		{Symbol: "BTC", Name: "Bitcoin", PriceUSD: 50000.0, PriceUSDLastUpdateTS: time.Now().Unix()},
+		{Symbol: "ETH", Name: "Ethereum", PriceUSD: 3000.0, PriceUSDLastUpdateTS: time.Now().Unix()},
+		{Symbol: "SOL", Name: "Solana", PriceUSD: 150.0, PriceUSDLastUpdateTS: time.Now().Unix()},
+	})
+	defer server.Close()
+
+	provider := NewCoinDeskProvider(logrus.New())
+	provider.SetAPIURL(server.URL)
+
+	// No symbols returns every listed asset
+	prices, err := provider.FetchPrices(context.Background(), nil)
+
+	require.NoError(t, err)
+	require.Len(t, prices, 3)
+	assert.Equal(t, "ETH", prices[1].Symbol)
+	assert.Equal(t, 3000.0, prices[1].Price)
+
+	// Listed symbols become the supported symbols
+	assert.Equal(t, []string{"BTC", "ETH", "SOL"}, provider.SupportedSymbols())
+
+	// An allowlist filters the list
+	prices, err = provider.FetchPrices(context.Background(), []string{"SOL", "BTC"})
+
+	require.NoError(t, err)
+	require.Len(t, prices, 2)
+	assert.Equal(t, "BTC", prices[0].Symbol)
+	assert.Equal(t, "SOL", prices[1].Symbol)
+}
+
+func TestCoinDeskFetchPriceNotFound(t *testing.T) {
+	// Mock server that doesn't return Bitcoin
+	server := newCoinDeskServer([]models.AssetData{
+		{
+			Symbol:               "ETH",
+			Name:                 "Ethereum",
+			PriceUSD:             3000.0,
+			PriceUSDLastUpdateTS: time.Now().Unix(),
+		},
+	})
+	defer server.Close()
+
+	provider := NewCoinDeskProvider(logrus.New())
+	provider.SetAPIURL(server.URL)
+
+	prices, err := provider.FetchPrices(context.Background(), []string{"BTC"})
+
+	assert.Error(t, err)
+	assert.Nil(t, prices)
+	assert.Contains(t, err.Error(), "BTC data not found")
+}
+
+func TestCoinDeskFetchPriceStaleTimestamp(t *testing.T) {
+	server := newCoinDeskServer([]models.AssetData{
+		{
+			Symbol:               "BTC",
+			Name:                 "Bitcoin",
+			PriceUSD:             50000.0,
+			PriceUSDLastUpdateTS: time.Now().Add(-2 * time.Hour).Unix(),
+		},
+	})
+	defer server.Close()
+
+	provider := NewCoinDeskProvider(logrus.New())
+	provider.SetAPIURL(server.URL)
+
+	prices, err := provider.FetchPrices(context.Background(), []string{"BTC"})
+
+	// Timestamps older than an hour are replaced with the current time
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), prices[0].Timestamp, 5*time.Second)
+}
+
+func TestCoinDeskFetchPriceAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	provider := NewCoinDeskProvider(logrus.New())
+	provider.SetAPIURL(server.URL)
+
+	prices, err := provider.FetchPrices(context.Background(), []string{"BTC"})
+
+	assert.Error(t, err)
+	assert.Nil(t, prices)
+	assert.Contains(t, err.Error(), "status code: 500")
+}
+
+func TestCoinDeskFetchPriceInvalidJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("invalid json"))
+	}))
+	defer server.Close()
+
+	provider := NewCoinDeskProvider(logrus.New())
+	provider.SetAPIURL(server.URL)
+
+	prices, err := provider.FetchPrices(context.Background(), []string{"BTC"})
+
+	assert.Error(t, err)
+	assert.Nil(t, prices)
+	assert.Contains(t, err.Error(), "failed to decode")
+}
@@ -0,0 +1,132 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FailoverProvider queries the healthiest provider first and fails over to the
+// next-healthiest one when a fetch fails
+type FailoverProvider struct {
+	providers []*MonitoredProvider
+	logger    *logrus.Logger
+}
+
+// NewFailoverProvider creates a failover provider, preferring earlier providers on ties
+func NewFailoverProvider(providers []*MonitoredProvider, logger *logrus.Logger) *FailoverProvider {
+	return &FailoverProvider{
+		providers: providers,
+		logger:    logger,
+	}
+}
+
+// Name returns the provider identifier, listing the underlying sources
+func (fp *FailoverProvider) Name() string {
+	names := make([]string, len(fp.providers))
+	for i, p := range fp.providers {
+		names[i] = p.Name()
+	}
+	return fmt.Sprintf("failover(%s)", strings.Join(names, ","))
+}
+
+// SupportedSymbols returns the union of symbols supported by the providers
+func (fp *FailoverProvider) SupportedSymbols() []string {
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, p := range fp.providers {
+		for _, symbol := range p.SupportedSymbols() {
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	return symbols
+}
+
+// FetchPrices tries providers in order of health score, failing over to the
+// next provider for any requested symbols that are still missing
+func (fp *FailoverProvider) FetchPrices(ctx context.Context, symbols []string) ([]models.PriceUpdate, error) {
+	var (
+		result  []models.PriceUpdate
+		errs    []error
+		missing = symbols
+	)
+
+	for _, p := range fp.ranked() {
+		requested, ok := supportedOf(p, missing)
+		if !ok {
+			continue
+		}
+
+		updates, err := p.FetchPrices(ctx, requested)
+		if err != nil {
+			if !errors.Is(err, ErrCircuitOpen) {
+				fp.logger.Warnf("Provider %s failed to quote prices, failing over: %v", p.Name(), err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+
+		result = append(result, updates...)
+
+		// A request for every symbol is satisfied by the first healthy provider
+		if len(symbols) == 0 {
+			return result, nil
+		}
+
+		got := make(map[string]bool, len(updates))
+		for _, update := range updates {
+			got[update.Symbol] = true
+		}
+		var remaining []string
+		for _, symbol := range missing {
+			if !got[symbol] {
+				remaining = append(remaining, symbol)
+			}
+		}
+		if missing = remaining; len(missing) == 0 {
+			return result, nil
+		}
+	}
+
+	if len(result) > 0 {
+		return result, nil
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no provider supports %s", strings.Join(symbols, ","))
+	}
+	return nil, fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+}
+
+// ranked returns the providers ordered by descending health score
+func (fp *FailoverProvider) ranked() []*MonitoredProvider {
+	ranked := make([]*MonitoredProvider, len(fp.providers))
+	copy(ranked, fp.providers)
+
+	scores := make(map[*MonitoredProvider]float64, len(ranked))
+	for _, p := range ranked {
+		scores[p] = p.Score()
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return scores[ranked[i]] > scores[ranked[j]]
+	})
+
+	return ranked
+}
+
+// Health returns the health of every underlying provider
+func (fp *FailoverProvider) Health() []ProviderHealth {
+	var health []ProviderHealth
+	for _, p := range fp.providers {
+		health = append(health, p.Health()...)
+	}
+	return health
+}
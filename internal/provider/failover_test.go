@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMonitored(p PriceProvider, threshold int) *MonitoredProvider {
+	return NewMonitoredProvider(p, NewCircuitBreaker(threshold, time.Minute), logrus.New())
+}
+
+func TestFailoverUsesNextProvider(t *testing.T) {
+	primary := &stubProvider{name: "primary", err: errors.New("timeout")}
+	secondary := &stubProvider{name: "secondary", price: 100}
+
+	failover := NewFailoverProvider([]*MonitoredProvider{
+		newMonitored(primary, 5),
+		newMonitored(secondary, 5),
+	}, logrus.New())
+
+	prices, err := failover.FetchPrices(context.Background(), []string{"BTC"})
+
+	require.NoError(t, err)
+	require.Len(t, prices, 1)
+	assert.Equal(t, 100.0, prices[0].Price)
+	assert.Equal(t, int32(1), primary.calls)
+	assert.Equal(t, int32(1), secondary.calls)
+}
+
+func TestFailoverPrefersHealthiestProvider(t *testing.T) {
+	primary := &stubProvider{name: "primary", err: errors.New("timeout")}
+	secondary := &stubProvider{name: "secondary", price: 100}
+
+	failover := NewFailoverProvider([]*MonitoredProvider{
+		newMonitored(primary, 5),
+		newMonitored(secondary, 5),
+	}, logrus.New())
+
+	// The first failure lowers the primary's score below the secondary's
+	failover.FetchPrices(context.Background(), []string{"BTC"})
+	failover.FetchPrices(context.Background(), []string{"BTC"})
+	failover.FetchPrices(context.Background(), []string{"BTC"})
+
+	assert.Equal(t, int32(1), primary.calls)
+	assert.Equal(t, int32(3), secondary.calls)
+}
+
+func TestFailoverSkipsOpenCircuit(t *testing.T) {
+	primary := &stubProvider{name: "primary", err: errors.New("timeout")}
+	secondary := &stubProvider{name: "secondary", err: errors.New("timeout")}
+
+	failover := NewFailoverProvider([]*MonitoredProvider{
+		newMonitored(primary, 2),
+		newMonitored(secondary, 5),
+	}, logrus.New())
+
+	// Two failures open the primary circuit
+	failover.FetchPrices(context.Background(), []string{"BTC"})
+	failover.FetchPrices(context.Background(), []string{"BTC"})
+	assert.Equal(t, int32(2), primary.calls)
+
+	// The open circuit is skipped entirely
+	_, err := failover.FetchPrices(context.Background(), []string{"BTC"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), primary.calls)
+	assert.Equal(t, int32(3), secondary.calls)
+
+	health := failover.Health()
+	require.Len(t, health, 2)
+	assert.Equal(t, "primary", health[0].Name)
+	assert.Equal(t, CircuitOpen, health[0].CircuitState)
+	assert.Equal(t, 0.0, health[0].Score)
+	assert.Equal(t, uint64(2), health[0].Failures)
+	assert.Equal(t, "timeout", health[0].LastError)
+	assert.Nil(t, health[0].LastSuccess)
+	assert.Equal(t, CircuitClosed, health[1].CircuitState)
+}
+
+func TestFailoverFillsMissingSymbols(t *testing.T) {
+	primary := &stubProvider{name: "primary", price: 100, symbols: []string{"BTC"}}
+	secondary := &stubProvider{name: "secondary", price: 10, symbols: []string{"BTC", "ETH"}}
+
+	failover := NewFailoverProvider([]*MonitoredProvider{
+		newMonitored(primary, 5),
+		newMonitored(secondary, 5),
+	}, logrus.New())
+
+	prices, err := failover.FetchPrices(context.Background(), []string{"BTC", "ETH"})
+
+	require.NoError(t, err)
+	require.Len(t, prices, 2)
+	assert.Equal(t, "BTC", prices[0].Symbol)
+	assert.Equal(t, 100.0, prices[0].Price)
+	assert.Equal(t, "ETH", prices[1].Symbol)
+	assert.Equal(t, 10.0, prices[1].Price)
+}
+
+func TestFailoverAllProvidersFail(t *testing.T) {
+	failover := NewFailoverProvider([]*MonitoredProvider{
+		newMonitored(&stubProvider{name: "a", err: errors.New("boom")}, 5),
+		newMonitored(&stubProvider{name: "b", err: errors.New("bang")}, 5),
+	}, logrus.New())
+
+	prices, err := failover.FetchPrices(context.Background(), []string{"BTC"})
+
+	assert.Nil(t, prices)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a: boom")
+	assert.Contains(t, err.Error(), "b: bang")
+}
+
+func TestMonitoredProviderScore(t *testing.T) {
+	healthy := newMonitored(&stubProvider{name: "healthy", price: 100}, 5)
+	flaky := newMonitored(&stubProvider{name: "flaky", err: errors.New("boom")}, 5)
+
+	// Untried providers start with a perfect score
+	assert.Equal(t, 1.0, flaky.Score())
+
+	healthy.FetchPrices(context.Background(), []string{"BTC"})
+	flaky.FetchPrices(context.Background(), []string{"BTC"})
+
+	assert.Greater(t, healthy.Score(), flaky.Score())
+	assert.InDelta(t, healthAlpha, flaky.Health()[0].ErrorRate, 1e-9)
+}
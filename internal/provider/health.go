@@ -0,0 +1,166 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrCircuitOpen is returned when a provider is skipped because its circuit is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+const (
+	// healthAlpha is the smoothing factor for the error rate and latency averages
+	healthAlpha = 0.2
+
+	// staleAfter is how long without a successful quote before a provider is penalized
+	staleAfter = time.Minute
+)
+
+// ProviderHealth is a snapshot of a provider's health statistics
+type ProviderHealth struct {
+	Name             string       `json:"name"`
+	CircuitState     CircuitState `json:"circuit_state"`
+	Score            float64      `json:"score"`
+	Requests         uint64       `json:"requests"`
+	Failures         uint64       `json:"failures"`
+	ErrorRate        float64      `json:"error_rate"`
+	LatencyMs        float64      `json:"latency_ms"`
+	LastSuccess      *time.Time   `json:"last_success,omitempty"`
+	StalenessSeconds float64      `json:"staleness_seconds"`
+	LastError        string       `json:"last_error,omitempty"`
+}
+
+// HealthReporter is implemented by providers that track upstream health
+type HealthReporter interface {
+	Health() []ProviderHealth
+}
+
+// MonitoredProvider wraps a provider with health tracking and a circuit breaker
+type MonitoredProvider struct {
+	provider    PriceProvider
+	breaker     *CircuitBreaker
+	requests    uint64
+	failures    uint64
+	errorRate   float64
+	latency     time.Duration
+	lastSuccess time.Time
+	lastError   string
+	mutex       sync.RWMutex
+	logger      *logrus.Logger
+}
+
+// NewMonitoredProvider wraps provider with health tracking
+func NewMonitoredProvider(provider PriceProvider, breaker *CircuitBreaker, logger *logrus.Logger) *MonitoredProvider {
+	return &MonitoredProvider{
+		provider: provider,
+		breaker:  breaker,
+		logger:   logger,
+	}
+}
+
+// Name returns the underlying provider name
+func (mp *MonitoredProvider) Name() string {
+	return mp.provider.Name()
+}
+
+// SupportedSymbols returns the underlying provider symbols
+func (mp *MonitoredProvider) SupportedSymbols() []string {
+	return mp.provider.SupportedSymbols()
+}
+
+// FetchPrices fetches from the underlying provider unless its circuit is open,
+// recording the outcome and latency
+func (mp *MonitoredProvider) FetchPrices(ctx context.Context, symbols []string) ([]models.PriceUpdate, error) {
+	if !mp.breaker.Allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	start := time.Now()
+	prices, err := mp.provider.FetchPrices(ctx, symbols)
+	mp.record(time.Since(start), err)
+
+	return prices, err
+}
+
+// record updates the health statistics with the outcome of a request
+func (mp *MonitoredProvider) record(latency time.Duration, err error) {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+
+	mp.requests++
+	mp.latency = time.Duration(healthAlpha*float64(latency) + (1-healthAlpha)*float64(mp.latency))
+	if mp.requests == 1 {
+		mp.latency = latency
+	}
+
+	if err == nil {
+		mp.errorRate = (1 - healthAlpha) * mp.errorRate
+		mp.lastSuccess = time.Now()
+		mp.breaker.RecordSuccess()
+		return
+	}
+
+	mp.failures++
+	mp.errorRate = healthAlpha + (1-healthAlpha)*mp.errorRate
+	mp.lastError = err.Error()
+	if mp.breaker.RecordFailure() {
+		mp.logger.Warnf("Circuit opened for provider %s: %v", mp.provider.Name(), err)
+	}
+}
+
+// Score returns a health score between 0 (unusable) and 1 (healthy)
+func (mp *MonitoredProvider) Score() float64 {
+	mp.mutex.RLock()
+	defer mp.mutex.RUnlock()
+
+	return mp.score()
+}
+
+// score computes the health score, caller must hold the lock
+func (mp *MonitoredProvider) score() float64 {
+	if mp.breaker.State() == CircuitOpen {
+		return 0
+	}
+
+	score := (1 - mp.errorRate) / (1 + mp.latency.Seconds())
+
+	// Penalize sources that have not produced a quote recently
+	if mp.requests > 0 {
+		if staleness := time.Since(mp.lastSuccess); staleness > staleAfter {
+			score *= float64(staleAfter) / float64(staleness)
+		}
+	}
+
+	return score
+}
+
+// Health returns the provider health snapshot
+func (mp *MonitoredProvider) Health() []ProviderHealth {
+	mp.mutex.RLock()
+	defer mp.mutex.RUnlock()
+
+	health := ProviderHealth{
+		Name:         mp.provider.Name(),
+		CircuitState: mp.breaker.State(),
+		Score:        mp.score(),
+		Requests:     mp.requests,
+		Failures:     mp.failures,
+		ErrorRate:    mp.errorRate,
+		LatencyMs:    float64(mp.latency) / float64(time.Millisecond),
+		LastError:    mp.lastError,
+	}
+
+	if !mp.lastSuccess.IsZero() {
+		lastSuccess := mp.lastSuccess
+		health.LastSuccess = &lastSuccess
+		health.StalenessSeconds = time.Since(lastSuccess).Seconds()
+	}
+
+	return []ProviderHealth{health}
+}
@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PriceProvider is an upstream source of asset prices
+type PriceProvider interface {
+	// Name returns a short identifier for the source (e.g. "coindesk")
+	Name() string
+
+	// SupportedSymbols returns the asset symbols the source can quote, or nil
+	// if the source quotes whatever its upstream lists
+	SupportedSymbols() []string
+
+	// FetchPrices fetches the latest prices for the given symbols, or for every
+	// supported symbol when symbols is empty. Symbols the source cannot quote
+	// are omitted from the result
+	FetchPrices(ctx context.Context, symbols []string) ([]models.PriceUpdate, error)
+}
+
+// assetNames maps symbols to display names for sources that only return tickers
+var assetNames = map[string]string{
+	"BTC":  "Bitcoin",
+	"ETH":  "Ethereum",
+	"SOL":  "Solana",
+	"XRP":  "XRP",
+	"BNB":  "BNB",
+	"DOGE": "Dogecoin",
+	"ADA":  "Cardano",
+}
+
+// New creates a provider by name
+func New(name string, logger *logrus.Logger) (PriceProvider, error) {
+	switch name {
+	case "coindesk":
+		return NewCoinDeskProvider(logger), nil
+	case "binance":
+		return NewBinanceProvider(logger), nil
+	case "coinbase":
+		return NewCoinbaseProvider(logger), nil
+	default:
+		return nil, fmt.Errorf("unknown price provider: %s", name)
+	}
+}
+
+// NewFromEnv creates the configured provider, wrapping each source with
+// health tracking and combining several sources according to PROVIDER_STRATEGY
+func NewFromEnv(logger *logrus.Logger) (PriceProvider, error) {
+	names := utils.GetEnvStringSlice("PRICE_PROVIDERS", []string{"coindesk"})
+	failureThreshold := utils.GetEnvInt("CIRCUIT_FAILURE_THRESHOLD", 5)
+	openTimeout := time.Duration(utils.GetEnvInt("CIRCUIT_OPEN_SECONDS", 30)) * time.Second
+
+	providers := make([]*MonitoredProvider, 0, len(names))
+	for _, name := range names {
+		p, err := New(name, logger)
+		if err != nil {
+			return nil, err
+		}
+		breaker := NewCircuitBreaker(failureThreshold, openTimeout)
+		providers = append(providers, NewMonitoredProvider(p, breaker, logger))
+	}
+
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+
+	switch strategy := utils.GetEnvString("PROVIDER_STRATEGY", "aggregate"); strategy {
+	case "failover":
+		return NewFailoverProvider(providers, logger), nil
+	case "aggregate":
+		method, err := ParseConsensusMethod(utils.GetEnvString("CONSENSUS_METHOD", string(ConsensusMedian)))
+		if err != nil {
+			return nil, err
+		}
+		minSources := utils.GetEnvInt("CONSENSUS_MIN_SOURCES", 1)
+
+		sources := make([]PriceProvider, len(providers))
+		for i, p := range providers {
+			sources[i] = p
+		}
+		return NewAggregator(sources, method, minSources, logger), nil
+	default:
+		return nil, fmt.Errorf("unknown provider strategy: %s", strategy)
+	}
+}
+
+// supportedOf returns the subset of symbols the provider can quote and whether
+// the provider should be queried at all. An empty request stays empty so the
+// provider returns everything it supports
+func supportedOf(p PriceProvider, symbols []string) ([]string, bool) {
+	supported := p.SupportedSymbols()
+	if len(symbols) == 0 || len(supported) == 0 {
+		return symbols, true
+	}
+
+	set := symbolSet(supported)
+	var filtered []string
+	for _, symbol := range symbols {
+		if set[symbol] {
+			filtered = append(filtered, symbol)
+		}
+	}
+	return filtered, len(filtered) > 0
+}
+
+// symbolSet converts a list of symbols to a set
+func symbolSet(symbols []string) map[string]bool {
+	set := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		set[symbol] = true
+	}
+	return set
+}
+
+// fetchJSON performs a GET request and decodes the JSON response into out
+func fetchJSON(ctx context.Context, client *http.Client, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode API response: %w", err)
+	}
+
+	return nil
+}
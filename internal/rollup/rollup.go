@@ -0,0 +1,446 @@
+package rollup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/storage"
+	"bitcoin-price-streamer/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Tier is a downsampled resolution of price history kept for a retention period
+type Tier struct {
+	Name      string
+	Step      time.Duration
+	Retention time.Duration
+}
+
+// Options configures the rollup tiers
+type Options struct {
+	// Tiers are ordered from the finest to the coarsest step, and each step
+	// must divide the next one
+	Tiers []Tier
+	// Dir persists closed bars to one file per tier; empty keeps them in memory
+	Dir string
+}
+
+// tierState holds the closed bars of a tier per symbol, oldest first, and the
+// bar of each symbol still accumulating finer data
+type tierState struct {
+	Tier
+	closed  map[string][]models.Candle
+	pending map[string]*models.Candle
+	file    *os.File
+	// fileBars counts the bars in the tier file, including expired ones
+	fileBars int
+}
+
+// Rollups downsamples price updates into progressively coarser tiers. Raw
+// updates fold into bars of the finest tier; each closed bar folds into the
+// next tier, and compaction drops bars once their tier's retention passes
+type Rollups struct {
+	tiers  []*tierState
+	dir    string
+	mutex  sync.RWMutex
+	logger *logrus.Logger
+}
+
+// New creates rollups for the given tiers, loading bars persisted in options.Dir
+func New(options Options, logger *logrus.Logger) (*Rollups, error) {
+	if len(options.Tiers) == 0 {
+		return nil, fmt.Errorf("no rollup tiers configured")
+	}
+	for i := 1; i < len(options.Tiers); i++ {
+		if options.Tiers[i].Step%options.Tiers[i-1].Step != 0 {
+			return nil, fmt.Errorf("rollup tier %s step is not a multiple of %s",
+				options.Tiers[i].Name, options.Tiers[i-1].Name)
+		}
+	}
+
+	r := &Rollups{dir: options.Dir, logger: logger}
+	for _, tier := range options.Tiers {
+		r.tiers = append(r.tiers, &tierState{
+			Tier:    tier,
+			closed:  make(map[string][]models.Candle),
+			pending: make(map[string]*models.Candle),
+		})
+	}
+
+	if r.dir != "" {
+		if err := r.load(time.Now()); err != nil {
+			r.Close()
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// NewFromEnv creates 1-minute and hourly rollups with retention taken from
+// ROLLUP_1M_RETENTION_DAYS and ROLLUP_1H_RETENTION_DAYS, persisted in
+// STORAGE_DIR when the disk storage backend is selected
+func NewFromEnv(logger *logrus.Logger) (*Rollups, error) {
+	options := Options{
+		Tiers: []Tier{
+			{Name: "1m", Step: time.Minute, Retention: time.Duration(utils.GetEnvInt("ROLLUP_1M_RETENTION_DAYS", 28)) * 24 * time.Hour},
+			{Name: "1h", Step: time.Hour, Retention: time.Duration(utils.GetEnvInt("ROLLUP_1H_RETENTION_DAYS", 1825)) * 24 * time.Hour},
+		},
+	}
+	if utils.GetEnvString("STORAGE_BACKEND", "memory") == "disk" {
+		options.Dir = utils.GetEnvString("STORAGE_DIR", "./data")
+	}
+	return New(options, logger)
+}
+
+// Tiers returns the configured tiers, finest first
+func (r *Rollups) Tiers() []Tier {
+	tiers := make([]Tier, len(r.tiers))
+	for i, t := range r.tiers {
+		tiers[i] = t.Tier
+	}
+	return tiers
+}
+
+// tier returns the state of the named tier
+func (r *Rollups) tier(name string) (int, bool) {
+	for i, t := range r.tiers {
+		if t.Name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Add folds a price update into the finest tier
+func (r *Rollups) Add(update models.PriceUpdate) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.fold(0, models.Candle{
+		Symbol:    update.Symbol,
+		Start:     update.Timestamp.UTC(),
+		Open:      update.Price,
+		High:      update.Price,
+		Low:       update.Price,
+		Close:     update.Price,
+		Volume24h: update.Volume24h,
+		Ticks:     1,
+	})
+}
+
+// fold merges a bar covering finer data into the pending bar of tier i,
+// closing the pending bar first if the new data starts a later step.
+// Callers must hold the lock
+func (r *Rollups) fold(i int, bar models.Candle) {
+	t := r.tiers[i]
+	start := bar.Start.Truncate(t.Step)
+
+	pending := t.pending[bar.Symbol]
+	if pending != nil && start.After(pending.Start) {
+		r.closeBar(i, bar.Symbol)
+		pending = nil
+	}
+
+	if pending == nil {
+		// Data already covered by a closed bar, e.g. replayed after a restart
+		if closed := t.closed[bar.Symbol]; len(closed) > 0 && start.Before(closed[len(closed)-1].End) {
+			return
+		}
+
+		t.pending[bar.Symbol] = &models.Candle{
+			Symbol:   bar.Symbol,
+			Interval: t.Name,
+			Start:    start,
+			End:      start.Add(t.Step),
+			Open:     bar.Open,
+			High:     bar.High,
+			Low:      bar.Low,
+			Close:    bar.Close,
+		}
+		pending = t.pending[bar.Symbol]
+	} else if start.Before(pending.Start) {
+		return
+	}
+
+	pending.High = max(pending.High, bar.High)
+	pending.Low = min(pending.Low, bar.Low)
+	pending.Close = bar.Close
+	pending.Ticks += bar.Ticks
+	if bar.Volume24h > 0 {
+		pending.Volume24h = bar.Volume24h
+	}
+}
+
+// closeBar closes the pending bar of symbol in tier i and folds it into the
+// next tier. Callers must hold the lock
+func (r *Rollups) closeBar(i int, symbol string) {
+	t := r.tiers[i]
+	bar := *t.pending[symbol]
+	bar.Closed = true
+	delete(t.pending, symbol)
+
+	t.closed[symbol] = append(t.closed[symbol], bar)
+	if err := r.persist(t, bar); err != nil {
+		r.logger.Errorf("Failed to persist %s rollup: %v", t.Name, err)
+	}
+
+	if i+1 < len(r.tiers) {
+		r.fold(i+1, bar)
+	}
+}
+
+// Compact closes pending bars whose step ended by now and drops closed bars
+// older than their tier's retention, rewriting tier files that are mostly
+// expired bars
+func (r *Rollups) Compact(now time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i, t := range r.tiers {
+		for symbol, pending := range t.pending {
+			if !now.Before(pending.End) {
+				r.closeBar(i, symbol)
+			}
+		}
+	}
+
+	for _, t := range r.tiers {
+		cutoff := now.Add(-t.Retention)
+		live, dropped := 0, 0
+		for symbol, bars := range t.closed {
+			expired := sort.Search(len(bars), func(j int) bool { return bars[j].End.After(cutoff) })
+			if expired > 0 {
+				t.closed[symbol] = append([]models.Candle(nil), bars[expired:]...)
+				dropped += expired
+			}
+			live += len(t.closed[symbol])
+			if len(t.closed[symbol]) == 0 {
+				delete(t.closed, symbol)
+			}
+		}
+		if dropped > 0 {
+			r.logger.Infof("Compacted %d expired %s rollups", dropped, t.Name)
+		}
+
+		if t.file != nil && t.fileBars > 2*live {
+			if err := r.rewrite(t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Query returns the closed and pending bars of symbol in the named tier that
+// start within [from, to], oldest first. Zero bounds are unset
+func (r *Rollups) Query(tierName, symbol string, from, to time.Time) ([]models.Candle, error) {
+	i, ok := r.tier(tierName)
+	if !ok {
+		return nil, fmt.Errorf("unknown rollup tier: %s", tierName)
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	t := r.tiers[i]
+	bars := t.closed[symbol]
+	if pending := t.pending[symbol]; pending != nil {
+		bars = append(bars[:len(bars):len(bars)], *pending)
+	}
+
+	lo, hi := 0, len(bars)
+	if !from.IsZero() {
+		lo = sort.Search(len(bars), func(j int) bool { return !bars[j].Start.Before(from) })
+	}
+	if !to.IsZero() {
+		hi = sort.Search(len(bars), func(j int) bool { return bars[j].Start.After(to) })
+	}
+	if lo >= hi {
+		return nil, nil
+	}
+	return append([]models.Candle(nil), bars[lo:hi]...), nil
+}
+
+// Backfill folds the updates already held in store, e.g. after history was
+// restored from disk. Updates covered by persisted bars are skipped
+func (r *Rollups) Backfill(store storage.Store) {
+	for _, symbol := range store.Symbols() {
+		store.Iterate(symbol, func(update models.PriceUpdate) bool {
+			r.Add(update)
+			return true
+		})
+	}
+}
+
+// Run compacts the tiers every minute until ctx is cancelled
+func (r *Rollups) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := r.Compact(now); err != nil {
+				r.logger.Errorf("Failed to compact rollups: %v", err)
+			}
+		}
+	}
+}
+
+// tierPath returns the file persisting the closed bars of a tier
+func (r *Rollups) tierPath(t *tierState) string {
+	return filepath.Join(r.dir, "rollup-"+t.Name+".jsonl")
+}
+
+// persist appends a closed bar to its tier file. Callers must hold the lock
+func (r *Rollups) persist(t *tierState, bar models.Candle) error {
+	if t.file == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(bar)
+	if err != nil {
+		return fmt.Errorf("failed to encode rollup: %w", err)
+	}
+	if _, err := t.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write rollup: %w", err)
+	}
+	t.fileBars++
+	return nil
+}
+
+// load reads persisted bars still within retention and rebuilds the pending
+// bars of coarser tiers from closed bars of finer ones
+func (r *Rollups) load(now time.Time) error {
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create rollup directory: %w", err)
+	}
+
+	for _, t := range r.tiers {
+		path := r.tierPath(t)
+		if err := readBars(path, func(bar models.Candle) {
+			t.fileBars++
+			if bar.End.After(now.Add(-t.Retention)) {
+				t.closed[bar.Symbol] = append(t.closed[bar.Symbol], bar)
+			}
+		}); err != nil {
+			return err
+		}
+
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open rollup file: %w", err)
+		}
+		t.file = file
+	}
+
+	for i := 1; i < len(r.tiers); i++ {
+		for symbol, bars := range r.tiers[i-1].closed {
+			for _, bar := range bars {
+				r.fold(i, bar)
+			}
+			r.logger.Debugf("Restored %d %s rollups for %s", len(bars), r.tiers[i-1].Name, symbol)
+		}
+	}
+	return nil
+}
+
+// readBars decodes a tier file, skipping a torn last line
+func readBars(path string, fn func(models.Candle)) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to open rollup file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var bar models.Candle
+		if err := json.Unmarshal(scanner.Bytes(), &bar); err != nil {
+			continue
+		}
+		fn(bar)
+	}
+	return scanner.Err()
+}
+
+// rewrite replaces a tier file with only its live bars. Callers must hold the lock
+func (r *Rollups) rewrite(t *tierState) error {
+	path := r.tierPath(t)
+	tmp, err := os.CreateTemp(r.dir, "rollup-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create rollup file: %w", err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	count := 0
+	for _, bars := range t.closed {
+		for _, bar := range bars {
+			data, err := json.Marshal(bar)
+			if err != nil {
+				tmp.Close()
+				os.Remove(tmp.Name())
+				return fmt.Errorf("failed to encode rollup: %w", err)
+			}
+			writer.Write(append(data, '\n'))
+			count++
+		}
+	}
+	if err := writer.Flush(); err == nil {
+		err = tmp.Sync()
+	}
+	tmp.Close()
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write rollup file: %w", err)
+	}
+
+	t.file.Close()
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace rollup file: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.file = nil
+		return fmt.Errorf("failed to open rollup file: %w", err)
+	}
+	t.file = file
+	t.fileBars = count
+	r.logger.Infof("Rewrote %s with %d rollups", path, count)
+	return nil
+}
+
+// Close closes the tier files
+func (r *Rollups) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var firstErr error
+	for _, t := range r.tiers {
+		if t.file == nil {
+			continue
+		}
+		if err := t.file.Sync(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err := t.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		t.file = nil
+	}
+	return firstErr
+}
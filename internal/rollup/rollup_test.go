@@ -0,0 +1,136 @@
+package rollup
+
+import (
+	"testing"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOptions(dir string) Options {
+	return Options{
+		Tiers: []Tier{
+			{Name: "1m", Step: time.Minute, Retention: 2 * time.Hour},
+			{Name: "1h", Step: time.Hour, Retention: 48 * time.Hour},
+		},
+		Dir: dir,
+	}
+}
+
+func TestNewRejectsMisalignedTiers(t *testing.T) {
+	_, err := New(Options{Tiers: []Tier{
+		{Name: "1m", Step: time.Minute},
+		{Name: "90s", Step: 90 * time.Second},
+	}}, logrus.New())
+	assert.Error(t, err)
+
+	_, err = New(Options{}, logrus.New())
+	assert.Error(t, err)
+}
+
+func TestRollupsCascadeThroughTiers(t *testing.T) {
+	rollups, err := New(testOptions(""), logrus.New())
+	require.NoError(t, err)
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	// Two ticks per minute for 61 minutes
+	for i := 0; i < 122; i++ {
+		rollups.Add(models.PriceUpdate{Timestamp: base.Add(time.Duration(i*30) * time.Second), Price: float64(i), Symbol: "BTC"})
+	}
+
+	minutes, err := rollups.Query("1m", "BTC", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, minutes, 61)
+	assert.True(t, minutes[0].Closed)
+	assert.Equal(t, 0.0, minutes[0].Open)
+	assert.Equal(t, 1.0, minutes[0].Close)
+	assert.Equal(t, 2, minutes[0].Ticks)
+	assert.False(t, minutes[60].Closed)
+
+	// The hour holds every closed minute and stays open until a later one closes
+	hours, err := rollups.Query("1h", "BTC", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, hours, 1)
+	assert.False(t, hours[0].Closed)
+	assert.Equal(t, 0.0, hours[0].Low)
+	assert.Equal(t, 119.0, hours[0].High)
+	assert.Equal(t, 120, hours[0].Ticks)
+
+	// Closing the 13:00 minute closes the hour
+	rollups.Add(models.PriceUpdate{Timestamp: base.Add(61 * time.Minute), Price: 200, Symbol: "BTC"})
+	hours, _ = rollups.Query("1h", "BTC", time.Time{}, time.Time{})
+	require.Len(t, hours, 2)
+	assert.True(t, hours[0].Closed)
+	assert.Equal(t, 2, hours[1].Ticks)
+
+	// Range bounds apply to bar start times and are inclusive
+	bars, err := rollups.Query("1m", "BTC", base.Add(10*time.Minute), base.Add(12*time.Minute))
+	require.NoError(t, err)
+	assert.Len(t, bars, 3)
+
+	_, err = rollups.Query("1w", "BTC", time.Time{}, time.Time{})
+	assert.Error(t, err)
+}
+
+func TestRollupsCompaction(t *testing.T) {
+	rollups, err := New(testOptions(""), logrus.New())
+	require.NoError(t, err)
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		rollups.Add(models.PriceUpdate{Timestamp: base.Add(time.Duration(i) * time.Minute), Price: float64(i), Symbol: "BTC"})
+	}
+
+	// Compaction closes the pending bar once its minute has passed
+	require.NoError(t, rollups.Compact(base.Add(3*time.Minute)))
+	minutes, _ := rollups.Query("1m", "BTC", time.Time{}, time.Time{})
+	require.Len(t, minutes, 3)
+	assert.True(t, minutes[2].Closed)
+
+	// Minutes expire after two hours, while the hour is retained
+	require.NoError(t, rollups.Compact(base.Add(2*time.Hour+150*time.Second)))
+	minutes, _ = rollups.Query("1m", "BTC", time.Time{}, time.Time{})
+	require.Len(t, minutes, 1)
+	assert.Equal(t, 2.0, minutes[0].Open)
+
+	hours, _ := rollups.Query("1h", "BTC", time.Time{}, time.Time{})
+	require.Len(t, hours, 1)
+	assert.True(t, hours[0].Closed)
+	assert.Equal(t, 3, hours[0].Ticks)
+}
+
+func TestRollupsPersistence(t *testing.T) {
+	dir := t.TempDir()
+	logger := logrus.New()
+
+	rollups, err := New(testOptions(dir), logger)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Hour)
+	for i := 0; i < 5; i++ {
+		rollups.Add(models.PriceUpdate{Timestamp: now.Add(time.Duration(i) * time.Minute), Price: float64(i), Symbol: "BTC"})
+	}
+	require.NoError(t, rollups.Close())
+
+	restarted, err := New(testOptions(dir), logger)
+	require.NoError(t, err)
+	defer restarted.Close()
+
+	// Closed minutes are restored and the hour is rebuilt from them
+	minutes, _ := restarted.Query("1m", "BTC", time.Time{}, time.Time{})
+	require.Len(t, minutes, 4)
+	hours, _ := restarted.Query("1h", "BTC", time.Time{}, time.Time{})
+	require.Len(t, hours, 1)
+	assert.Equal(t, 4, hours[0].Ticks)
+
+	// Replaying raw updates already rolled up does not count them twice
+	restarted.Add(models.PriceUpdate{Timestamp: now.Add(time.Minute), Price: 1, Symbol: "BTC"})
+	restarted.Add(models.PriceUpdate{Timestamp: now.Add(4 * time.Minute), Price: 4, Symbol: "BTC"})
+	minutes, _ = restarted.Query("1m", "BTC", time.Time{}, time.Time{})
+	require.Len(t, minutes, 5)
+	assert.Equal(t, 1, minutes[4].Ticks)
+}
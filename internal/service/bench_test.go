@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/storage"
+
+	"github.com/sirupsen/logrus"
+)
+
+// BenchmarkBroadcastPrice fans one update out to every subscriber, half of
+// them subscribed to its symbol. Buffers are kept full under drop_oldest, the
+// slowest steady state
+func BenchmarkBroadcastPrice(b *testing.B) {
+	for _, n := range []int{10000, 50000} {
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			logger := logrus.New()
+			logger.SetLevel(logrus.ErrorLevel)
+			storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+			service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
+			service.bufferSize = 1
+			service.policy = PolicyDropOldest
+
+			for i := range n {
+				if i%2 == 0 {
+					service.Subscribe("BTC")
+				} else {
+					service.Subscribe("ETH")
+				}
+			}
+			price := models.PriceUpdate{Symbol: "BTC", Price: 50000.0}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				price.Seq = uint64(i + 1)
+				service.broadcastPrice(price)
+			}
+		})
+	}
+}
+
+// BenchmarkSubscribeDuringBroadcast subscribes and unsubscribes clients while
+// updates are broadcast to 10k others
+func BenchmarkSubscribeDuringBroadcast(b *testing.B) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
+	service.bufferSize = 1
+	service.policy = PolicyDropOldest
+
+	for range 10000 {
+		service.Subscribe("BTC")
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for seq := uint64(1); ; seq++ {
+			select {
+			case <-stop:
+				return
+			default:
+				service.broadcastPrice(models.PriceUpdate{Seq: seq, Symbol: "BTC"})
+			}
+		}
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			service.Unsubscribe(service.Subscribe("BTC"))
+		}
+	})
+	b.StopTimer()
+	close(stop)
+	<-done
+}
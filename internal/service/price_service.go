@@ -2,56 +2,111 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"bitcoin-price-streamer/internal/broker"
+	"bitcoin-price-streamer/internal/bus"
+	"bitcoin-price-streamer/internal/metrics"
 	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/provider"
 	"bitcoin-price-streamer/internal/storage"
 	"bitcoin-price-streamer/internal/utils"
 
 	"github.com/sirupsen/logrus"
 )
 
-// PriceService manages Bitcoin price polling and client connections
+// PriceService manages price polling and client connections
 type PriceService struct {
-	storage    *storage.PriceStorage
+	storage    storage.Store
 	logger     *logrus.Logger
-	clients    map[chan models.PriceUpdate]bool
-	clientsMux sync.RWMutex
-	httpClient *http.Client
-	apiURL     string
+	clients    *broker.Broker[*Subscription]
+	nextID     atomic.Uint64
+	provider   provider.PriceProvider
+	symbols    []string
 	bufferSize int
+	policy     SlowConsumerPolicy
+	observers  []func(models.PriceUpdate)
+	observeMux sync.RWMutex
+	metrics    serviceMetrics
+	bus        bus.Bus
+	instance   string
+}
+
+// serviceMetrics counts subscribers and the updates they miss
+type serviceMetrics struct {
+	subscribers  *metrics.Gauge
+	dropped      *metrics.CounterVec
+	disconnected *metrics.CounterVec
+}
+
+// newServiceMetrics registers the price service metrics with registry
+func newServiceMetrics(registry *metrics.Registry) serviceMetrics {
+	return serviceMetrics{
+		subscribers:  registry.Gauge("price_subscribers", "Clients subscribed to price updates"),
+		dropped:      registry.CounterVec("price_updates_dropped_total", "Price updates discarded for slow clients, by policy", "policy"),
+		disconnected: registry.CounterVec("price_subscribers_disconnected_total", "Clients disconnected by the server, by reason", "reason"),
+	}
 }
 
 // NewPriceService creates a new price service
-func NewPriceService(storage *storage.PriceStorage, logger *logrus.Logger) *PriceService {
-	apiURL := utils.GetEnvString("COINDESK_API_URL", "https://data-api.coindesk.com/asset/v1/top/list")
+func NewPriceService(storage storage.Store, provider provider.PriceProvider, logger *logrus.Logger) *PriceService {
 	bufferSize := utils.GetEnvInt("CLIENT_BUFFER_SIZE", 50)
+	shards := utils.GetEnvInt("BROKER_SHARDS", broker.DefaultShards)
+
+	policy, err := ParseSlowConsumerPolicy(utils.GetEnvString("SLOW_CONSUMER_POLICY", ""))
+	if err != nil {
+		logger.Warnf("Invalid SLOW_CONSUMER_POLICY, disconnecting slow clients: %v", err)
+		policy = PolicyDisconnect
+	}
+
+	// An empty allowlist tracks every asset the provider lists
+	var symbols []string
+	for _, symbol := range utils.GetEnvStringSlice("TRACKED_SYMBOLS", nil) {
+		symbols = append(symbols, strings.ToUpper(symbol))
+	}
 
 	return &PriceService{
-		storage: storage,
-		logger:  logger,
-		clients: make(map[chan models.PriceUpdate]bool),
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		apiURL:     apiURL,
+		storage:    storage,
+		logger:     logger,
+		clients:    broker.New[*Subscription](shards),
+		provider:   provider,
+		symbols:    symbols,
 		bufferSize: bufferSize,
+		policy:     policy,
+		metrics:    newServiceMetrics(metrics.NewRegistry()),
+		bus:        bus.NewMemory(),
+		instance:   utils.InstanceID(),
 	}
 }
 
-// StartPolling starts polling the CoinDesk API for Bitcoin price updates
+// SetMetrics makes the service record its metrics in registry
+func (ps *PriceService) SetMetrics(registry *metrics.Registry) {
+	ps.metrics = newServiceMetrics(registry)
+}
+
+// SetBus makes the service publish the updates it polls on b, and serve the
+// updates other instances publish there once Follow is running
+func (ps *PriceService) SetBus(b bus.Bus) {
+	ps.bus = b
+}
+
+// StartPolling starts polling the price provider for price updates
 func (ps *PriceService) StartPolling(ctx context.Context) {
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
-	ps.logger.Info("Starting Bitcoin price polling...")
+	ps.logger.Info("Starting price polling...")
+
+	// A new leader may have missed the previous leader's last updates
+	ps.resync(ctx)
 
 	// Do initial fetch immediately
-	ps.fetchAndBroadcastPrice()
+	ps.fetchAndBroadcastPrices(ctx)
 
 	for {
 		select {
@@ -59,125 +114,262 @@ func (ps *PriceService) StartPolling(ctx context.Context) {
 			ps.logger.Info("Stopping price polling...")
 			return
 		case <-ticker.C:
-			ps.fetchAndBroadcastPrice()
+			ps.fetchAndBroadcastPrices(ctx)
 		}
 	}
 }
 
-// fetchAndBroadcastPrice fetches the latest Bitcoin price and broadcasts to all clients
-func (ps *PriceService) fetchAndBroadcastPrice() {
-	price, err := ps.fetchBitcoinPrice()
+// fetchAndBroadcastPrices fetches the latest prices of the tracked symbols and broadcasts them to all clients
+func (ps *PriceService) fetchAndBroadcastPrices(ctx context.Context) {
+	prices, err := ps.provider.FetchPrices(ctx, ps.symbols)
 	if err != nil {
-		ps.logger.Errorf("Failed to fetch Bitcoin price: %v", err)
+		ps.logger.Errorf("Failed to fetch prices from %s: %v", ps.provider.Name(), err)
 		return
 	}
 
-	// Store the price update
-	ps.storage.Add(*price)
+	ps.logger.Infof("Fetched %d prices from %s", len(prices), ps.provider.Name())
 
-	// Broadcast to all connected clients
-	ps.broadcastPrice(*price)
-}
+	for _, price := range prices {
+		ps.logger.Debugf("Fetched %s price: $%.2f USD at %s",
+			price.Symbol, price.Price, price.Timestamp.Format(time.RFC3339))
 
-// fetchBitcoinPrice fetches the latest Bitcoin price from the CoinDesk API
-func (ps *PriceService) fetchBitcoinPrice() (*models.PriceUpdate, error) {
-	resp, err := ps.httpClient.Get(ps.apiURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make HTTP request: %w", err)
-	}
-	defer resp.Body.Close()
+		// Store the price update, assigning its sequence number
+		stored := ps.storage.Add(price)
+
+		// Feed derived data such as candles before clients see the update
+		ps.notifyObservers(stored)
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status code: %d", resp.StatusCode)
+		// Broadcast to all connected clients
+		ps.broadcastPrice(stored)
+
+		// Let the other instances serve it too
+		if err := ps.bus.Publish(ctx, bus.Message{Origin: ps.instance, Update: stored}); err != nil {
+			ps.logger.Errorf("Failed to publish price update %d to the %s bus: %v", stored.Seq, ps.bus.Name(), err)
+		}
 	}
+}
 
-	var apiResponse models.CoinDeskResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode API response: %w", err)
+// Follow serves the price updates other instances publish on the bus until
+// ctx is done, storing them with their publisher's sequence numbers when the
+// storage supports it
+func (ps *PriceService) Follow(ctx context.Context) error {
+	messages, err := ps.bus.Subscribe(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to the %s bus: %w", ps.bus.Name(), err)
 	}
 
-	// Find Bitcoin in the list
-	var bitcoinData *models.AssetData
-	for _, asset := range apiResponse.Data.List {
-		if asset.Symbol == "BTC" {
-			bitcoinData = &asset
-			break
+	ps.logger.Infof("Following price updates on the %s bus as %s", ps.bus.Name(), ps.instance)
+	for message := range messages {
+		if message.Origin != ps.instance {
+			ps.ingest(message.Update)
 		}
 	}
+	return nil
+}
 
-	if bitcoinData == nil {
-		return nil, fmt.Errorf("bitcoin data not found in API response")
+// resync stores the last update published on the bus unless this instance
+// already has it, so that after a failover the new leader continues the
+// sequence instead of reissuing numbers other instances already hold
+func (ps *PriceService) resync(ctx context.Context) {
+	message, ok, err := ps.bus.Last(ctx)
+	if err != nil {
+		ps.logger.Warnf("Failed to read the last update on the %s bus, sequence numbers may be reissued: %v", ps.bus.Name(), err)
+		return
+	}
+	if !ok || message.Update.Seq <= ps.storage.LastSeq() {
+		return
 	}
 
-	// Convert timestamp from Unix timestamp to time.Time
-	timestamp := time.Unix(bitcoinData.PriceUSDLastUpdateTS, 0)
+	ps.logger.Warnf("Resuming the sequence after update %d published by %s, which this instance missed",
+		message.Update.Seq, message.Origin)
+	ps.ingest(message.Update)
+}
 
-	// Use current time if the API timestamp is too old (more than 1 hour)
-	if time.Since(timestamp) > time.Hour {
-		timestamp = time.Now()
+// ingest stores a price update published by another instance and serves it
+// like a polled one
+func (ps *PriceService) ingest(price models.PriceUpdate) {
+	stored := price
+	if appender, ok := ps.storage.(storage.Appender); ok {
+		if !appender.Append(price) {
+			// Only a publisher that reissued sequence numbers sends these
+			ps.logger.Warnf("Dropping %s price update %d from the bus, not newer than sequence %d",
+				price.Symbol, price.Seq, ps.storage.LastSeq())
+			return
+		}
+	} else {
+		stored = ps.storage.Add(price)
 	}
 
-	priceUpdate := &models.PriceUpdate{
-		Timestamp: timestamp,
-		Price:     bitcoinData.PriceUSD,
-		Symbol:    bitcoinData.Symbol,
-		Name:      bitcoinData.Name,
-	}
+	ps.notifyObservers(stored)
+	ps.broadcastPrice(stored)
+}
 
-	ps.logger.Infof("Fetched Bitcoin price: $%.2f USD at %s", priceUpdate.Price, priceUpdate.Timestamp.Format(time.RFC3339))
+// Observe registers fn to be called synchronously with every stored price
+// update, in sequence order, before the update is broadcast
+func (ps *PriceService) Observe(fn func(models.PriceUpdate)) {
+	ps.observeMux.Lock()
+	defer ps.observeMux.Unlock()
 
-	return priceUpdate, nil
+	ps.observers = append(ps.observers, fn)
 }
 
-// broadcastPrice sends a price update to all connected clients
+// notifyObservers passes a stored price update to every observer
+func (ps *PriceService) notifyObservers(price models.PriceUpdate) {
+	ps.observeMux.RLock()
+	defer ps.observeMux.RUnlock()
+
+	for _, fn := range ps.observers {
+		fn(price)
+	}
+}
+
+// broadcastPrice sends a price update to all clients subscribed to its symbol.
+// Clients whose buffer is full are handled by their slow-consumer policy. The
+// broker is read without locks, so clients may subscribe and unsubscribe
+// during the broadcast
 func (ps *PriceService) broadcastPrice(price models.PriceUpdate) {
-	ps.clientsMux.RLock()
-	defer ps.clientsMux.RUnlock()
+	now := time.Now()
 
-	for clientChan := range ps.clients {
-		select {
-		case clientChan <- price:
-			// Successfully sent
-		default:
-			// Channel is full or blocked, remove the client
-			ps.logger.Warn("Removing blocked client")
-			delete(ps.clients, clientChan)
-			close(clientChan)
+	ps.clients.Each(func(sub *Subscription) {
+		if !sub.Matches(price.Symbol) {
+			return
+		}
+
+		dropped, ok := sub.publish(price, now)
+		ps.recordDropped(sub, dropped)
+		if !ok {
+			ps.disconnectSlow(sub)
 		}
+	})
+}
+
+// recordDropped counts updates discarded for a slow client
+func (ps *PriceService) recordDropped(sub *Subscription, dropped int) {
+	if dropped > 0 {
+		ps.metrics.dropped.With(string(sub.Policy())).Add(int64(dropped))
+	}
+}
+
+// disconnectSlow disconnects a client that fell behind
+func (ps *PriceService) disconnectSlow(sub *Subscription) {
+	ps.disconnect(sub, ReasonSlowConsumer,
+		fmt.Sprintf("client buffer of %d updates is full", cap(sub.C)))
+}
+
+// SetThrottle makes the server send sub at most one update per interval for
+// each symbol, always the newest, holding the others back outside its
+// buffer. A zero interval sends every update again
+func (ps *PriceService) SetThrottle(sub *Subscription, interval time.Duration) error {
+	if interval < 0 || interval > MaxThrottle {
+		return fmt.Errorf("throttle must be between 0 and %s", MaxThrottle)
+	}
+
+	dropped, ok := sub.setThrottle(interval)
+	ps.recordDropped(sub, dropped)
+	if !ok {
+		ps.disconnectSlow(sub)
+	}
+	return nil
+}
+
+// releaseThrottled delivers a client's held-back updates once they are due
+func (ps *PriceService) releaseThrottled(sub *Subscription) {
+	dropped, ok := sub.release(time.Now())
+	ps.recordDropped(sub, dropped)
+	if !ok {
+		ps.disconnectSlow(sub)
 	}
 }
 
-// Subscribe adds a new client to receive price updates
-func (ps *PriceService) Subscribe() chan models.PriceUpdate {
-	clientChan := make(chan models.PriceUpdate, ps.bufferSize)
+// disconnect removes a client and closes its channel with a notice of why
+func (ps *PriceService) disconnect(sub *Subscription, reason, message string) {
+	if !ps.clients.Remove(sub) {
+		return
+	}
+	sub.close(reason, message)
+
+	ps.metrics.subscribers.Dec()
+	ps.metrics.disconnected.With(reason).Inc()
+	ps.logger.Warnf("Disconnected client %s (%s) after %d dropped updates. Total clients: %d",
+		sub.ID, reason, sub.Dropped(), ps.clients.Len())
+}
 
-	ps.clientsMux.Lock()
-	ps.clients[clientChan] = true
-	ps.clientsMux.Unlock()
+// Subscribe adds a new client to receive price updates for the given symbols,
+// or for every symbol if none are given
+func (ps *PriceService) Subscribe(symbols ...string) *Subscription {
+	sub := newSubscription(ps.bufferSize, symbols)
+	sub.ID = fmt.Sprintf("client-%d", ps.nextID.Add(1))
+	sub.policy = ps.policy
+	sub.throttle.onDue = func() { ps.releaseThrottled(sub) }
 
-	ps.logger.Infof("New client subscribed with buffer size %d. Total clients: %d",
-		ps.bufferSize, len(ps.clients))
+	ps.clients.Add(sub)
+	ps.metrics.subscribers.Inc()
+	ps.logger.Infof("New client %s subscribed to %v with buffer size %d. Total clients: %d",
+		sub.ID, sub.Symbols(), ps.bufferSize, ps.clients.Len())
 
-	return clientChan
+	return sub
 }
 
 // Unsubscribe removes a client from receiving price updates
-func (ps *PriceService) Unsubscribe(clientChan chan models.PriceUpdate) {
-	ps.clientsMux.Lock()
-	defer ps.clientsMux.Unlock()
+func (ps *PriceService) Unsubscribe(sub *Subscription) {
+	if ps.clients.Remove(sub) {
+		sub.close("", "")
+		ps.metrics.subscribers.Dec()
+		ps.logger.Infof("Client %s unsubscribed. Total clients: %d", sub.ID, ps.clients.Len())
+	}
+}
+
+// ClientStats describes a subscribed client
+type ClientStats struct {
+	ID          string             `json:"id"`
+	Symbols     []string           `json:"symbols"`
+	Policy      SlowConsumerPolicy `json:"policy"`
+	Buffered    int                `json:"buffered"`
+	BufferSize  int                `json:"buffer_size"`
+	Dropped     uint64             `json:"dropped"`
+	Throttle    string             `json:"throttle,omitempty"`
+	Conflated   uint64             `json:"conflated,omitempty"`
+	ConnectedAt time.Time          `json:"connected_at"`
+}
 
-	if _, exists := ps.clients[clientChan]; exists {
-		delete(ps.clients, clientChan)
-		close(clientChan)
-		ps.logger.Infof("Client unsubscribed. Total clients: %d", len(ps.clients))
+// Clients returns the subscribed clients, oldest first
+func (ps *PriceService) Clients() []ClientStats {
+	subs := ps.clients.Snapshot()
+	clients := make([]ClientStats, 0, len(subs))
+	for _, sub := range subs {
+		stats := ClientStats{
+			ID:          sub.ID,
+			Symbols:     sub.Symbols(),
+			Policy:      sub.Policy(),
+			Buffered:    len(sub.C),
+			BufferSize:  cap(sub.C),
+			Dropped:     sub.Dropped(),
+			Conflated:   sub.Conflated(),
+			ConnectedAt: sub.ConnectedAt,
+		}
+		if throttle := sub.Throttle(); throttle > 0 {
+			stats.Throttle = throttle.String()
+		}
+		clients = append(clients, stats)
 	}
+	sort.Slice(clients, func(i, j int) bool {
+		if !clients[i].ConnectedAt.Equal(clients[j].ConnectedAt) {
+			return clients[i].ConnectedAt.Before(clients[j].ConnectedAt)
+		}
+		return clients[i].ID < clients[j].ID
+	})
+	return clients
 }
 
-// GetStorage returns the price storage for accessing missed updates
-func (ps *PriceService) GetStorage() *storage.PriceStorage {
+// GetStorage returns the store the service writes price updates to
+func (ps *PriceService) GetStorage() storage.Store {
 	return ps.storage
 }
 
-func (ps *PriceService) SetAPIURL(url string) {
-	ps.apiURL = url
+// ProviderHealth returns the health of the upstream providers, if tracked
+func (ps *PriceService) ProviderHealth() []provider.ProviderHealth {
+	if reporter, ok := ps.provider.(provider.HealthReporter); ok {
+		return reporter.Health()
+	}
+	return nil
 }
@@ -2,57 +2,96 @@ package service
 
 import (
 	"context"
-	"encoding/json"
-	"net/http"
-	"net/http/httptest"
+	"errors"
+	"os"
 	"testing"
 	"time"
 
+	"bitcoin-price-streamer/internal/bus"
 	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/provider"
 	"bitcoin-price-streamer/internal/storage"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// mockProvider is a PriceProvider returning a fixed quote or error
+type mockProvider struct {
+	price float64
+	err   error
+}
+
+func (m *mockProvider) Name() string {
+	return "mock"
+}
+
+func (m *mockProvider) SupportedSymbols() []string {
+	return []string{"BTC"}
+}
+
+func (m *mockProvider) FetchPrices(ctx context.Context, symbols []string) ([]models.PriceUpdate, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []models.PriceUpdate{
+		{Timestamp: time.Now(), Price: m.price, Symbol: "BTC", Name: "Bitcoin"},
+		{Timestamp: time.Now(), Price: m.price / 10, Symbol: "ETH", Name: "Ethereum"},
+	}, nil
+}
+
 func TestNewPriceService(t *testing.T) {
 	logger := logrus.New()
-	storage := storage.NewPriceStorage(context.Background(), 100, logger)
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+
+	provider := &mockProvider{price: 50000.0}
 
-	service := NewPriceService(storage, logger)
+	service := NewPriceService(storage, provider, logger)
 
 	assert.NotNil(t, service)
 	assert.Equal(t, storage, service.storage)
 	assert.Equal(t, logger, service.logger)
 	assert.NotNil(t, service.clients)
-	assert.NotNil(t, service.httpClient)
-	assert.Equal(t, "https://data-api.coindesk.com/asset/v1/top/list", service.apiURL)
+	assert.Equal(t, provider, service.provider)
+	assert.Nil(t, service.symbols)          // Track every asset by default
 	assert.Equal(t, 50, service.bufferSize) // Default value
 }
 
+func TestNewPriceServiceTrackedSymbols(t *testing.T) {
+	os.Setenv("TRACKED_SYMBOLS", "btc, eth")
+	defer os.Unsetenv("TRACKED_SYMBOLS")
+
+	logger := logrus.New()
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
+
+	assert.Equal(t, []string{"BTC", "ETH"}, service.symbols)
+}
+
 func TestSubscribeAndUnsubscribe(t *testing.T) {
 	logger := logrus.New()
-	storage := storage.NewPriceStorage(context.Background(), 100, logger)
-	service := NewPriceService(storage, logger)
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
 
 	// Subscribe
-	clientChan := service.Subscribe()
-	assert.NotNil(t, clientChan)
-	assert.Len(t, service.clients, 1)
+	sub := service.Subscribe()
+	assert.NotNil(t, sub)
+	assert.Equal(t, 1, service.clients.Len())
 
 	// Unsubscribe
-	service.Unsubscribe(clientChan)
-	assert.Len(t, service.clients, 0)
+	service.Unsubscribe(sub)
+	assert.Equal(t, 0, service.clients.Len())
 
 	// Verify channel is closed
-	_, ok := <-clientChan
+	_, ok := <-sub.C
 	assert.False(t, ok, "Channel should be closed after unsubscribe")
 }
 
 func TestBroadcastPrice(t *testing.T) {
 	logger := logrus.New()
-	storage := storage.NewPriceStorage(context.Background(), 100, logger)
-	service := NewPriceService(storage, logger)
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
 
 	// Subscribe two clients
 	client1 := service.Subscribe()
@@ -71,7 +110,7 @@ func TestBroadcastPrice(t *testing.T) {
 
 	// Receive from both clients
 	select {
-	case received1 := <-client1:
+	case received1 := <-client1.C:
 		assert.Equal(t, price.Price, received1.Price)
 		assert.Equal(t, price.Symbol, received1.Symbol)
 	case <-time.After(1 * time.Second):
@@ -79,7 +118,7 @@ func TestBroadcastPrice(t *testing.T) {
 	}
 
 	select {
-	case received2 := <-client2:
+	case received2 := <-client2.C:
 		assert.Equal(t, price.Price, received2.Price)
 		assert.Equal(t, price.Symbol, received2.Symbol)
 	case <-time.After(1 * time.Second):
@@ -93,238 +132,112 @@ func TestBroadcastPrice(t *testing.T) {
 
 func TestBroadcastPriceWithBlockedClient(t *testing.T) {
 	logger := logrus.New()
-	storage := storage.NewPriceStorage(context.Background(), 100, logger)
-	service := NewPriceService(storage, logger)
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
 
 	// Subscribe with small buffer
-	clientChan := make(chan models.PriceUpdate, 1)
-	service.clientsMux.Lock()
-	service.clients[clientChan] = true
-	service.clientsMux.Unlock()
+	sub := newSubscription(1, nil)
+	service.clients.Add(sub)
 
 	// Fill the buffer
 	price1 := models.PriceUpdate{Price: 50000.0, Timestamp: time.Now()}
-	clientChan <- price1
+	sub.C <- price1
 
 	// Try to broadcast another price (should remove blocked client)
 	price2 := models.PriceUpdate{Price: 51000.0, Timestamp: time.Now()}
 	service.broadcastPrice(price2)
 
 	// Client should be removed
-	service.clientsMux.RLock()
-	_, exists := service.clients[clientChan]
-	service.clientsMux.RUnlock()
-	assert.False(t, exists, "Blocked client should be removed")
+	assert.False(t, service.clients.Contains(sub), "Blocked client should be removed")
 }
 
-func TestFetchBitcoinPrice(t *testing.T) {
-	// Create a mock server
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		response := models.CoinDeskResponse{
-			Data: struct {
-				Stats struct {
-					Page        int `json:"PAGE"`
-					PageSize    int `json:"PAGE_SIZE"`
-					TotalAssets int `json:"TOTAL_ASSETS"`
-				} `json:"STATS"`
-				List []models.AssetData `json:"LIST"`
-			}{
-				List: []models.AssetData{
-					{
-						Symbol:               "BTC",
-						Name:                 "Bitcoin",
-						PriceUSD:             50000.0,
-						PriceUSDLastUpdateTS: time.Now().Unix(),
-					},
-				},
-			},
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
-	}))
-	defer server.Close()
-
+func TestBroadcastPriceFiltersBySymbol(t *testing.T) {
 	logger := logrus.New()
-	storage := storage.NewPriceStorage(context.Background(), 100, logger)
-	service := NewPriceService(storage, logger)
-	service.apiURL = server.URL // Use mock server
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
 
-	price, err := service.fetchBitcoinPrice()
+	btcClient := service.Subscribe("BTC")
+	allClient := service.Subscribe()
+	defer service.Unsubscribe(btcClient)
+	defer service.Unsubscribe(allClient)
 
-	assert.NoError(t, err)
-	assert.NotNil(t, price)
-	assert.Equal(t, "BTC", price.Symbol)
-	assert.Equal(t, "Bitcoin", price.Name)
-	assert.Equal(t, 50000.0, price.Price)
-}
+	service.broadcastPrice(models.PriceUpdate{Symbol: "ETH", Price: 3000.0})
+	service.broadcastPrice(models.PriceUpdate{Symbol: "BTC", Price: 50000.0})
 
-func TestFetchBitcoinPriceNotFound(t *testing.T) {
-	// Create a mock server that doesn't return Bitcoin
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		response := models.CoinDeskResponse{
-			Data: struct {
-				Stats struct {
-					Page        int `json:"PAGE"`
-					PageSize    int `json:"PAGE_SIZE"`
-					TotalAssets int `json:"TOTAL_ASSETS"`
-				} `json:"STATS"`
-				List []models.AssetData `json:"LIST"`
-			}{
-				List: []models.AssetData{
-					{
-						Symbol:               "ETH",
-						Name:                 "Ethereum",
-						PriceUSD:             3000.0,
-						PriceUSDLastUpdateTS: time.Now().Unix(),
-					},
-				},
-			},
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
-	}))
-	defer server.Close()
-
-	logger := logrus.New()
-	storage := storage.NewPriceStorage(context.Background(), 100, logger)
-	service := NewPriceService(storage, logger)
-	service.apiURL = server.URL
-
-	price, err := service.fetchBitcoinPrice()
-
-	assert.Error(t, err)
-	assert.Nil(t, price)
-	assert.Contains(t, err.Error(), "bitcoin data not found")
-}
-
-func TestFetchBitcoinPriceAPIError(t *testing.T) {
-	// Create a mock server that returns an error
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusInternalServerError)
-	}))
-	defer server.Close()
-
-	logger := logrus.New()
-	storage := storage.NewPriceStorage(context.Background(), 100, logger)
-	service := NewPriceService(storage, logger)
-	service.apiURL = server.URL
-
-	price, err := service.fetchBitcoinPrice()
-
-	assert.Error(t, err)
-	assert.Nil(t, price)
-	assert.Contains(t, err.Error(), "status code: 500")
-}
-
-func TestFetchBitcoinPriceInvalidJSON(t *testing.T) {
-	// Create a mock server that returns invalid JSON
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.Write([]byte("invalid json"))
-	}))
-	defer server.Close()
-
-	logger := logrus.New()
-	storage := storage.NewPriceStorage(context.Background(), 100, logger)
-	service := NewPriceService(storage, logger)
-	service.apiURL = server.URL
+	assert.Len(t, btcClient.C, 1)
+	assert.Equal(t, "BTC", (<-btcClient.C).Symbol)
 
-	price, err := service.fetchBitcoinPrice()
+	assert.Len(t, allClient.C, 2)
+	assert.Equal(t, "ETH", (<-allClient.C).Symbol)
+	assert.Equal(t, "BTC", (<-allClient.C).Symbol)
 
-	assert.Error(t, err)
-	assert.Nil(t, price)
-	assert.Contains(t, err.Error(), "failed to decode")
+	// Changing the symbol set takes effect on the next broadcast
+	btcClient.AddSymbols("ETH")
+	service.broadcastPrice(models.PriceUpdate{Symbol: "ETH", Price: 3001.0})
+	assert.Equal(t, 3001.0, (<-btcClient.C).Price)
 }
 
 func TestFetchAndBroadcastPrice(t *testing.T) {
-	// Create a mock server
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		response := models.CoinDeskResponse{
-			Data: struct {
-				Stats struct {
-					Page        int `json:"PAGE"`
-					PageSize    int `json:"PAGE_SIZE"`
-					TotalAssets int `json:"TOTAL_ASSETS"`
-				} `json:"STATS"`
-				List []models.AssetData `json:"LIST"`
-			}{
-				List: []models.AssetData{
-					{
-						Symbol:               "BTC",
-						Name:                 "Bitcoin",
-						PriceUSD:             50000.0,
-						PriceUSDLastUpdateTS: time.Now().Unix(),
-					},
-				},
-			},
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
-	}))
-	defer server.Close()
-
 	logger := logrus.New()
-	storage := storage.NewPriceStorage(context.Background(), 100, logger)
-	service := NewPriceService(storage, logger)
-	service.apiURL = server.URL
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
 
 	// Subscribe a client
-	clientChan := service.Subscribe()
-	defer service.Unsubscribe(clientChan)
+	sub := service.Subscribe("BTC")
+	defer service.Unsubscribe(sub)
 
 	// Fetch and broadcast
-	service.fetchAndBroadcastPrice()
+	service.fetchAndBroadcastPrices(context.Background())
 
-	// Check that price was stored
-	latest, exists := storage.GetLatest()
+	// Check that prices were stored per symbol
+	latest, exists := storage.Latest("BTC")
 	assert.True(t, exists)
 	assert.Equal(t, 50000.0, latest.Price)
 
+	latest, exists = storage.Latest("ETH")
+	assert.True(t, exists)
+	assert.Equal(t, 5000.0, latest.Price)
+
 	// Check that client received the price
 	select {
-	case received := <-clientChan:
+	case received := <-sub.C:
 		assert.Equal(t, 50000.0, received.Price)
 		assert.Equal(t, "BTC", received.Symbol)
 	case <-time.After(1 * time.Second):
 		t.Fatal("Timeout waiting for client to receive price")
 	}
+
+	// The ETH update is filtered out for a BTC-only client
+	select {
+	case received := <-sub.C:
+		t.Fatalf("Unexpected update for %s", received.Symbol)
+	default:
+	}
 }
 
-func TestStartPolling(t *testing.T) {
-	// Create a mock server
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		response := models.CoinDeskResponse{
-			Data: struct {
-				Stats struct {
-					Page        int `json:"PAGE"`
-					PageSize    int `json:"PAGE_SIZE"`
-					TotalAssets int `json:"TOTAL_ASSETS"`
-				} `json:"STATS"`
-				List []models.AssetData `json:"LIST"`
-			}{
-				List: []models.AssetData{
-					{
-						Symbol:               "BTC",
-						Name:                 "Bitcoin",
-						PriceUSD:             50000.0,
-						PriceUSDLastUpdateTS: time.Now().Unix(),
-					},
-				},
-			},
-		}
+func TestObserveReceivesStoredUpdates(t *testing.T) {
+	logger := logrus.New()
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
+
+	var observed []models.PriceUpdate
+	service.Observe(func(price models.PriceUpdate) {
+		observed = append(observed, price)
+	})
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
-	}))
-	defer server.Close()
+	service.fetchAndBroadcastPrices(context.Background())
+
+	// Observers see every update with its sequence number, in order
+	require.Len(t, observed, 2)
+	assert.Equal(t, "BTC", observed[0].Symbol)
+	assert.Equal(t, uint64(1), observed[0].Seq)
+	assert.Equal(t, uint64(2), observed[1].Seq)
+}
 
+func TestStartPolling(t *testing.T) {
 	logger := logrus.New()
-	storage := storage.NewPriceStorage(context.Background(), 100, logger)
-	service := NewPriceService(storage, logger)
-	service.apiURL = server.URL
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
 
 	// Create context with short timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
@@ -337,16 +250,390 @@ func TestStartPolling(t *testing.T) {
 	<-ctx.Done()
 
 	// Check that at least one price was fetched
-	latest, exists := storage.GetLatest()
+	latest, exists := storage.Latest("BTC")
 	assert.True(t, exists, "At least one price should be fetched")
 	assert.Equal(t, 50000.0, latest.Price)
 }
 
 func TestGetStorage(t *testing.T) {
 	logger := logrus.New()
-	storage := storage.NewPriceStorage(context.Background(), 100, logger)
-	service := NewPriceService(storage, logger)
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
 
 	retrievedStorage := service.GetStorage()
 	assert.Equal(t, storage, retrievedStorage)
 }
+
+func TestFetchAndBroadcastPriceProviderError(t *testing.T) {
+	logger := logrus.New()
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{err: errors.New("upstream unavailable")}, logger)
+
+	sub := service.Subscribe()
+	defer service.Unsubscribe(sub)
+
+	service.fetchAndBroadcastPrices(context.Background())
+
+	// Nothing should be stored or broadcast
+	_, exists := storage.Latest("BTC")
+	assert.False(t, exists)
+
+	select {
+	case <-sub.C:
+		t.Fatal("Client should not receive a price when the provider fails")
+	default:
+	}
+}
+
+func TestProviderHealth(t *testing.T) {
+	logger := logrus.New()
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+
+	// Providers without health tracking report nothing
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
+	assert.Nil(t, service.ProviderHealth())
+
+	// Monitored providers report their health
+	monitored := provider.NewMonitoredProvider(&mockProvider{price: 50000.0}, provider.NewCircuitBreaker(5, time.Minute), logger)
+	service = NewPriceService(storage, monitored, logger)
+	service.fetchAndBroadcastPrices(context.Background())
+
+	health := service.ProviderHealth()
+	assert.Len(t, health, 1)
+	assert.Equal(t, "mock", health[0].Name)
+	assert.Equal(t, uint64(1), health[0].Requests)
+}
+
+func TestSlowConsumerDisconnect(t *testing.T) {
+	logger := logrus.New()
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
+	service.bufferSize = 2
+
+	sub := service.Subscribe()
+	for i := range 3 {
+		service.broadcastPrice(models.PriceUpdate{Seq: uint64(i + 1), Symbol: "BTC"})
+	}
+
+	// The buffered updates are still delivered before the close is seen
+	assert.Equal(t, uint64(1), (<-sub.C).Seq)
+	assert.Equal(t, uint64(2), (<-sub.C).Seq)
+	_, ok := <-sub.C
+	assert.False(t, ok)
+
+	notice := sub.CloseNotice()
+	require.NotNil(t, notice)
+	assert.Equal(t, ReasonSlowConsumer, notice.Reason)
+	assert.Equal(t, uint64(1), notice.Dropped)
+	assert.Empty(t, service.Clients())
+
+	// Unsubscribing after a disconnect is harmless
+	service.Unsubscribe(sub)
+}
+
+func TestSlowConsumerDropOldest(t *testing.T) {
+	logger := logrus.New()
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
+	service.bufferSize = 2
+
+	sub := service.Subscribe()
+	defer service.Unsubscribe(sub)
+	sub.SetPolicy(PolicyDropOldest)
+
+	for i := range 5 {
+		service.broadcastPrice(models.PriceUpdate{Seq: uint64(i + 1), Symbol: "BTC"})
+	}
+
+	assert.Equal(t, uint64(4), (<-sub.C).Seq)
+	assert.Equal(t, uint64(5), (<-sub.C).Seq)
+	assert.Equal(t, uint64(3), sub.Dropped())
+	assert.Nil(t, sub.CloseNotice())
+}
+
+func TestSlowConsumerConflate(t *testing.T) {
+	logger := logrus.New()
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
+	service.bufferSize = 2
+
+	sub := service.Subscribe()
+	defer service.Unsubscribe(sub)
+	sub.SetPolicy(PolicyConflate)
+
+	service.broadcastPrice(models.PriceUpdate{Seq: 1, Symbol: "BTC", Price: 1})
+	service.broadcastPrice(models.PriceUpdate{Seq: 2, Symbol: "ETH", Price: 2})
+	service.broadcastPrice(models.PriceUpdate{Seq: 3, Symbol: "BTC", Price: 3})
+	service.broadcastPrice(models.PriceUpdate{Seq: 4, Symbol: "BTC", Price: 4})
+
+	// Only the newest update of each symbol is left, in arrival order
+	assert.Equal(t, uint64(2), (<-sub.C).Seq)
+	assert.Equal(t, uint64(4), (<-sub.C).Seq)
+	assert.Equal(t, uint64(2), sub.Dropped())
+
+	// With more symbols than buffer slots the oldest symbols give way
+	service.broadcastPrice(models.PriceUpdate{Seq: 5, Symbol: "BTC"})
+	service.broadcastPrice(models.PriceUpdate{Seq: 6, Symbol: "ETH"})
+	service.broadcastPrice(models.PriceUpdate{Seq: 7, Symbol: "SOL"})
+
+	assert.Equal(t, uint64(6), (<-sub.C).Seq)
+	assert.Equal(t, uint64(7), (<-sub.C).Seq)
+	assert.Equal(t, uint64(3), sub.Dropped())
+}
+
+func TestParseSlowConsumerPolicy(t *testing.T) {
+	policy, err := ParseSlowConsumerPolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, PolicyDisconnect, policy)
+
+	policy, err = ParseSlowConsumerPolicy(" Conflate ")
+	require.NoError(t, err)
+	assert.Equal(t, PolicyConflate, policy)
+
+	_, err = ParseSlowConsumerPolicy("ignore")
+	assert.Error(t, err)
+}
+
+func TestSlowConsumerPolicyFromEnv(t *testing.T) {
+	t.Setenv("SLOW_CONSUMER_POLICY", "drop_oldest")
+
+	logger := logrus.New()
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
+
+	sub := service.Subscribe("BTC")
+	defer service.Unsubscribe(sub)
+
+	clients := service.Clients()
+	require.Len(t, clients, 1)
+	assert.Equal(t, sub.ID, clients[0].ID)
+	assert.Equal(t, PolicyDropOldest, clients[0].Policy)
+	assert.Equal(t, []string{"BTC"}, clients[0].Symbols)
+}
+
+func TestBroadcastDuringChurn(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
+	service.bufferSize = 1
+
+	// Slow clients are disconnected while others subscribe and unsubscribe
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 200 {
+			service.broadcastPrice(models.PriceUpdate{Seq: uint64(i + 1), Symbol: "BTC"})
+		}
+	}()
+	for range 200 {
+		sub := service.Subscribe()
+		service.Unsubscribe(sub)
+	}
+	<-done
+}
+
+func TestThrottleHoldsUpdatesOutsideBuffer(t *testing.T) {
+	logger := logrus.New()
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
+	service.bufferSize = 2
+
+	sub := service.Subscribe()
+	defer service.Unsubscribe(sub)
+	require.NoError(t, service.SetThrottle(sub, 100*time.Millisecond))
+	assert.Equal(t, 100*time.Millisecond, sub.Throttle())
+
+	// The first update of each symbol goes through, later ones are coalesced
+	// without occupying the buffer, so the client is never too slow
+	for i := range 10 {
+		service.broadcastPrice(models.PriceUpdate{Seq: uint64(i + 1), Symbol: "BTC"})
+	}
+	require.Len(t, sub.C, 1)
+	assert.Equal(t, uint64(1), (<-sub.C).Seq)
+	assert.Equal(t, uint64(8), sub.Conflated())
+	assert.Equal(t, uint64(0), sub.Dropped())
+
+	// The newest one arrives once the interval has passed
+	select {
+	case price := <-sub.C:
+		assert.Equal(t, uint64(10), price.Seq)
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for the throttled update")
+	}
+
+	// Lifting the throttle releases held-back updates right away
+	service.broadcastPrice(models.PriceUpdate{Seq: 11, Symbol: "BTC"})
+	assert.Empty(t, sub.C)
+	require.NoError(t, service.SetThrottle(sub, 0))
+	assert.Equal(t, uint64(11), (<-sub.C).Seq)
+
+	service.broadcastPrice(models.PriceUpdate{Seq: 12, Symbol: "BTC"})
+	assert.Equal(t, uint64(12), (<-sub.C).Seq)
+
+	assert.Error(t, service.SetThrottle(sub, -time.Second))
+	assert.Error(t, service.SetThrottle(sub, 2*MaxThrottle))
+}
+
+func TestThrottleIsPerSymbol(t *testing.T) {
+	logger := logrus.New()
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
+
+	sub := service.Subscribe()
+	require.NoError(t, service.SetThrottle(sub, time.Hour))
+
+	service.broadcastPrice(models.PriceUpdate{Seq: 1, Symbol: "BTC"})
+	service.broadcastPrice(models.PriceUpdate{Seq: 2, Symbol: "ETH"})
+	service.broadcastPrice(models.PriceUpdate{Seq: 3, Symbol: "BTC"})
+
+	assert.Equal(t, uint64(1), (<-sub.C).Seq)
+	assert.Equal(t, uint64(2), (<-sub.C).Seq)
+	assert.Empty(t, sub.C)
+
+	clients := service.Clients()
+	require.Len(t, clients, 1)
+	assert.Equal(t, "1h0m0s", clients[0].Throttle)
+
+	// Unsubscribing discards held-back updates and stops the timer
+	service.Unsubscribe(sub)
+	_, ok := <-sub.C
+	assert.False(t, ok)
+}
+
+func TestUnsubscribeRacesSlowConsumerDisconnect(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
+	service.bufferSize = 1
+
+	// Each client is removed exactly once, whichever side gets there first,
+	// and its channel is closed exactly once
+	subs := make([]*Subscription, 100)
+	for i := range subs {
+		subs[i] = service.Subscribe()
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 3 {
+			service.broadcastPrice(models.PriceUpdate{Seq: uint64(i + 1), Symbol: "BTC"})
+		}
+	}()
+	for _, sub := range subs {
+		service.Unsubscribe(sub)
+	}
+	<-done
+
+	assert.Equal(t, 0, service.clients.Len())
+	assert.Equal(t, int64(0), service.metrics.subscribers.Value())
+	for _, sub := range subs {
+		for range sub.C {
+		}
+	}
+}
+
+func TestBrokerShardsFromEnv(t *testing.T) {
+	t.Setenv("BROKER_SHARDS", "1")
+
+	logger := logrus.New()
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	service := NewPriceService(storage, &mockProvider{price: 50000.0}, logger)
+
+	sub := service.Subscribe()
+	assert.True(t, service.clients.Contains(sub))
+	service.Unsubscribe(sub)
+	assert.False(t, service.clients.Contains(sub))
+}
+
+func TestFollowServesUpdatesFromTheBus(t *testing.T) {
+	logger := logrus.New()
+	shared := bus.NewMemory()
+
+	// Two replicas share the bus, only the first one polls
+	leaderStorage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	leader := NewPriceService(leaderStorage, &mockProvider{price: 50000.0}, logger)
+	leader.instance = "leader"
+	leader.SetBus(shared)
+
+	followerStorage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	follower := NewPriceService(followerStorage, &mockProvider{price: 1.0}, logger)
+	follower.instance = "follower"
+	follower.SetBus(shared)
+	sub := follower.Subscribe("BTC")
+	defer follower.Unsubscribe(sub)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	leaderDone := make(chan error, 1)
+	followerDone := make(chan error, 1)
+	go func() { leaderDone <- leader.Follow(ctx) }()
+	go func() { followerDone <- follower.Follow(ctx) }()
+
+	// Poll until the follower has subscribed, then let it catch up
+	require.Eventually(t, func() bool {
+		if followerStorage.LastSeq() > 0 {
+			return true
+		}
+		leader.fetchAndBroadcastPrices(ctx)
+		return false
+	}, time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return followerStorage.LastSeq() == leaderStorage.LastSeq()
+	}, time.Second, 10*time.Millisecond)
+
+	// The follower's clients see the leader's prices and sequence numbers
+	select {
+	case price := <-sub.C:
+		assert.Equal(t, 50000.0, price.Price)
+		stored := leaderStorage.AfterSeq("BTC", price.Seq-1)
+		require.NotEmpty(t, stored)
+		assert.Equal(t, stored[0], price)
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for the followed update")
+	}
+
+	for _, symbol := range []string{"BTC", "ETH"} {
+		want, _ := leaderStorage.Latest(symbol)
+		got, exists := followerStorage.Latest(symbol)
+		require.True(t, exists)
+		assert.Equal(t, want, got)
+	}
+
+	// The leader does not store its own updates twice
+	assert.Len(t, leaderStorage.AfterSeq("BTC", 0), int(leaderStorage.LastSeq()/2))
+
+	cancel()
+	assert.NoError(t, <-leaderDone)
+	assert.NoError(t, <-followerDone)
+}
+
+func TestStartPollingResumesThePublishedSequence(t *testing.T) {
+	logger := logrus.New()
+	shared := bus.NewMemory()
+	ctx := context.Background()
+
+	// The previous leader published update 10, which this instance missed
+	previous := models.PriceUpdate{Seq: 10, Timestamp: time.Now(), Price: 49000.0, Symbol: "BTC", Name: "Bitcoin"}
+	require.NoError(t, shared.Publish(ctx, bus.Message{Origin: "previous", Update: previous}))
+
+	store := storage.NewSymbolStorage(ctx, 100, logger)
+	leader := NewPriceService(store, &mockProvider{price: 50000.0}, logger)
+	leader.instance = "leader"
+	leader.SetBus(shared)
+
+	leader.resync(ctx)
+	assert.Equal(t, uint64(10), store.LastSeq())
+
+	// New updates continue after it instead of reissuing its number
+	leader.fetchAndBroadcastPrices(ctx)
+	latest, exists := store.Latest("BTC")
+	require.True(t, exists)
+	assert.Greater(t, latest.Seq, previous.Seq)
+
+	// Once caught up there is nothing to resync
+	lastSeq := store.LastSeq()
+	leader.resync(ctx)
+	assert.Equal(t, lastSeq, store.LastSeq())
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/storage"
+)
+
+// Replay is what a reconnecting client is sent before live updates
+type Replay struct {
+	Reset   *models.ResetNotice
+	Gaps    []models.GapNotice
+	Updates []models.PriceUpdate
+}
+
+// BuildReplay collects the updates for symbols stored after afterSeq, or
+// after since if afterSeq is zero, merged in sequence order, along with
+// notices for history the store can no longer provide. Without a resume
+// position the replay is empty
+func BuildReplay(store storage.Store, symbols []string, afterSeq uint64, since time.Time) Replay {
+	var r Replay
+	if afterSeq == 0 && since.IsZero() {
+		return r
+	}
+
+	// A position beyond the newest update means server history was lost
+	if lastSeq := store.LastSeq(); afterSeq > lastSeq {
+		r.Reset = &models.ResetNotice{
+			Reason:       "resume position is ahead of server history",
+			RequestedSeq: afterSeq,
+			LastSeq:      lastSeq,
+		}
+		return r
+	}
+
+	for _, symbol := range symbols {
+		if symbol == AllSymbols {
+			symbols = store.Symbols()
+			break
+		}
+	}
+
+	for _, symbol := range symbols {
+		if detector, ok := store.(storage.GapDetector); ok {
+			if gap := detector.DetectGap(symbol, afterSeq, since); gap != nil {
+				r.Gaps = append(r.Gaps, *gap)
+			}
+		}
+
+		if afterSeq > 0 {
+			r.Updates = append(r.Updates, store.AfterSeq(symbol, afterSeq)...)
+		} else {
+			r.Updates = append(r.Updates, store.Since(symbol, since)...)
+		}
+	}
+
+	sort.Slice(r.Updates, func(i, j int) bool {
+		return r.Updates[i].Seq < r.Updates[j].Seq
+	})
+	return r
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+)
+
+// AllSymbols subscribes a client to every symbol
+const AllSymbols = "*"
+
+// SlowConsumerPolicy decides what happens to an update for a client whose
+// buffer is full
+type SlowConsumerPolicy string
+
+const (
+	// PolicyDisconnect closes the client's feed with a disconnect notice
+	PolicyDisconnect SlowConsumerPolicy = "disconnect"
+	// PolicyDropOldest discards the oldest buffered update to make room
+	PolicyDropOldest SlowConsumerPolicy = "drop_oldest"
+	// PolicyConflate keeps only the newest buffered update of each symbol
+	PolicyConflate SlowConsumerPolicy = "conflate"
+)
+
+// ParseSlowConsumerPolicy parses a slow-consumer policy, defaulting to disconnect
+func ParseSlowConsumerPolicy(value string) (SlowConsumerPolicy, error) {
+	switch policy := SlowConsumerPolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case "":
+		return PolicyDisconnect, nil
+	case PolicyDisconnect, PolicyDropOldest, PolicyConflate:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown slow consumer policy: %s", value)
+	}
+}
+
+// ReasonSlowConsumer is the disconnect reason of clients that fell behind
+const ReasonSlowConsumer = "slow_consumer"
+
+// Subscription is a client's feed of price updates filtered by symbol. When
+// the server closes C, CloseNotice says why
+type Subscription struct {
+	C           chan models.PriceUpdate
+	ID          string
+	ConnectedAt time.Time
+	symbols     map[string]bool
+	policy      SlowConsumerPolicy
+	notice      *models.DisconnectNotice
+	dropped     atomic.Uint64
+	conflated   atomic.Uint64
+	mutex       sync.RWMutex
+	sendMux     sync.Mutex
+	throttle    *throttle
+	closed      bool
+}
+
+// newSubscription creates a subscription for symbols, or for every symbol if none are given
+func newSubscription(bufferSize int, symbols []string) *Subscription {
+	if len(symbols) == 0 {
+		symbols = []string{AllSymbols}
+	}
+
+	sub := &Subscription{
+		C:           make(chan models.PriceUpdate, bufferSize),
+		ConnectedAt: time.Now(),
+		symbols:     make(map[string]bool, len(symbols)),
+		policy:      PolicyDisconnect,
+		throttle:    newThrottle(nil),
+	}
+	sub.AddSymbols(symbols...)
+	return sub
+}
+
+// Matches reports whether updates for symbol should be delivered
+func (s *Subscription) Matches(symbol string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.symbols[AllSymbols] || s.symbols[symbol]
+}
+
+// AddSymbols adds symbols to the subscription
+func (s *Subscription) AddSymbols(symbols ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, symbol := range symbols {
+		s.symbols[symbol] = true
+	}
+}
+
+// RemoveSymbols removes symbols from the subscription, AllSymbols removes every symbol
+func (s *Subscription) RemoveSymbols(symbols ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, symbol := range symbols {
+		if symbol == AllSymbols {
+			s.symbols = make(map[string]bool)
+			return
+		}
+		delete(s.symbols, symbol)
+	}
+}
+
+// Symbols returns the sorted list of subscribed symbols
+func (s *Subscription) Symbols() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	symbols := make([]string, 0, len(s.symbols))
+	for symbol := range s.symbols {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// Policy returns the subscription's slow-consumer policy
+func (s *Subscription) Policy() SlowConsumerPolicy {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.policy
+}
+
+// SetPolicy changes the subscription's slow-consumer policy
+func (s *Subscription) SetPolicy(policy SlowConsumerPolicy) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.policy = policy
+}
+
+// Dropped returns the number of updates discarded because the client fell behind
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Conflated returns the number of updates replaced by a newer one while the
+// client's throttle held them back
+func (s *Subscription) Conflated() uint64 {
+	return s.conflated.Load()
+}
+
+// Throttle returns the minimum interval between updates of each symbol,
+// zero if the subscription is not throttled
+func (s *Subscription) Throttle() time.Duration {
+	s.sendMux.Lock()
+	defer s.sendMux.Unlock()
+
+	return s.throttle.interval
+}
+
+// CloseNotice returns why the server closed C, or nil if it was not closed
+// by the server
+func (s *Subscription) CloseNotice() *models.DisconnectNotice {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.notice
+}
+
+// publish offers an update to the client through its throttle. It returns
+// the number of updates discarded, and false if the client must be
+// disconnected. Updates for a closed subscription are ignored
+func (s *Subscription) publish(price models.PriceUpdate, now time.Time) (int, bool) {
+	s.sendMux.Lock()
+	defer s.sendMux.Unlock()
+
+	if s.closed {
+		return 0, true
+	}
+
+	deliver, replaced := s.throttle.offer(price, now)
+	if replaced {
+		s.conflated.Add(1)
+	}
+	if !deliver {
+		return 0, true
+	}
+	return s.deliver(price)
+}
+
+// release delivers the held-back updates that are due by now, or all of them
+// if now is zero, with the same results as publish
+func (s *Subscription) release(now time.Time) (int, bool) {
+	s.sendMux.Lock()
+	defer s.sendMux.Unlock()
+
+	if s.closed {
+		return 0, true
+	}
+
+	return s.deliverAll(s.throttle.due(now))
+}
+
+// setThrottle changes the throttle interval, delivering any held-back updates
+// right away, with the same results as publish
+func (s *Subscription) setThrottle(interval time.Duration) (int, bool) {
+	s.sendMux.Lock()
+	defer s.sendMux.Unlock()
+
+	if s.closed {
+		return 0, true
+	}
+
+	return s.deliverAll(s.throttle.setInterval(interval))
+}
+
+// deliverAll delivers updates in order, stopping if the client must be
+// disconnected. Callers must hold the send lock
+func (s *Subscription) deliverAll(updates []models.PriceUpdate) (int, bool) {
+	var dropped int
+	for _, update := range updates {
+		n, ok := s.deliver(update)
+		dropped += n
+		if !ok {
+			return dropped, false
+		}
+	}
+	return dropped, true
+}
+
+// deliver puts an update in the client's buffer, applying its slow-consumer
+// policy if the buffer is full. It returns the number of updates discarded,
+// and false if the client must be disconnected, in which case price is
+// discarded. Callers must hold the send lock
+func (s *Subscription) deliver(price models.PriceUpdate) (int, bool) {
+	select {
+	case s.C <- price:
+		return 0, true
+	default:
+	}
+
+	var dropped int
+	switch s.Policy() {
+	case PolicyDropOldest:
+		select {
+		case <-s.C:
+			dropped++
+		default:
+		}
+		select {
+		case s.C <- price:
+		default:
+			dropped++
+		}
+	case PolicyConflate:
+		dropped = s.conflate(price)
+	default:
+		s.dropped.Add(1)
+		return 1, false
+	}
+
+	s.dropped.Add(uint64(dropped))
+	return dropped, true
+}
+
+// conflate replaces the buffered updates with the newest one of each symbol,
+// including price, and returns the number discarded. If there are more
+// symbols than buffer slots the oldest updates are discarded too
+func (s *Subscription) conflate(price models.PriceUpdate) int {
+	var queued []models.PriceUpdate
+drain:
+	for {
+		select {
+		case update := <-s.C:
+			queued = append(queued, update)
+		default:
+			break drain
+		}
+	}
+	queued = append(queued, price)
+
+	// Keep the last update of each symbol in arrival order
+	newest := make(map[string]int, len(queued))
+	for i, update := range queued {
+		newest[update.Symbol] = i
+	}
+	kept := make([]models.PriceUpdate, 0, len(newest))
+	for i, update := range queued {
+		if newest[update.Symbol] == i {
+			kept = append(kept, update)
+		}
+	}
+	if excess := len(kept) - cap(s.C); excess > 0 {
+		kept = kept[excess:]
+	}
+
+	dropped := len(queued) - len(kept)
+	for _, update := range kept {
+		select {
+		case s.C <- update:
+		default:
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// close closes C, with a notice of why unless reason is empty. It holds the
+// send lock so no update is being delivered meanwhile, and later ones are
+// ignored
+func (s *Subscription) close(reason, message string) {
+	s.sendMux.Lock()
+	defer s.sendMux.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.throttle.stop()
+
+	if reason != "" {
+		s.mutex.Lock()
+		s.notice = &models.DisconnectNotice{
+			Reason:  reason,
+			Message: message,
+			Dropped: s.dropped.Load(),
+		}
+		s.mutex.Unlock()
+	}
+
+	close(s.C)
+}
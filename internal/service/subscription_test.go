@@ -0,0 +1,36 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionDefaultsToAllSymbols(t *testing.T) {
+	sub := newSubscription(10, nil)
+
+	assert.Equal(t, 10, cap(sub.C))
+	assert.True(t, sub.Matches("BTC"))
+	assert.True(t, sub.Matches("ETH"))
+	assert.Equal(t, []string{AllSymbols}, sub.Symbols())
+}
+
+func TestSubscriptionAddAndRemoveSymbols(t *testing.T) {
+	sub := newSubscription(10, []string{"BTC"})
+
+	assert.True(t, sub.Matches("BTC"))
+	assert.False(t, sub.Matches("ETH"))
+
+	sub.AddSymbols("ETH", "SOL")
+	assert.True(t, sub.Matches("ETH"))
+	assert.Equal(t, []string{"BTC", "ETH", "SOL"}, sub.Symbols())
+
+	sub.RemoveSymbols("BTC")
+	assert.False(t, sub.Matches("BTC"))
+	assert.Equal(t, []string{"ETH", "SOL"}, sub.Symbols())
+
+	// The wildcard removes everything
+	sub.RemoveSymbols(AllSymbols)
+	assert.False(t, sub.Matches("ETH"))
+	assert.Empty(t, sub.Symbols())
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+)
+
+// MaxThrottle is the longest interval a client may throttle its feed to
+const MaxThrottle = time.Hour
+
+// throttle coalesces a subscription's price updates so at most one per
+// interval is delivered for each symbol, always the newest. Updates arriving
+// too early are held back outside the channel until their symbol's interval
+// has passed, when onDue is called from the timer. A zero interval delivers
+// every update. Callers must hold the subscription's send lock
+type throttle struct {
+	interval time.Duration
+	next     map[string]time.Time
+	pending  map[string]models.PriceUpdate
+	timer    *time.Timer
+	onDue    func()
+}
+
+// newThrottle creates a disabled throttle calling onDue when held-back
+// updates are due
+func newThrottle(onDue func()) *throttle {
+	return &throttle{
+		next:    make(map[string]time.Time),
+		pending: make(map[string]models.PriceUpdate),
+		onDue:   onDue,
+	}
+}
+
+// setInterval changes the interval, returning any held-back updates so they
+// can be delivered right away
+func (t *throttle) setInterval(interval time.Duration) []models.PriceUpdate {
+	released := t.due(time.Time{})
+	t.interval = interval
+	clear(t.next)
+	return released
+}
+
+// offer reports whether price should be delivered now. Otherwise it replaces
+// any update of the same symbol being held back, returning true for replaced
+// if there was one
+func (t *throttle) offer(price models.PriceUpdate, now time.Time) (deliver, replaced bool) {
+	if t.interval <= 0 {
+		return true, false
+	}
+
+	if next, exists := t.next[price.Symbol]; !exists || !now.Before(next) {
+		t.next[price.Symbol] = now.Add(t.interval)
+		return true, false
+	}
+
+	_, replaced = t.pending[price.Symbol]
+	t.pending[price.Symbol] = price
+	t.schedule(now)
+	return false, replaced
+}
+
+// due removes and returns the held-back updates whose interval has passed by
+// now, in sequence order. A zero now returns every held-back update
+func (t *throttle) due(now time.Time) []models.PriceUpdate {
+	var due []models.PriceUpdate
+	for symbol, price := range t.pending {
+		if next := t.next[symbol]; now.IsZero() || !now.Before(next) {
+			due = append(due, price)
+			delete(t.pending, symbol)
+			if !now.IsZero() {
+				t.next[symbol] = now.Add(t.interval)
+			}
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].Seq < due[j].Seq
+	})
+
+	t.schedule(now)
+	return due
+}
+
+// schedule arms the timer for the earliest held-back update
+func (t *throttle) schedule(now time.Time) {
+	if len(t.pending) == 0 {
+		t.stop()
+		return
+	}
+
+	var earliest time.Time
+	for symbol := range t.pending {
+		if next := t.next[symbol]; earliest.IsZero() || next.Before(earliest) {
+			earliest = next
+		}
+	}
+
+	if t.timer == nil {
+		t.timer = time.AfterFunc(earliest.Sub(now), t.onDue)
+	} else {
+		t.timer.Reset(earliest.Sub(now))
+	}
+}
+
+// stop disarms the timer
+func (t *throttle) stop() {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
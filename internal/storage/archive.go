@@ -0,0 +1,351 @@
+package storage
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"bitcoin-price-streamer/internal/gorilla"
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// archiveExt is the file extension of per-symbol archive files
+const archiveExt = ".gor"
+
+// ArchiveOptions configures the compressed tier kept behind each symbol's ring
+// buffer. Updates evicted from the ring are archived with their sequence
+// number, timestamp and price; market data is not kept
+type ArchiveOptions struct {
+	Dir       string // directory for archive files, empty to keep archives in memory
+	BlockSize int    // updates per compressed block
+	MaxBlocks int    // sealed blocks kept per symbol before the oldest is dropped
+}
+
+// archive is the compressed history of one symbol's evicted updates. When
+// backed by a file every sealed block is appended to it, and the file is
+// rewritten once it holds twice as many blocks as are kept
+type archive struct {
+	symbol    string
+	series    *gorilla.Series
+	lastSeq   uint64
+	maxBlocks int
+	path      string
+	file      *os.File
+	blocks    int
+	logger    *logrus.Logger
+}
+
+// archivePath returns the archive file for symbol in dir
+func archivePath(dir, symbol string) string {
+	return filepath.Join(dir, url.PathEscape(symbol)+archiveExt)
+}
+
+// archivedSymbols lists the symbols with an archive file in dir
+func archivedSymbols(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+archiveExt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archives: %w", err)
+	}
+
+	symbols := make([]string, 0, len(paths))
+	for _, path := range paths {
+		symbol, err := url.PathUnescape(strings.TrimSuffix(filepath.Base(path), archiveExt))
+		if err != nil {
+			continue
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, nil
+}
+
+// openArchive creates the archive for symbol, restoring the blocks previously
+// written to its file if options.Dir is set
+func openArchive(symbol string, options ArchiveOptions, logger *logrus.Logger) (*archive, error) {
+	a := &archive{
+		symbol:    symbol,
+		series:    gorilla.NewSeries(options.BlockSize, options.MaxBlocks),
+		maxBlocks: options.MaxBlocks,
+		logger:    logger,
+	}
+	if options.Dir == "" {
+		return a, nil
+	}
+
+	if err := os.MkdirAll(options.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	a.path = archivePath(options.Dir, symbol)
+
+	if err := a.load(); err != nil {
+		return nil, err
+	}
+	if a.blocks > a.maxBlocks {
+		if err := a.compact(); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	a.file = file
+	return a, nil
+}
+
+// load reads the blocks in the archive file, truncating a torn block at its end
+func (a *archive) load() error {
+	file, err := os.Open(a.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		block, err := gorilla.ReadBlock(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			a.logger.Warnf("Truncating corrupt archive %s at offset %d: %v", a.path, offset, err)
+			if err := os.Truncate(a.path, offset); err != nil {
+				return fmt.Errorf("failed to truncate archive: %w", err)
+			}
+			return nil
+		}
+
+		if err := a.series.AppendBlock(block); err != nil {
+			return fmt.Errorf("failed to decode archive %s: %w", a.path, err)
+		}
+		if index := a.series.Index(); len(index) > 0 {
+			a.lastSeq = max(a.lastSeq, index[len(index)-1].Last.Seq)
+		}
+		a.blocks++
+		offset += 8 + int64(len(block))
+	}
+}
+
+// add archives an evicted update. Updates already archived, as happens when
+// the WAL is replayed on startup, are skipped
+func (a *archive) add(update models.PriceUpdate) {
+	if update.Seq <= a.lastSeq {
+		return
+	}
+	a.lastSeq = update.Seq
+
+	block := a.series.Append(gorilla.Point{
+		Timestamp: update.Timestamp,
+		Seq:       update.Seq,
+		Value:     update.Price,
+	})
+	if block != nil && a.file != nil {
+		a.write(block)
+	}
+}
+
+// write appends a sealed block to the archive file
+func (a *archive) write(block gorilla.Block) {
+	if _, err := block.WriteTo(a.file); err != nil {
+		a.logger.Errorf("Failed to write archive block for %s: %v", a.symbol, err)
+		return
+	}
+	a.blocks++
+
+	if a.blocks >= 2*a.maxBlocks {
+		if err := a.compact(); err != nil {
+			a.logger.Errorf("Failed to compact archive for %s: %v", a.symbol, err)
+		}
+	}
+}
+
+// compact rewrites the archive file with only the blocks still in the series
+func (a *archive) compact() error {
+	blocks := a.series.Sealed()
+
+	tmp := a.path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	writer := bufio.NewWriter(file)
+	for _, block := range blocks {
+		if _, err := block.WriteTo(writer); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync archive: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close archive: %w", err)
+	}
+
+	if err := os.Rename(tmp, a.path); err != nil {
+		return fmt.Errorf("failed to replace archive: %w", err)
+	}
+	if err := syncDir(filepath.Dir(a.path)); err != nil {
+		return fmt.Errorf("failed to sync archive directory: %w", err)
+	}
+
+	// Reopen so appends go to the new file
+	if a.file != nil {
+		a.file.Close()
+		a.file, err = os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open archive: %w", err)
+		}
+	}
+	a.blocks = len(blocks)
+	return nil
+}
+
+// close writes the partially filled block, so no archived update is lost on
+// restart, and closes the archive file
+func (a *archive) close() error {
+	if a.file == nil {
+		return nil
+	}
+
+	if blocks := a.series.Blocks(); len(blocks) > len(a.series.Sealed()) {
+		if _, err := blocks[len(blocks)-1].WriteTo(a.file); err != nil {
+			a.file.Close()
+			a.file = nil
+			return fmt.Errorf("failed to write archive: %w", err)
+		}
+	}
+
+	err := a.file.Sync()
+	if closeErr := a.file.Close(); err == nil {
+		err = closeErr
+	}
+	a.file = nil
+	return err
+}
+
+// iterate decodes archived updates oldest first, calling fn for those with
+// a sequence number below before (or all of them if before is 0) until fn
+// returns false. It reports whether fn asked to continue
+func (a *archive) iterate(name string, before uint64, fn func(models.PriceUpdate) bool) bool {
+	more := true
+	err := a.series.Iterate(func(p gorilla.Point) bool {
+		if before > 0 && p.Seq >= before {
+			return false
+		}
+		more = fn(a.update(p, name))
+		return more
+	})
+	if err != nil {
+		a.logger.Errorf("Failed to decode archive for %s: %v", a.symbol, err)
+	}
+	return more
+}
+
+// updates decodes the archived updates with a sequence number below before
+func (a *archive) updates(name string, before uint64) []models.PriceUpdate {
+	var updates []models.PriceUpdate
+	a.iterate(name, before, func(update models.PriceUpdate) bool {
+		updates = append(updates, update)
+		return true
+	})
+	return updates
+}
+
+// archiveBlocks is a snapshot of an archive's blocks with their first and
+// last points, the block being filled last
+type archiveBlocks struct {
+	sealed []gorilla.BlockInfo
+	active *gorilla.BlockInfo
+}
+
+// index returns the blocks starting before the sequence number before, or
+// all of them if before is 0
+func (a *archive) index(before uint64) archiveBlocks {
+	starts := func(info gorilla.BlockInfo) bool { return before > 0 && info.First.Seq >= before }
+
+	sealed := a.series.Index()
+	b := archiveBlocks{sealed: sealed[:sort.Search(len(sealed), func(i int) bool { return starts(sealed[i]) })]}
+	if len(b.sealed) == len(sealed) {
+		if active, ok := a.series.Active(); ok && !starts(active) {
+			b.active = &active
+		}
+	}
+	return b
+}
+
+// len returns the number of blocks
+func (b archiveBlocks) len() int {
+	if b.active != nil {
+		return len(b.sealed) + 1
+	}
+	return len(b.sealed)
+}
+
+// at returns the i-th oldest block
+func (b archiveBlocks) at(i int) gorilla.BlockInfo {
+	if i < len(b.sealed) {
+		return b.sealed[i]
+	}
+	return *b.active
+}
+
+// decode decodes the blocks at indexes [lo, hi), skipping updates with a
+// sequence number at or above before unless it is 0
+func (a *archive) decode(b archiveBlocks, lo, hi int, name string, before uint64) []models.PriceUpdate {
+	count := 0
+	for i := lo; i < hi; i++ {
+		count += b.at(i).Block.Len()
+	}
+
+	updates := make([]models.PriceUpdate, 0, count)
+	for i := lo; i < hi; i++ {
+		if err := b.at(i).Block.Iterate(func(p gorilla.Point) bool {
+			if before > 0 && p.Seq >= before {
+				return false
+			}
+			updates = append(updates, a.update(p, name))
+			return true
+		}); err != nil {
+			a.logger.Errorf("Failed to decode archive for %s: %v", a.symbol, err)
+		}
+	}
+	return updates
+}
+
+// dropped returns the newest update dropped from the archive, if any
+func (a *archive) dropped(name string) (models.PriceUpdate, bool) {
+	p, ok := a.series.Dropped()
+	if !ok {
+		return models.PriceUpdate{}, false
+	}
+	return a.update(p, name), true
+}
+
+// update rebuilds a price update from an archived point
+func (a *archive) update(p gorilla.Point, name string) models.PriceUpdate {
+	return models.PriceUpdate{
+		Seq:       p.Seq,
+		Price:     p.Value,
+		Timestamp: p.Timestamp,
+		Symbol:    a.symbol,
+		Name:      name,
+	}
+}
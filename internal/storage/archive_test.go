@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"context"
+	"math/rand"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addUpdates stores n BTC updates one second apart with market data attached
+func addUpdates(storage *SymbolStorage, n int, start time.Time) {
+	for i := range n {
+		storage.Add(models.PriceUpdate{
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			Price:     100.0 + float64(i),
+			Symbol:    "BTC",
+			Name:      "Bitcoin",
+			Volume24h: 1e9,
+		})
+	}
+}
+
+func seqs(updates []models.PriceUpdate) []uint64 {
+	result := make([]uint64, len(updates))
+	for i, update := range updates {
+		result[i] = update.Seq
+	}
+	return result
+}
+
+func TestArchiveKeepsEvictedUpdates(t *testing.T) {
+	logger := logrus.New()
+	storage := NewSymbolStorage(context.Background(), 3, logger)
+	require.NoError(t, storage.EnableArchive(ArchiveOptions{BlockSize: 2, MaxBlocks: 2}))
+
+	start := time.UnixMilli(time.Now().UnixMilli())
+	addUpdates(storage, 10, start)
+
+	// The ring holds 8-10; 1-7 were archived in blocks of two, and sealing
+	// the third block dropped the first
+	all := storage.Since("BTC", time.Time{})
+	assert.Equal(t, []uint64{3, 4, 5, 6, 7, 8, 9, 10}, seqs(all))
+
+	archived := all[0]
+	assert.Equal(t, 102.0, archived.Price)
+	assert.True(t, start.Add(2*time.Second).Equal(archived.Timestamp))
+	assert.Equal(t, "Bitcoin", archived.Name)
+	assert.Zero(t, archived.Volume24h, "market data is not archived")
+
+	// Range reads only decode the archive when they reach past the ring
+	assert.Equal(t, []uint64{9, 10}, seqs(storage.AfterSeq("BTC", 8)))
+	assert.Equal(t, []uint64{5, 6, 7, 8}, seqs(storage.Range("BTC", start.Add(4*time.Second), start.Add(8*time.Second))))
+
+	// Pages continue from the ring into the archive
+	page := storage.Query("BTC", Query{Order: OrderDesc, Limit: 4})
+	assert.Equal(t, []uint64{10, 9, 8, 7}, seqs(page.Updates))
+	cursor, err := DecodeCursor(page.NextCursor)
+	require.NoError(t, err)
+	page = storage.Query("BTC", Query{Order: OrderDesc, Limit: 4, BeforeSeq: cursor.BeforeSeq})
+	assert.Equal(t, []uint64{6, 5, 4, 3}, seqs(page.Updates))
+	assert.Empty(t, page.NextCursor)
+
+	// Only updates dropped from the archive are reported as a gap
+	assert.Nil(t, storage.DetectGap("BTC", 2, time.Time{}))
+	gap := storage.DetectGap("BTC", 1, time.Time{})
+	require.NotNil(t, gap)
+	assert.Equal(t, uint64(2), gap.MissedThroughSeq)
+	assert.Equal(t, uint64(3), gap.ReplayFromSeq)
+
+	var iterated []models.PriceUpdate
+	storage.Iterate("BTC", func(update models.PriceUpdate) bool {
+		iterated = append(iterated, update)
+		return len(iterated) < 6
+	})
+	assert.Equal(t, []uint64{3, 4, 5, 6, 7, 8}, seqs(iterated))
+}
+
+func TestArchiveRestoresFromDisk(t *testing.T) {
+	logger := logrus.New()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	options := testWALOptions(t)
+	archive := &ArchiveOptions{Dir: filepath.Join(options.Dir, "archive"), BlockSize: 2, MaxBlocks: 10}
+
+	storage, err := NewDiskStorage(ctx, 3, options, archive, logger)
+	require.NoError(t, err)
+	addUpdates(storage, 10, time.Now())
+	require.NoError(t, storage.Close())
+	assert.FileExists(t, archivePath(archive.Dir, "BTC"))
+
+	// Replaying the log through the ring does not archive updates twice
+	restarted, err := NewDiskStorage(ctx, 3, options, archive, logger)
+	require.NoError(t, err)
+	defer restarted.Close()
+
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, seqs(restarted.Since("BTC", time.Time{})))
+	assert.Nil(t, restarted.DetectGap("BTC", 0, time.Time{}))
+}
+
+func TestArchiveCompactsFile(t *testing.T) {
+	logger := logrus.New()
+	options := ArchiveOptions{Dir: t.TempDir(), BlockSize: 2, MaxBlocks: 2}
+
+	storage := NewSymbolStorage(context.Background(), 1, logger)
+	require.NoError(t, storage.EnableArchive(options))
+	addUpdates(storage, 20, time.Now())
+	require.NoError(t, storage.Close())
+
+	// Only the kept blocks survive a restart
+	restarted := NewSymbolStorage(context.Background(), 1, logger)
+	require.NoError(t, restarted.EnableArchive(options))
+	defer restarted.Close()
+
+	series, exists := restarted.get("BTC")
+	require.True(t, exists)
+	assert.LessOrEqual(t, series.archive.blocks, options.MaxBlocks)
+	assert.Equal(t, []uint64{17, 18, 19}, seqs(restarted.Since("BTC", time.Time{})))
+}
+
+func TestArchiveReadsMatchFullHistory(t *testing.T) {
+	logger := logrus.New()
+	storage := NewSymbolStorage(context.Background(), 5, logger)
+	require.NoError(t, storage.EnableArchive(ArchiveOptions{BlockSize: 4, MaxBlocks: 30}))
+
+	start := time.UnixMilli(time.Now().UnixMilli())
+	addUpdates(storage, 150, start)
+
+	var all []models.PriceUpdate
+	storage.Iterate("BTC", func(update models.PriceUpdate) bool {
+		all = append(all, update)
+		return true
+	})
+	require.Len(t, all, 126)
+
+	// Every page, read from whichever blocks it needs, matches a scan of
+	// the full history
+	rng := rand.New(rand.NewSource(1))
+	seq := func() uint64 { return uint64(rng.Intn(160)) }
+	at := func() time.Time { return start.Add(time.Duration(rng.Intn(160)) * time.Second) }
+	for i := 0; i < 500; i++ {
+		q := Query{Limit: rng.Intn(12)}
+		switch rng.Intn(4) {
+		case 0:
+			q.AfterSeq = seq()
+		case 1:
+			q.Since = at()
+		}
+		switch rng.Intn(4) {
+		case 0:
+			q.BeforeSeq = seq()
+		case 1:
+			q.Until = at()
+		}
+		if rng.Intn(2) == 0 {
+			q.Order = OrderDesc
+		}
+
+		var matching []models.PriceUpdate
+		for _, u := range all {
+			if lowerBound(q)(u) && (upperBound(q) == nil || !upperBound(q)(u)) {
+				matching = append(matching, u)
+			}
+		}
+		if q.Order == OrderDesc {
+			slices.Reverse(matching)
+		}
+
+		var paged []models.PriceUpdate
+		for page, pages := storage.Query("BTC", q), 0; ; pages++ {
+			require.Less(t, pages, 200, "%+v", q)
+			paged = append(paged, page.Updates...)
+			if page.NextCursor == "" {
+				break
+			}
+			cursor, err := DecodeCursor(page.NextCursor)
+			require.NoError(t, err)
+			next := q
+			if cursor.AfterSeq > 0 {
+				next.AfterSeq = cursor.AfterSeq
+			} else {
+				next.BeforeSeq = cursor.BeforeSeq
+			}
+			page = storage.Query("BTC", next)
+		}
+		assert.Equal(t, seqs(matching), seqs(paged), "%+v", q)
+	}
+
+	// A limited page decodes only a few blocks
+	series, _ := storage.get("BTC")
+	h := series.view(window{from: lowerBound(Query{}), limit: 3, desc: true})
+	assert.Empty(t, h.archived)
+	h = series.view(window{from: lowerBound(Query{}), limit: 10, desc: true})
+	assert.LessOrEqual(t, len(h.archived), 12)
+	h = series.view(window{from: lowerBound(Query{AfterSeq: 40}), limit: 10})
+	assert.Equal(t, uint64(41), h.archived[0].Seq)
+	assert.LessOrEqual(t, len(h.archived), 16)
+	assert.False(t, h.ring)
+}
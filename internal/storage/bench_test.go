@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+	"unsafe"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+const benchUpdates = 10000
+
+// benchHistory generates a realistic history: 5-second polling with jitter,
+// sequence numbers interleaved with other symbols and a random walk price
+func benchHistory(n int) []models.PriceUpdate {
+	rng := rand.New(rand.NewSource(42))
+	updates := make([]models.PriceUpdate, n)
+
+	t := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	seq := uint64(1)
+	price := 42000.0
+	for i := range updates {
+		updates[i] = models.PriceUpdate{Seq: seq, Timestamp: t, Price: price, Symbol: "BTC", Name: "Bitcoin"}
+
+		t = t.Add(5*time.Second + time.Duration(rng.Intn(20)-10)*time.Millisecond)
+		seq += 7
+		if rng.Intn(3) > 0 {
+			price = math.Round((price+rng.NormFloat64()*15)*100) / 100
+		}
+	}
+	return updates
+}
+
+// BenchmarkPriceStorageRing measures the footprint of the history kept in the
+// []models.PriceUpdate ring: the struct itself plus the symbol and name
+// strings carried by every update
+func BenchmarkPriceStorageRing(b *testing.B) {
+	updates := benchHistory(benchUpdates)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ring := NewPriceStorage(context.Background(), benchUpdates, logger)
+		for _, update := range updates {
+			ring.Add(update)
+		}
+	}
+
+	perUpdate := int(unsafe.Sizeof(models.PriceUpdate{})) + len("BTC") + len("Bitcoin")
+	b.ReportMetric(float64(perUpdate), "bytes/point")
+}
+
+// BenchmarkPriceStorageArchive measures the same history pushed through a
+// small ring into the compressed archive
+func BenchmarkPriceStorageArchive(b *testing.B) {
+	updates := benchHistory(benchUpdates)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	options := ArchiveOptions{BlockSize: 120, MaxBlocks: benchUpdates}
+
+	var size int
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ring := NewPriceStorage(context.Background(), 1, logger)
+		ring.archive, _ = openArchive("BTC", options, logger)
+		for _, update := range updates {
+			ring.Add(update)
+		}
+		size = ring.archive.series.Size()
+	}
+	b.ReportMetric(float64(size)/(benchUpdates-1), "bytes/point")
+}
+
+// BenchmarkPriceStorageHistory measures history reads against a full
+// archive at the default block size and count, plus the default ring
+func BenchmarkPriceStorageHistory(b *testing.B) {
+	options := ArchiveOptions{BlockSize: 120, MaxBlocks: 2016}
+	updates := benchHistory(options.BlockSize*options.MaxBlocks + 1000)
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	ring := NewPriceStorage(context.Background(), 1000, logger)
+	ring.archive, _ = openArchive("BTC", options, logger)
+	for _, update := range updates {
+		ring.Add(update)
+	}
+	middle := updates[len(updates)/2]
+
+	for _, bench := range []struct {
+		name  string
+		query Query
+	}{
+		{"newest", Query{Limit: 100, Order: OrderDesc}},
+		{"oldest", Query{Limit: 100}},
+		{"after_seq", Query{AfterSeq: middle.Seq, Limit: 100}},
+		{"before_seq", Query{BeforeSeq: middle.Seq, Limit: 100, Order: OrderDesc}},
+		{"since", Query{Since: middle.Timestamp, Until: middle.Timestamp.Add(time.Hour)}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ring.Query(bench.query)
+			}
+		})
+	}
+}
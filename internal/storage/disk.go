@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"bitcoin-price-streamer/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewDiskStorage creates a storage that appends every update to a write-ahead
+// log in options.Dir and restores the retained history from it on startup.
+// Sequence numbers continue after the last one logged, even if its update
+// has expired. If archive is set, evicted updates are also kept in compressed archive
+// files, so the log only needs to retain what fits in the ring buffers.
+// The log is flushed and closed when ctx is cancelled
+func NewDiskStorage(ctx context.Context, capacity int, options WALOptions, archive *ArchiveOptions, logger *logrus.Logger) (*SymbolStorage, error) {
+	wal, err := OpenWAL(options, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := wal.EnforceRetention(time.Now()); err != nil {
+		logger.Warnf("Failed to enforce WAL retention: %v", err)
+	}
+
+	ss := NewSymbolStorage(ctx, capacity, logger)
+	if archive != nil {
+		if err := ss.EnableArchive(*archive); err != nil {
+			wal.Close()
+			return nil, fmt.Errorf("failed to restore archives: %w", err)
+		}
+	}
+	if err := wal.Replay(ss.restore); err != nil {
+		wal.Close()
+		return nil, fmt.Errorf("failed to replay WAL: %w", err)
+	}
+	ss.resumeSeq(wal.LastSeq())
+	ss.journal = wal
+
+	go wal.Run(ctx)
+
+	logger.Infof("Restored %d symbols up to sequence %d from %s", len(ss.Symbols()), ss.LastSeq(), options.Dir)
+	return ss, nil
+}
+
+// NewFromEnv builds the storage backend selected by STORAGE_BACKEND: 'memory'
+// (ring buffers only) or 'disk' (ring buffers backed by a write-ahead log).
+// Unless STORAGE_ARCHIVE is 'false', updates evicted from the ring buffers
+// are kept in a compressed archive, on disk with the 'disk' backend
+func NewFromEnv(ctx context.Context, logger *logrus.Logger) (*SymbolStorage, error) {
+	capacity := utils.GetEnvInt("STORAGE_CAPACITY", 1000)
+
+	var archive *ArchiveOptions
+	if utils.GetEnvString("STORAGE_ARCHIVE", "true") == "true" {
+		archive = &ArchiveOptions{
+			BlockSize: utils.GetEnvInt("STORAGE_ARCHIVE_BLOCK_SIZE", 120),
+			MaxBlocks: utils.GetEnvInt("STORAGE_ARCHIVE_BLOCKS", 2016),
+		}
+	}
+
+	switch backend := utils.GetEnvString("STORAGE_BACKEND", "memory"); backend {
+	case "memory":
+		ss := NewSymbolStorage(ctx, capacity, logger)
+		if archive != nil {
+			if err := ss.EnableArchive(*archive); err != nil {
+				return nil, err
+			}
+		}
+		return ss, nil
+	case "disk":
+		fsync, err := ParseFsyncPolicy(utils.GetEnvString("STORAGE_FSYNC", string(FsyncInterval)))
+		if err != nil {
+			return nil, err
+		}
+
+		options := WALOptions{
+			Dir:           utils.GetEnvString("STORAGE_DIR", "./data"),
+			Fsync:         fsync,
+			FsyncInterval: time.Duration(utils.GetEnvInt("STORAGE_FSYNC_INTERVAL_MS", 1000)) * time.Millisecond,
+			SegmentBytes:  int64(utils.GetEnvInt("STORAGE_SEGMENT_BYTES", 16<<20)),
+			Retention:     time.Duration(utils.GetEnvInt("STORAGE_RETENTION_HOURS", 24)) * time.Hour,
+		}
+		if archive != nil {
+			archive.Dir = filepath.Join(options.Dir, "archive")
+		}
+		return NewDiskStorage(ctx, capacity, options, archive, logger)
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", backend)
+	}
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskStorageRestoresHistory(t *testing.T) {
+	logger := logrus.New()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	options := testWALOptions(t)
+
+	storage, err := NewDiskStorage(ctx, 10, options, nil, logger)
+	require.NoError(t, err)
+
+	now := time.Now()
+	storage.Add(models.PriceUpdate{Timestamp: now, Price: 100.0, Symbol: "BTC"})
+	storage.Add(models.PriceUpdate{Timestamp: now, Price: 10.0, Symbol: "ETH"})
+	storage.Add(models.PriceUpdate{Timestamp: now, Price: 101.0, Symbol: "BTC"})
+	require.NoError(t, storage.Close())
+
+	// A restart replays the log and continues the sequence
+	restarted, err := NewDiskStorage(ctx, 10, options, nil, logger)
+	require.NoError(t, err)
+	defer restarted.Close()
+
+	assert.Equal(t, uint64(3), restarted.LastSeq())
+	assert.Equal(t, []string{"BTC", "ETH"}, restarted.Symbols())
+
+	btc := restarted.Since("BTC", time.Time{})
+	require.Len(t, btc, 2)
+	assert.Equal(t, uint64(3), btc[1].Seq)
+
+	stored := restarted.Add(models.PriceUpdate{Timestamp: now, Price: 102.0, Symbol: "BTC"})
+	assert.Equal(t, uint64(4), stored.Seq)
+}
+
+func TestDiskStorageResumesSequenceAfterRetention(t *testing.T) {
+	logger := logrus.New()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	options := testWALOptions(t)
+	options.SegmentBytes = 1
+	archive := &ArchiveOptions{Dir: filepath.Join(options.Dir, "archive"), BlockSize: 2, MaxBlocks: 10}
+
+	// Downtime longer than the retention window expires the whole history
+	storage, err := NewDiskStorage(ctx, 3, options, archive, logger)
+	require.NoError(t, err)
+	addUpdates(storage, 10, time.Now().Add(-2*options.Retention))
+	require.NoError(t, storage.Close())
+
+	restarted, err := NewDiskStorage(ctx, 3, options, archive, logger)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), restarted.LastSeq())
+	assert.Equal(t, 1, restarted.journal.Segments())
+
+	addUpdates(restarted, 6, time.Now())
+	require.NoError(t, restarted.Close())
+
+	// New updates are numbered after the expired ones, so the archive keeps
+	// them and every sequence number identifies one update. 8-10 expired
+	// before leaving the ring, so were never archived
+	restarted, err = NewDiskStorage(ctx, 3, options, archive, logger)
+	require.NoError(t, err)
+	defer restarted.Close()
+
+	page := restarted.Query("BTC", Query{AfterSeq: 3})
+	assert.Equal(t, []uint64{4, 5, 6, 7, 11, 12, 13, 14, 15, 16}, seqs(page.Updates))
+	assert.Equal(t, 100.0, page.Updates[4].Price)
+	assert.Equal(t, uint64(16), restarted.LastSeq())
+
+	// The log alone carries the sequence when there is no archive
+	restarted.Close()
+	withoutArchive, err := NewDiskStorage(ctx, 3, options, nil, logger)
+	require.NoError(t, err)
+	defer withoutArchive.Close()
+	assert.Equal(t, uint64(16), withoutArchive.LastSeq())
+}
+
+func TestNewFromEnvRejectsUnknownBackend(t *testing.T) {
+	t.Setenv("STORAGE_BACKEND", "tape")
+
+	_, err := NewFromEnv(context.Background(), logrus.New())
+	assert.Error(t, err)
+}
@@ -2,9 +2,12 @@ package storage
 
 import (
 	"context"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
+	"bitcoin-price-streamer/internal/gorilla"
 	"bitcoin-price-streamer/internal/models"
 
 	"github.com/sirupsen/logrus"
@@ -12,13 +15,15 @@ import (
 
 // PriceStorage manages price updates with ring buffer
 type PriceStorage struct {
-	updates  []models.PriceUpdate
-	capacity int
-	head     int
-	tail     int
-	size     int
-	mutex    sync.RWMutex
-	logger   *logrus.Logger
+	updates     []models.PriceUpdate
+	capacity    int
+	head        int
+	tail        int
+	size        int
+	lastEvicted *models.PriceUpdate
+	archive     *archive
+	mutex       sync.RWMutex
+	logger      *logrus.Logger
 }
 
 func NewPriceStorage(ctx context.Context, capacity int, logger *logrus.Logger) *PriceStorage {
@@ -34,6 +39,15 @@ func (ps *PriceStorage) Add(update models.PriceUpdate) {
 	ps.mutex.Lock()
 	defer ps.mutex.Unlock()
 
+	if ps.size == ps.capacity {
+		// remember the oldest update before it is overwritten
+		evicted := ps.updates[ps.head]
+		ps.lastEvicted = &evicted
+		if ps.archive != nil {
+			ps.archive.add(evicted)
+		}
+	}
+
 	ps.updates[ps.head] = update
 	ps.head = (ps.head + 1) % ps.capacity
 
@@ -49,37 +63,264 @@ func (ps *PriceStorage) Add(update models.PriceUpdate) {
 		update.Price, update.Timestamp.Format(time.RFC3339), ps.size, ps.capacity)
 }
 
+// at returns the i-th oldest update in the ring. Callers must hold the lock
+func (ps *PriceStorage) at(i int) models.PriceUpdate {
+	return ps.updates[(ps.tail+i)%ps.capacity]
+}
+
+// name returns the asset name carried by the newest update, used for the
+// archived updates which do not keep it. Callers must hold the lock
+func (ps *PriceStorage) name() string {
+	if ps.size == 0 {
+		return ""
+	}
+	return ps.at(ps.size - 1).Name
+}
+
+// ringStart returns the sequence number of the oldest update in the ring, or
+// 0 if it is empty. Callers must hold the lock
+func (ps *PriceStorage) ringStart() uint64 {
+	if ps.size == 0 {
+		return 0
+	}
+	return ps.at(0).Seq
+}
+
+// window selects the updates a read needs: from the first matching from up
+// to the first matching to, or the newest if to is nil. With a limit only
+// that many are read, from the oldest or, if desc, the newest
+type window struct {
+	from  func(models.PriceUpdate) bool
+	to    func(models.PriceUpdate) bool
+	limit int
+	desc  bool
+}
+
+// past reports whether u is after the end of the window
+func (w window) past(u models.PriceUpdate) bool {
+	return w.to != nil && w.to(u)
+}
+
+// history is a contiguous run of a symbol's updates: some of the archived
+// ones, followed by the ring unless the run ends before it. Callers must
+// hold the lock while using it
+type history struct {
+	archived []models.PriceUpdate
+	ring     bool
+	ps       *PriceStorage
+}
+
+// view returns the history covering w. Only the archived blocks holding
+// updates in w are decoded and, with a limit, only enough of them to fill
+// it and tell whether more follow
+func (ps *PriceStorage) view(w window) history {
+	h := history{ps: ps, ring: true}
+	if ps.archive == nil || (ps.size > 0 && !w.from(ps.at(0))) {
+		return h
+	}
+
+	// The ring may hold the whole page of a newest-first read
+	ringMatches := sort.Search(ps.size, func(i int) bool { return w.past(ps.at(i)) })
+	if w.desc && w.limit > 0 && ringMatches > w.limit {
+		return h
+	}
+
+	name, start := ps.name(), ps.ringStart()
+	blocks := ps.archive.index(start)
+	point := func(p gorilla.Point) models.PriceUpdate { return ps.archive.update(p, name) }
+
+	n := blocks.len()
+	lo := sort.Search(n, func(i int) bool { return w.from(point(blocks.at(i).Last)) })
+	hi := sort.Search(n, func(i int) bool { return w.past(point(blocks.at(i).First)) })
+	h.ring = hi == n
+	if lo >= hi {
+		return h
+	}
+
+	// The blocks at lo and hi-1 may hold updates outside w, so only the
+	// blocks between them count towards the limit
+	begin, end := lo, hi
+	if w.limit > 0 && w.desc {
+		count := 0
+		if h.ring {
+			count = ringMatches
+		}
+		for begin = hi - 1; begin > lo && count <= w.limit; {
+			begin--
+			count += blocks.at(begin).Block.Len()
+		}
+	} else if w.limit > 0 {
+		count := 0
+		for end = lo + 1; end < hi && count <= w.limit; end++ {
+			count += blocks.at(end).Block.Len()
+		}
+		h.ring = h.ring && end == hi
+	}
+
+	h.archived = ps.archive.decode(blocks, begin, end, name, start)
+	return h
+}
+
+// len returns the number of updates in the history
+func (h history) len() int {
+	if !h.ring {
+		return len(h.archived)
+	}
+	return len(h.archived) + h.ps.size
+}
+
+// at returns the i-th oldest update
+func (h history) at(i int) models.PriceUpdate {
+	if i < len(h.archived) {
+		return h.archived[i]
+	}
+	return h.ps.at(i - len(h.archived))
+}
+
+// search returns the smallest logical index in [0, len) for which pred is
+// true, or len. Updates are appended in time and sequence order, so pred
+// must be false for a prefix of the history and true for the rest
+func (h history) search(pred func(models.PriceUpdate) bool) int {
+	return sort.Search(h.len(), func(i int) bool {
+		return pred(h.at(i))
+	})
+}
+
+// copyRange copies the updates at logical indexes [lo, hi) into a new slice
+func (h history) copyRange(lo, hi int) []models.PriceUpdate {
+	if lo >= hi {
+		return nil
+	}
+	updates := make([]models.PriceUpdate, hi-lo)
+	for i := range updates {
+		updates[i] = h.at(lo + i)
+	}
+	return updates
+}
+
+// bounds returns the logical index range [lo, hi) of updates in w
+func (h history) bounds(w window) (int, int) {
+	lo, hi := h.search(w.from), h.len()
+	if w.to != nil {
+		hi = h.search(w.to)
+	}
+	return lo, hi
+}
+
+// read copies the updates in w, which must have no limit
+func (ps *PriceStorage) read(w window) []models.PriceUpdate {
+	h := ps.view(w)
+	return h.copyRange(h.bounds(w))
+}
+
+// lowerBound returns the predicate matching updates at or after the start of q
+func lowerBound(q Query) func(models.PriceUpdate) bool {
+	return func(u models.PriceUpdate) bool {
+		return (q.Since.IsZero() || u.Timestamp.After(q.Since)) && u.Seq > q.AfterSeq
+	}
+}
+
+// upperBound returns the predicate matching updates after the end of q, or
+// nil if q has no end
+func upperBound(q Query) func(models.PriceUpdate) bool {
+	if q.Until.IsZero() && q.BeforeSeq == 0 {
+		return nil
+	}
+	return func(u models.PriceUpdate) bool {
+		return (!q.Until.IsZero() && u.Timestamp.After(q.Until)) || (q.BeforeSeq > 0 && u.Seq >= q.BeforeSeq)
+	}
+}
+
 // GetUpdatesSince returns all updates since the given timestamp
 func (ps *PriceStorage) GetUpdatesSince(since time.Time) []models.PriceUpdate {
 	ps.mutex.RLock()
 	defer ps.mutex.RUnlock()
 
-	var updates []models.PriceUpdate
+	updates := ps.read(window{from: func(u models.PriceUpdate) bool { return u.Timestamp.After(since) }})
 
-	for i := 0; i < ps.size; i++ {
-		idx := (ps.tail + i) % ps.capacity
-		update := ps.updates[idx]
+	ps.logger.Debugf("Retrieved %d updates since %s", len(updates), since.Format(time.RFC3339))
+	return updates
+}
+
+// GetUpdatesAfterSeq returns all updates with a sequence number greater than seq
+func (ps *PriceStorage) GetUpdatesAfterSeq(seq uint64) []models.PriceUpdate {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	updates := ps.read(window{from: func(u models.PriceUpdate) bool { return u.Seq > seq }})
+
+	ps.logger.Debugf("Retrieved %d updates after sequence %d", len(updates), seq)
+	return updates
+}
+
+// GetUpdatesBetween returns all updates with a timestamp in [from, to)
+func (ps *PriceStorage) GetUpdatesBetween(from, to time.Time) []models.PriceUpdate {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	updates := ps.read(window{
+		from: func(u models.PriceUpdate) bool { return !u.Timestamp.Before(from) },
+		to:   func(u models.PriceUpdate) bool { return !u.Timestamp.Before(to) },
+	})
+
+	ps.logger.Debugf("Retrieved %d updates between %s and %s", len(updates),
+		from.Format(time.RFC3339), to.Format(time.RFC3339))
+	return updates
+}
 
-		// Only check if update is after the requested time
-		if update.Timestamp.After(since) {
-			updates = append(updates, update)
+// Query returns one page of the updates matching q
+func (ps *PriceStorage) Query(q Query) Page {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	w := window{from: lowerBound(q), to: upperBound(q), limit: q.Limit, desc: q.Order == OrderDesc}
+	h := ps.view(w)
+	lo, hi := h.bounds(w)
+	limit := hi - lo
+	if q.Limit > 0 && q.Limit < limit {
+		limit = q.Limit
+	}
+
+	var page Page
+	if q.Order == OrderDesc {
+		page.Updates = h.copyRange(hi-limit, hi)
+		slices.Reverse(page.Updates)
+		if hi-limit > lo {
+			page.NextCursor = EncodeCursor(Cursor{BeforeSeq: h.at(hi - limit).Seq})
+		}
+	} else {
+		page.Updates = h.copyRange(lo, lo+limit)
+		if lo+limit < hi {
+			page.NextCursor = EncodeCursor(Cursor{AfterSeq: h.at(lo + limit - 1).Seq})
 		}
 	}
 
-	ps.logger.Debugf("Retrieved %d updates since %s", len(updates), since.Format(time.RFC3339))
-	return updates
+	ps.logger.Debugf("Queried %d updates", len(page.Updates))
+	return page
 }
 
-// GetAllUpdates returns all stored updates
-func (ps *PriceStorage) GetAllUpdates() []models.PriceUpdate {
+// Iterate calls fn for each stored update, archived ones first, oldest first,
+// until fn returns false. The storage is read-locked while fn runs
+func (ps *PriceStorage) Iterate(fn func(models.PriceUpdate) bool) {
 	ps.mutex.RLock()
 	defer ps.mutex.RUnlock()
 
-	updates := make([]models.PriceUpdate, ps.size)
+	if ps.archive != nil && !ps.archive.iterate(ps.name(), ps.ringStart(), fn) {
+		return
+	}
 	for i := 0; i < ps.size; i++ {
-		idx := (ps.tail + i) % ps.capacity
-		updates[i] = ps.updates[idx]
+		if !fn(ps.at(i)) {
+			return
+		}
 	}
+}
+
+// GetAllUpdates returns all stored updates
+func (ps *PriceStorage) GetAllUpdates() []models.PriceUpdate {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	updates := ps.read(window{from: func(models.PriceUpdate) bool { return true }})
 
 	ps.logger.Debugf("Retrieved all %d updates", len(updates))
 	return updates
@@ -101,3 +342,52 @@ func (ps *PriceStorage) GetLatest() (models.PriceUpdate, bool) {
 		latest.Price, latest.Timestamp.Format(time.RFC3339))
 	return latest, true
 }
+
+// GetOldest returns the oldest retained price update
+func (ps *PriceStorage) GetOldest() (models.PriceUpdate, bool) {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	if ps.archive != nil {
+		var oldest models.PriceUpdate
+		found := false
+		ps.archive.iterate(ps.name(), ps.ringStart(), func(update models.PriceUpdate) bool {
+			oldest, found = update, true
+			return false
+		})
+		if found {
+			return oldest, true
+		}
+	}
+	if ps.size == 0 {
+		return models.PriceUpdate{}, false
+	}
+	return ps.updates[ps.tail], true
+}
+
+// closeArchive flushes and closes the archive, if the storage has one
+func (ps *PriceStorage) closeArchive() error {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	if ps.archive == nil {
+		return nil
+	}
+	return ps.archive.close()
+}
+
+// GetLastEvicted returns the most recent update no longer retained: pushed
+// out of the buffer, or out of the archive when the storage has one
+func (ps *PriceStorage) GetLastEvicted() (models.PriceUpdate, bool) {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	if ps.archive != nil {
+		return ps.archive.dropped(ps.name())
+	}
+
+	if ps.lastEvicted == nil {
+		return models.PriceUpdate{}, false
+	}
+	return *ps.lastEvicted, true
+}
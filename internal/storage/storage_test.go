@@ -9,6 +9,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewPriceStorage(t *testing.T) {
@@ -259,3 +260,118 @@ func TestStorageCapacity(t *testing.T) {
 		assert.Equal(t, expectedPrices[i], update.Price)
 	}
 }
+
+func TestGetOldestAndLastEvicted(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+	storage := NewPriceStorage(ctx, 3, logger)
+
+	_, exists := storage.GetOldest()
+	assert.False(t, exists)
+
+	for i := 0; i < 3; i++ {
+		storage.Add(models.PriceUpdate{Timestamp: time.Now(), Price: float64(i), Symbol: "BTC"})
+	}
+
+	// Nothing evicted while the buffer has room
+	_, evicted := storage.GetLastEvicted()
+	assert.False(t, evicted)
+
+	storage.Add(models.PriceUpdate{Timestamp: time.Now(), Price: 3, Symbol: "BTC"})
+	storage.Add(models.PriceUpdate{Timestamp: time.Now(), Price: 4, Symbol: "BTC"})
+
+	oldest, exists := storage.GetOldest()
+	assert.True(t, exists)
+	assert.Equal(t, 2.0, oldest.Price)
+
+	last, evicted := storage.GetLastEvicted()
+	assert.True(t, evicted)
+	assert.Equal(t, 1.0, last.Price)
+}
+
+func TestQueryPagination(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+	storage := NewPriceStorage(ctx, 5, logger)
+
+	// Wrap the buffer so the oldest update is not at index 0
+	baseTime := time.Now()
+	for i := 1; i <= 7; i++ {
+		storage.Add(models.PriceUpdate{
+			Seq:       uint64(i),
+			Timestamp: baseTime.Add(time.Duration(i) * time.Second),
+			Price:     float64(i),
+			Symbol:    "BTC",
+		})
+	}
+
+	prices := func(page Page) []float64 {
+		var result []float64
+		for _, update := range page.Updates {
+			result = append(result, update.Price)
+		}
+		return result
+	}
+
+	// Ascending pages walk forward from the oldest match
+	page := storage.Query(Query{Limit: 2, Order: OrderAsc})
+	assert.Equal(t, []float64{3, 4}, prices(page))
+	cursor, err := DecodeCursor(page.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, Cursor{AfterSeq: 4}, cursor)
+
+	page = storage.Query(Query{AfterSeq: cursor.AfterSeq, Limit: 2, Order: OrderAsc})
+	assert.Equal(t, []float64{5, 6}, prices(page))
+	page = storage.Query(Query{AfterSeq: 6, Limit: 2, Order: OrderAsc})
+	assert.Equal(t, []float64{7}, prices(page))
+	assert.Empty(t, page.NextCursor)
+
+	// Descending pages walk backward from the newest match
+	page = storage.Query(Query{Limit: 2, Order: OrderDesc})
+	assert.Equal(t, []float64{7, 6}, prices(page))
+	cursor, err = DecodeCursor(page.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, Cursor{BeforeSeq: 6}, cursor)
+
+	// Time bounds: since is exclusive and until inclusive
+	page = storage.Query(Query{
+		Since: baseTime.Add(4 * time.Second),
+		Until: baseTime.Add(6 * time.Second),
+		Order: OrderAsc,
+	})
+	assert.Equal(t, []float64{5, 6}, prices(page))
+	assert.Empty(t, page.NextCursor)
+
+	// Empty ranges
+	assert.Empty(t, storage.Query(Query{AfterSeq: 7}).Updates)
+	assert.Empty(t, storage.Query(Query{Since: baseTime.Add(time.Hour), Until: baseTime}).Updates)
+}
+
+func TestGetUpdatesBetweenWithWrapping(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+	storage := NewPriceStorage(ctx, 3, logger)
+
+	baseTime := time.Now()
+	for i := 0; i < 5; i++ {
+		storage.Add(models.PriceUpdate{Timestamp: baseTime.Add(time.Duration(i) * time.Second), Price: float64(i), Symbol: "BTC"})
+	}
+
+	updates := storage.GetUpdatesBetween(baseTime, baseTime.Add(4*time.Second))
+	require.Len(t, updates, 2)
+	assert.Equal(t, 2.0, updates[0].Price)
+	assert.Equal(t, 3.0, updates[1].Price)
+}
+
+func TestCursorEncoding(t *testing.T) {
+	for _, cursor := range []Cursor{{AfterSeq: 42}, {BeforeSeq: 7}} {
+		decoded, err := DecodeCursor(EncodeCursor(cursor))
+		require.NoError(t, err)
+		assert.Equal(t, cursor, decoded)
+	}
+
+	_, err := DecodeCursor("not a cursor")
+	assert.Error(t, err)
+	_, err = DecodeCursor(EncodeCursor(Cursor{AfterSeq: 1})[:1])
+	assert.Error(t, err)
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+)
+
+// Order is the direction a query walks a symbol's history
+type Order string
+
+const (
+	// OrderAsc returns updates oldest first
+	OrderAsc Order = "asc"
+	// OrderDesc returns updates newest first
+	OrderDesc Order = "desc"
+)
+
+// ParseOrder parses a query order, defaulting to ascending
+func ParseOrder(value string) (Order, error) {
+	switch order := Order(strings.ToLower(value)); order {
+	case "":
+		return OrderAsc, nil
+	case OrderAsc, OrderDesc:
+		return order, nil
+	default:
+		return "", fmt.Errorf("unknown order: %s", value)
+	}
+}
+
+// Query selects a page of a symbol's updates. Zero-valued bounds are unset
+type Query struct {
+	Since     time.Time // timestamp after Since
+	Until     time.Time // timestamp at or before Until
+	AfterSeq  uint64    // sequence number greater than AfterSeq
+	BeforeSeq uint64    // sequence number less than BeforeSeq
+	Limit     int       // maximum number of updates, 0 for all
+	Order     Order     // OrderAsc pages from the oldest match, OrderDesc from the newest
+}
+
+// Page is one page of query results
+type Page struct {
+	Updates []models.PriceUpdate
+	// NextCursor continues the query in the same order, empty on the last page
+	NextCursor string
+}
+
+// Cursor is a position between two sequence numbers in a symbol's history.
+// Exactly one of its fields is set
+type Cursor struct {
+	AfterSeq  uint64
+	BeforeSeq uint64
+}
+
+// errInvalidCursor reports a cursor that was not produced by EncodeCursor
+var errInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor encodes a cursor as an opaque URL-safe token
+func EncodeCursor(c Cursor) string {
+	raw := "a" + strconv.FormatUint(c.AfterSeq, 10)
+	if c.BeforeSeq > 0 {
+		raw = "b" + strconv.FormatUint(c.BeforeSeq, 10)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor decodes a token produced by EncodeCursor
+func DecodeCursor(token string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) < 2 {
+		return Cursor{}, errInvalidCursor
+	}
+
+	seq, err := strconv.ParseUint(string(raw[1:]), 10, 64)
+	if err != nil {
+		return Cursor{}, errInvalidCursor
+	}
+
+	switch raw[0] {
+	case 'a':
+		return Cursor{AfterSeq: seq}, nil
+	case 'b':
+		return Cursor{BeforeSeq: seq}, nil
+	default:
+		return Cursor{}, errInvalidCursor
+	}
+}
+
+// Store is a history of sequenced price updates kept per asset symbol.
+// Updates of a symbol are returned oldest first
+type Store interface {
+	// Add assigns the next sequence number to an update, stores it and
+	// returns the stored update
+	Add(update models.PriceUpdate) models.PriceUpdate
+	// Latest returns the most recent update for symbol
+	Latest(symbol string) (models.PriceUpdate, bool)
+	// Since returns the updates for symbol with a timestamp after since
+	Since(symbol string, since time.Time) []models.PriceUpdate
+	// AfterSeq returns the updates for symbol with a sequence number greater than seq
+	AfterSeq(symbol string, seq uint64) []models.PriceUpdate
+	// Range returns the updates for symbol with a timestamp in [from, to)
+	Range(symbol string, from, to time.Time) []models.PriceUpdate
+	// Query returns one page of the updates for symbol matching q
+	Query(symbol string, q Query) Page
+	// Iterate calls fn for each update of symbol until fn returns false
+	Iterate(symbol string, fn func(models.PriceUpdate) bool)
+	// Symbols returns the sorted symbols with stored updates
+	Symbols() []string
+	// LastSeq returns the sequence number of the most recently stored update
+	LastSeq() uint64
+	// Close releases any resources held by the store
+	Close() error
+}
+
+// GapDetector is implemented by stores that evict old updates, to tell
+// reconnecting clients which part of the history is no longer available
+type GapDetector interface {
+	DetectGap(symbol string, afterSeq uint64, since time.Time) *models.GapNotice
+}
+
+// Appender is implemented by stores that can take updates sequenced by
+// another instance, so every instance serves the same sequence numbers
+type Appender interface {
+	// Append stores an update with its own sequence number, returning false
+	// without storing it unless it is newer than the last stored update
+	Append(update models.PriceUpdate) bool
+}
+
+var (
+	_ Store       = (*SymbolStorage)(nil)
+	_ GapDetector = (*SymbolStorage)(nil)
+	_ Appender    = (*SymbolStorage)(nil)
+)
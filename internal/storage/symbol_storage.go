@@ -0,0 +1,298 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SymbolStorage keeps a separate ring buffer of price updates per asset symbol
+// and stamps every update with a sequence number that increases across symbols
+type SymbolStorage struct {
+	ctx      context.Context
+	series   map[string]*PriceStorage
+	capacity int
+	lastSeq  uint64
+	journal  *WAL
+	archive  *ArchiveOptions
+	mutex    sync.RWMutex
+	logger   *logrus.Logger
+}
+
+// NewSymbolStorage creates a storage holding up to capacity updates per symbol
+func NewSymbolStorage(ctx context.Context, capacity int, logger *logrus.Logger) *SymbolStorage {
+	return &SymbolStorage{
+		ctx:      ctx,
+		series:   make(map[string]*PriceStorage),
+		capacity: capacity,
+		logger:   logger,
+	}
+}
+
+// EnableArchive keeps updates evicted from each symbol's ring buffer in a
+// compressed archive, restoring archives found in options.Dir. Sequence
+// numbers continue after the newest archived update. It must be called
+// before any update is stored
+func (ss *SymbolStorage) EnableArchive(options ArchiveOptions) error {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	ss.archive = &options
+	if options.Dir == "" {
+		return nil
+	}
+
+	symbols, err := archivedSymbols(options.Dir)
+	if err != nil {
+		return err
+	}
+	for _, symbol := range symbols {
+		if series := ss.newSeries(symbol); series.archive != nil {
+			ss.lastSeq = max(ss.lastSeq, series.archive.lastSeq)
+		}
+	}
+	return nil
+}
+
+// newSeries creates the ring buffer for symbol, with its archive if enabled.
+// Callers must hold the lock
+func (ss *SymbolStorage) newSeries(symbol string) *PriceStorage {
+	series := NewPriceStorage(ss.ctx, ss.capacity, ss.logger)
+	if ss.archive != nil {
+		archive, err := openArchive(symbol, *ss.archive, ss.logger)
+		if err != nil {
+			ss.logger.Errorf("Failed to open archive for %s, keeping only recent updates: %v", symbol, err)
+		} else {
+			series.archive = archive
+		}
+	}
+	ss.series[symbol] = series
+	return series
+}
+
+// Add assigns the next sequence number to a price update, adds it to the ring
+// buffer of its symbol and returns the stored update
+func (ss *SymbolStorage) Add(update models.PriceUpdate) models.PriceUpdate {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	series, exists := ss.series[update.Symbol]
+	if !exists {
+		series = ss.newSeries(update.Symbol)
+		ss.logger.Infof("Tracking new symbol %s", update.Symbol)
+	}
+
+	ss.lastSeq++
+	update.Seq = ss.lastSeq
+
+	// Persist before serving so a replayed history never skips a sequence
+	if ss.journal != nil {
+		if err := ss.journal.Append(update); err != nil {
+			ss.logger.Errorf("Failed to persist price update %d: %v", update.Seq, err)
+		}
+	}
+	series.Add(update)
+
+	return update
+}
+
+// Append stores an update sequenced elsewhere, keeping its sequence number.
+// Updates not newer than the last stored one are ignored, so a sequence may
+// skip numbers but never goes back
+func (ss *SymbolStorage) Append(update models.PriceUpdate) bool {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	if update.Seq <= ss.lastSeq {
+		return false
+	}
+
+	series, exists := ss.series[update.Symbol]
+	if !exists {
+		series = ss.newSeries(update.Symbol)
+		ss.logger.Infof("Tracking new symbol %s", update.Symbol)
+	}
+
+	ss.lastSeq = update.Seq
+	if ss.journal != nil {
+		if err := ss.journal.Append(update); err != nil {
+			ss.logger.Errorf("Failed to persist price update %d: %v", update.Seq, err)
+		}
+	}
+	series.Add(update)
+
+	return true
+}
+
+// restore adds a previously persisted update, keeping its sequence number
+func (ss *SymbolStorage) restore(update models.PriceUpdate) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	series, exists := ss.series[update.Symbol]
+	if !exists {
+		series = ss.newSeries(update.Symbol)
+	}
+
+	series.Add(update)
+	if update.Seq > ss.lastSeq {
+		ss.lastSeq = update.Seq
+	}
+}
+
+// resumeSeq makes sequence numbers continue after seq, e.g. the last one
+// used before a restart whose update has since expired
+func (ss *SymbolStorage) resumeSeq(seq uint64) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	ss.lastSeq = max(ss.lastSeq, seq)
+}
+
+// Close flushes and closes the on-disk log and archives, if the storage has them
+func (ss *SymbolStorage) Close() error {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	var errs []error
+	for symbol, series := range ss.series {
+		if err := series.closeArchive(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close archive for %s: %w", symbol, err))
+		}
+	}
+	if ss.journal != nil {
+		errs = append(errs, ss.journal.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// LastSeq returns the sequence number of the most recently stored update
+func (ss *SymbolStorage) LastSeq() uint64 {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	return ss.lastSeq
+}
+
+// get returns the ring buffer for symbol, if any updates were stored for it
+func (ss *SymbolStorage) get(symbol string) (*PriceStorage, bool) {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	series, exists := ss.series[symbol]
+	return series, exists
+}
+
+// Since returns all updates for symbol since the given timestamp
+func (ss *SymbolStorage) Since(symbol string, since time.Time) []models.PriceUpdate {
+	series, exists := ss.get(symbol)
+	if !exists {
+		return nil
+	}
+	return series.GetUpdatesSince(since)
+}
+
+// AfterSeq returns all updates for symbol with a sequence number greater than seq
+func (ss *SymbolStorage) AfterSeq(symbol string, seq uint64) []models.PriceUpdate {
+	series, exists := ss.get(symbol)
+	if !exists {
+		return nil
+	}
+	return series.GetUpdatesAfterSeq(seq)
+}
+
+// Range returns the updates for symbol with a timestamp in [from, to)
+func (ss *SymbolStorage) Range(symbol string, from, to time.Time) []models.PriceUpdate {
+	series, exists := ss.get(symbol)
+	if !exists {
+		return nil
+	}
+	return series.GetUpdatesBetween(from, to)
+}
+
+// Query returns one page of the updates for symbol matching q
+func (ss *SymbolStorage) Query(symbol string, q Query) Page {
+	series, exists := ss.get(symbol)
+	if !exists {
+		return Page{}
+	}
+	return series.Query(q)
+}
+
+// Iterate calls fn for each stored update of symbol until fn returns false
+func (ss *SymbolStorage) Iterate(symbol string, fn func(models.PriceUpdate) bool) {
+	series, exists := ss.get(symbol)
+	if !exists {
+		return
+	}
+	series.Iterate(fn)
+}
+
+// Latest returns the most recent price update for symbol
+func (ss *SymbolStorage) Latest(symbol string) (models.PriceUpdate, bool) {
+	series, exists := ss.get(symbol)
+	if !exists {
+		return models.PriceUpdate{}, false
+	}
+	return series.GetLatest()
+}
+
+// DetectGap reports whether updates for symbol after the resume position were
+// evicted from storage. The position is a sequence number if afterSeq is
+// non-zero, otherwise a timestamp
+func (ss *SymbolStorage) DetectGap(symbol string, afterSeq uint64, since time.Time) *models.GapNotice {
+	series, exists := ss.get(symbol)
+	if !exists {
+		return nil
+	}
+
+	evicted, hasEvicted := series.GetLastEvicted()
+	if !hasEvicted {
+		return nil
+	}
+
+	gap := &models.GapNotice{
+		Symbol:           symbol,
+		MissedThroughSeq: evicted.Seq,
+		MissedThrough:    evicted.Timestamp,
+	}
+	if afterSeq > 0 {
+		if evicted.Seq <= afterSeq {
+			return nil
+		}
+		gap.RequestedSeq = afterSeq
+	} else {
+		if !evicted.Timestamp.After(since) {
+			return nil
+		}
+		gap.RequestedSince = &since
+	}
+
+	if oldest, ok := series.GetOldest(); ok {
+		gap.ReplayFromSeq = oldest.Seq
+		gap.ReplayFrom = &oldest.Timestamp
+	}
+
+	ss.logger.Debugf("Detected gap for %s: missed through sequence %d", symbol, evicted.Seq)
+	return gap
+}
+
+// Symbols returns the sorted list of symbols with stored updates
+func (ss *SymbolStorage) Symbols() []string {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	symbols := make([]string, 0, len(ss.series))
+	for symbol := range ss.series {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymbolStorageSeparatesSymbols(t *testing.T) {
+	logger := logrus.New()
+	storage := NewSymbolStorage(context.Background(), 2, logger)
+
+	baseTime := time.Now()
+	storage.Add(models.PriceUpdate{Timestamp: baseTime.Add(-2 * time.Second), Price: 100.0, Symbol: "BTC"})
+	storage.Add(models.PriceUpdate{Timestamp: baseTime.Add(-2 * time.Second), Price: 10.0, Symbol: "ETH"})
+	storage.Add(models.PriceUpdate{Timestamp: baseTime.Add(-1 * time.Second), Price: 101.0, Symbol: "BTC"})
+	storage.Add(models.PriceUpdate{Timestamp: baseTime, Price: 102.0, Symbol: "BTC"})
+
+	// Each symbol has its own capacity
+	btc := storage.Since("BTC", time.Time{})
+	assert.Len(t, btc, 2)
+	assert.Equal(t, 101.0, btc[0].Price)
+	assert.Equal(t, 102.0, btc[1].Price)
+
+	eth := storage.Since("ETH", time.Time{})
+	assert.Len(t, eth, 1)
+	assert.Equal(t, 10.0, eth[0].Price)
+
+	assert.Equal(t, []string{"BTC", "ETH"}, storage.Symbols())
+}
+
+func TestSymbolStorageLatest(t *testing.T) {
+	logger := logrus.New()
+	storage := NewSymbolStorage(context.Background(), 10, logger)
+
+	_, exists := storage.Latest("BTC")
+	assert.False(t, exists)
+
+	storage.Add(models.PriceUpdate{Timestamp: time.Now(), Price: 100.0, Symbol: "BTC"})
+	storage.Add(models.PriceUpdate{Timestamp: time.Now(), Price: 10.0, Symbol: "ETH"})
+
+	latest, exists := storage.Latest("BTC")
+	assert.True(t, exists)
+	assert.Equal(t, 100.0, latest.Price)
+
+	_, exists = storage.Latest("SOL")
+	assert.False(t, exists)
+}
+
+func TestSymbolStorageSince(t *testing.T) {
+	logger := logrus.New()
+	storage := NewSymbolStorage(context.Background(), 10, logger)
+
+	baseTime := time.Now()
+	storage.Add(models.PriceUpdate{Timestamp: baseTime.Add(-10 * time.Second), Price: 100.0, Symbol: "BTC"})
+	storage.Add(models.PriceUpdate{Timestamp: baseTime, Price: 101.0, Symbol: "BTC"})
+	storage.Add(models.PriceUpdate{Timestamp: baseTime, Price: 10.0, Symbol: "ETH"})
+
+	updates := storage.Since("BTC", baseTime.Add(-5*time.Second))
+	assert.Len(t, updates, 1)
+	assert.Equal(t, 101.0, updates[0].Price)
+
+	assert.Empty(t, storage.Since("SOL", baseTime.Add(-5*time.Second)))
+}
+
+func TestSymbolStorageAssignsSequenceNumbers(t *testing.T) {
+	logger := logrus.New()
+	storage := NewSymbolStorage(context.Background(), 10, logger)
+
+	assert.Equal(t, uint64(0), storage.LastSeq())
+
+	// Sequence numbers increase across symbols, even for identical timestamps
+	now := time.Now()
+	first := storage.Add(models.PriceUpdate{Timestamp: now, Price: 100.0, Symbol: "BTC"})
+	second := storage.Add(models.PriceUpdate{Timestamp: now, Price: 10.0, Symbol: "ETH"})
+	third := storage.Add(models.PriceUpdate{Timestamp: now, Price: 101.0, Symbol: "BTC"})
+
+	assert.Equal(t, uint64(1), first.Seq)
+	assert.Equal(t, uint64(2), second.Seq)
+	assert.Equal(t, uint64(3), third.Seq)
+	assert.Equal(t, uint64(3), storage.LastSeq())
+
+	latest, _ := storage.Latest("BTC")
+	assert.Equal(t, uint64(3), latest.Seq)
+}
+
+func TestSymbolStorageAppendKeepsSequenceNumbers(t *testing.T) {
+	logger := logrus.New()
+	storage := NewSymbolStorage(context.Background(), 10, logger)
+
+	now := time.Now()
+	assert.True(t, storage.Append(models.PriceUpdate{Seq: 5, Timestamp: now, Price: 100.0, Symbol: "BTC"}))
+	assert.True(t, storage.Append(models.PriceUpdate{Seq: 7, Timestamp: now, Price: 10.0, Symbol: "ETH"}))
+
+	// Duplicates and older updates are ignored
+	assert.False(t, storage.Append(models.PriceUpdate{Seq: 7, Timestamp: now, Price: 11.0, Symbol: "ETH"}))
+	assert.False(t, storage.Append(models.PriceUpdate{Seq: 6, Timestamp: now, Price: 101.0, Symbol: "BTC"}))
+	assert.Equal(t, uint64(7), storage.LastSeq())
+
+	latest, _ := storage.Latest("ETH")
+	assert.Equal(t, 10.0, latest.Price)
+
+	// Local updates continue the sequence
+	next := storage.Add(models.PriceUpdate{Timestamp: now, Price: 102.0, Symbol: "BTC"})
+	assert.Equal(t, uint64(8), next.Seq)
+}
+
+func TestSymbolStorageAfterSeq(t *testing.T) {
+	logger := logrus.New()
+	storage := NewSymbolStorage(context.Background(), 10, logger)
+
+	// Updates sharing a timestamp are still resumed exactly
+	now := time.Now()
+	storage.Add(models.PriceUpdate{Timestamp: now, Price: 100.0, Symbol: "BTC"})
+	storage.Add(models.PriceUpdate{Timestamp: now, Price: 101.0, Symbol: "BTC"})
+	storage.Add(models.PriceUpdate{Timestamp: now, Price: 10.0, Symbol: "ETH"})
+	storage.Add(models.PriceUpdate{Timestamp: now, Price: 102.0, Symbol: "BTC"})
+
+	updates := storage.AfterSeq("BTC", 1)
+	assert.Len(t, updates, 2)
+	assert.Equal(t, 101.0, updates[0].Price)
+	assert.Equal(t, 102.0, updates[1].Price)
+
+	assert.Empty(t, storage.AfterSeq("BTC", 4))
+	assert.Empty(t, storage.AfterSeq("SOL", 0))
+}
+
+func TestSymbolStorageDetectGap(t *testing.T) {
+	logger := logrus.New()
+	storage := NewSymbolStorage(context.Background(), 2, logger)
+
+	baseTime := time.Now()
+	storage.Add(models.PriceUpdate{Timestamp: baseTime.Add(-30 * time.Second), Price: 100.0, Symbol: "BTC"})
+	storage.Add(models.PriceUpdate{Timestamp: baseTime.Add(-20 * time.Second), Price: 101.0, Symbol: "BTC"})
+
+	// Nothing evicted yet
+	assert.Nil(t, storage.DetectGap("BTC", 1, time.Time{}))
+
+	storage.Add(models.PriceUpdate{Timestamp: baseTime.Add(-10 * time.Second), Price: 102.0, Symbol: "BTC"})
+	storage.Add(models.PriceUpdate{Timestamp: baseTime, Price: 103.0, Symbol: "BTC"})
+
+	// Sequence 1 and 2 were evicted, replay starts at 3
+	gap := storage.DetectGap("BTC", 1, time.Time{})
+	if assert.NotNil(t, gap) {
+		assert.Equal(t, "BTC", gap.Symbol)
+		assert.Equal(t, uint64(1), gap.RequestedSeq)
+		assert.Equal(t, uint64(2), gap.MissedThroughSeq)
+		assert.Equal(t, uint64(3), gap.ReplayFromSeq)
+	}
+	assert.Nil(t, storage.DetectGap("BTC", 2, time.Time{}))
+
+	// Time-based positions behave the same way
+	gap = storage.DetectGap("BTC", 0, baseTime.Add(-40*time.Second))
+	if assert.NotNil(t, gap) {
+		assert.NotNil(t, gap.RequestedSince)
+		assert.Equal(t, uint64(2), gap.MissedThroughSeq)
+	}
+	assert.Nil(t, storage.DetectGap("BTC", 0, baseTime.Add(-20*time.Second)))
+
+	assert.Nil(t, storage.DetectGap("ETH", 1, time.Time{}))
+}
+
+func TestSymbolStorageRange(t *testing.T) {
+	logger := logrus.New()
+	storage := NewSymbolStorage(context.Background(), 10, logger)
+
+	baseTime := time.Now()
+	for i := 0; i < 5; i++ {
+		storage.Add(models.PriceUpdate{Timestamp: baseTime.Add(time.Duration(i) * time.Second), Price: float64(i), Symbol: "BTC"})
+	}
+
+	// The lower bound is inclusive and the upper bound exclusive
+	updates := storage.Range("BTC", baseTime.Add(1*time.Second), baseTime.Add(3*time.Second))
+	assert.Len(t, updates, 2)
+	assert.Equal(t, 1.0, updates[0].Price)
+	assert.Equal(t, 2.0, updates[1].Price)
+
+	assert.Empty(t, storage.Range("SOL", baseTime, baseTime.Add(time.Hour)))
+}
+
+func TestSymbolStorageIterate(t *testing.T) {
+	logger := logrus.New()
+	storage := NewSymbolStorage(context.Background(), 3, logger)
+
+	for i := 0; i < 5; i++ {
+		storage.Add(models.PriceUpdate{Timestamp: time.Now(), Price: float64(i), Symbol: "BTC"})
+	}
+
+	// Iteration runs oldest first and stops when the callback returns false
+	var prices []float64
+	storage.Iterate("BTC", func(update models.PriceUpdate) bool {
+		prices = append(prices, update.Price)
+		return len(prices) < 2
+	})
+	assert.Equal(t, []float64{2, 3}, prices)
+
+	storage.Iterate("SOL", func(update models.PriceUpdate) bool {
+		t.Fatal("unexpected update for unknown symbol")
+		return false
+	})
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package storage
+
+// syncDir does nothing, directories cannot be synced outside Unix
+func syncDir(dir string) error {
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package storage
+
+import "os"
+
+// syncDir flushes a directory to stable storage, so files just created in or
+// renamed into it survive a crash along with their contents
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return file.Sync()
+}
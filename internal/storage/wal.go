@@ -0,0 +1,433 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FsyncPolicy controls when appended records are flushed to stable storage
+type FsyncPolicy string
+
+const (
+	// FsyncAlways flushes after every record
+	FsyncAlways FsyncPolicy = "always"
+	// FsyncInterval flushes pending records periodically
+	FsyncInterval FsyncPolicy = "interval"
+	// FsyncNever leaves flushing to the operating system
+	FsyncNever FsyncPolicy = "never"
+)
+
+// ParseFsyncPolicy parses an fsync policy name
+func ParseFsyncPolicy(value string) (FsyncPolicy, error) {
+	switch policy := FsyncPolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case FsyncAlways, FsyncInterval, FsyncNever:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown fsync policy: %s", value)
+	}
+}
+
+const (
+	// segmentExt is the file extension of WAL segments
+	segmentExt = ".wal"
+	// recordHeaderSize is the length and CRC32 prefix of each record
+	recordHeaderSize = 8
+	// maxRecordSize bounds a record so a corrupt length cannot exhaust memory
+	maxRecordSize = 1 << 20
+)
+
+// errCorruptRecord reports a torn or corrupted record in a segment
+var errCorruptRecord = errors.New("corrupt record")
+
+// WALOptions configures a write-ahead log
+type WALOptions struct {
+	Dir           string
+	Fsync         FsyncPolicy
+	FsyncInterval time.Duration
+	SegmentBytes  int64
+	Retention     time.Duration
+}
+
+// segment describes one WAL segment file, named after its first sequence number
+type segment struct {
+	path     string
+	firstSeq uint64
+	lastSeq  uint64
+	newest   time.Time
+	size     int64
+}
+
+// WAL is an append-only log of price updates split into size-bounded segment
+// files. Each record is a little-endian length and CRC32 followed by the JSON
+// encoded update, so a torn write at the tail is detected and cut off on open
+type WAL struct {
+	options  WALOptions
+	segments []*segment
+	file     *os.File
+	dirty    bool
+	closed   bool
+	mutex    sync.Mutex
+	logger   *logrus.Logger
+}
+
+// OpenWAL opens the log in options.Dir, creating the directory if needed and
+// truncating any incomplete record at the end of the last segment
+func OpenWAL(options WALOptions, logger *logrus.Logger) (*WAL, error) {
+	if err := os.MkdirAll(options.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create WAL directory: %w", err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(options.Dir, "*"+segmentExt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list WAL segments: %w", err)
+	}
+	sort.Strings(paths)
+
+	w := &WAL{options: options, logger: logger}
+	for i, path := range paths {
+		seg := &segment{path: path}
+		valid, err := readSegment(path, func(update models.PriceUpdate) {
+			if seg.firstSeq == 0 {
+				seg.firstSeq = update.Seq
+			}
+			seg.lastSeq = update.Seq
+			if update.Timestamp.After(seg.newest) {
+				seg.newest = update.Timestamp
+			}
+		})
+		seg.size = valid
+
+		if errors.Is(err, errCorruptRecord) {
+			if i < len(paths)-1 {
+				logger.Warnf("Skipping corrupt tail of WAL segment %s at offset %d", path, valid)
+			} else {
+				logger.Warnf("Truncating torn write in WAL segment %s at offset %d", path, valid)
+				if err := os.Truncate(path, valid); err != nil {
+					return nil, fmt.Errorf("failed to truncate WAL segment: %w", err)
+				}
+			}
+		} else if err != nil {
+			return nil, err
+		}
+
+		if seg.firstSeq == 0 {
+			seg.firstSeq = segmentFirstSeq(path)
+		}
+		w.segments = append(w.segments, seg)
+	}
+
+	// Keep appending to the last segment
+	if len(w.segments) > 0 {
+		active := w.segments[len(w.segments)-1]
+		file, err := os.OpenFile(active.path, os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open WAL segment: %w", err)
+		}
+		w.file = file
+	}
+
+	logger.Infof("Opened WAL in %s with %d segments", options.Dir, len(w.segments))
+	return w, nil
+}
+
+// segmentFirstSeq parses the first sequence number from a segment file name
+func segmentFirstSeq(path string) uint64 {
+	seq, _ := strconv.ParseUint(strings.TrimSuffix(filepath.Base(path), segmentExt), 10, 64)
+	return seq
+}
+
+// readSegment decodes every record in a segment, returning the offset just
+// past the last valid record
+func readSegment(path string, fn func(models.PriceUpdate)) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open WAL segment: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	header := make([]byte, recordHeaderSize)
+	var offset int64
+
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			if err == io.EOF {
+				return offset, nil
+			}
+			return offset, errCorruptRecord
+		}
+
+		length := binary.LittleEndian.Uint32(header[0:4])
+		if length > maxRecordSize {
+			return offset, errCorruptRecord
+		}
+
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return offset, errCorruptRecord
+		}
+		if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:8]) {
+			return offset, errCorruptRecord
+		}
+
+		var update models.PriceUpdate
+		if err := json.Unmarshal(payload, &update); err != nil {
+			return offset, errCorruptRecord
+		}
+
+		fn(update)
+		offset += recordHeaderSize + int64(length)
+	}
+}
+
+// Replay calls fn for every record still within the retention window, in
+// the order they were appended
+func (w *WAL) Replay(fn func(models.PriceUpdate)) error {
+	w.mutex.Lock()
+	segments := append([]*segment(nil), w.segments...)
+	w.mutex.Unlock()
+
+	cutoff := w.retentionCutoff(time.Now())
+	count := 0
+	for _, seg := range segments {
+		if _, err := readSegment(seg.path, func(update models.PriceUpdate) {
+			if update.Timestamp.Before(cutoff) {
+				return
+			}
+			fn(update)
+			count++
+		}); err != nil && !errors.Is(err, errCorruptRecord) {
+			return err
+		}
+	}
+
+	w.logger.Infof("Replayed %d updates from WAL", count)
+	return nil
+}
+
+// Append writes an update to the active segment, rotating to a new segment
+// once the active one reaches the configured size
+func (w *WAL) Append(update models.PriceUpdate) error {
+	payload, err := json.Marshal(update)
+	if err != nil {
+		return fmt.Errorf("failed to encode WAL record: %w", err)
+	}
+
+	record := make([]byte, recordHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[recordHeaderSize:], payload)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return errors.New("WAL is closed")
+	}
+
+	if w.file == nil || w.active().size >= w.options.SegmentBytes {
+		if err := w.rotate(update.Seq); err != nil {
+			return err
+		}
+	}
+
+	if _, err := w.file.Write(record); err != nil {
+		return fmt.Errorf("failed to write WAL record: %w", err)
+	}
+
+	active := w.active()
+	active.size += int64(len(record))
+	active.lastSeq = update.Seq
+	if update.Timestamp.After(active.newest) {
+		active.newest = update.Timestamp
+	}
+	w.dirty = true
+
+	if w.options.Fsync == FsyncAlways {
+		return w.syncLocked()
+	}
+	return nil
+}
+
+// active returns the segment currently appended to
+func (w *WAL) active() *segment {
+	return w.segments[len(w.segments)-1]
+}
+
+// rotate closes the active segment and starts a new one at firstSeq
+func (w *WAL) rotate(firstSeq uint64) error {
+	if w.file != nil {
+		if err := w.syncLocked(); err != nil {
+			return err
+		}
+		if err := w.file.Close(); err != nil {
+			return fmt.Errorf("failed to close WAL segment: %w", err)
+		}
+		w.file = nil
+	}
+
+	path := filepath.Join(w.options.Dir, fmt.Sprintf("%020d%s", firstSeq, segmentExt))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create WAL segment: %w", err)
+	}
+	w.file = file
+	w.segments = append(w.segments, &segment{path: path, firstSeq: firstSeq})
+
+	// Sync the new directory entry, or records synced to the segment could
+	// still be lost with it in a crash
+	if err := syncDir(w.options.Dir); err != nil {
+		return fmt.Errorf("failed to sync WAL directory: %w", err)
+	}
+	w.logger.Debugf("Rotated WAL to segment %s", path)
+	return nil
+}
+
+// Sync flushes appended records to stable storage
+func (w *WAL) Sync() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.syncLocked()
+}
+
+// syncLocked flushes the active segment if it has unsynced records
+func (w *WAL) syncLocked() error {
+	if w.file == nil || !w.dirty {
+		return nil
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync WAL segment: %w", err)
+	}
+	w.dirty = false
+	return nil
+}
+
+// retentionCutoff returns the time before which records have expired
+func (w *WAL) retentionCutoff(now time.Time) time.Time {
+	if w.options.Retention <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-w.options.Retention)
+}
+
+// EnforceRetention deletes closed segments whose newest record is older than
+// the retention window
+func (w *WAL) EnforceRetention(now time.Time) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	cutoff := w.retentionCutoff(now)
+	if cutoff.IsZero() {
+		return nil
+	}
+
+	kept := w.segments[:0]
+	for i, seg := range w.segments {
+		// The active segment is never deleted
+		if i == len(w.segments)-1 || !seg.newest.Before(cutoff) {
+			kept = append(kept, seg)
+			continue
+		}
+		if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+			w.segments = append(kept, w.segments[i:]...)
+			return fmt.Errorf("failed to remove WAL segment: %w", err)
+		}
+		w.logger.Infof("Removed expired WAL segment %s (sequences %d-%d)", seg.path, seg.firstSeq, seg.lastSeq)
+	}
+	w.segments = kept
+	return nil
+}
+
+// LastSeq returns the highest sequence number ever appended to the log,
+// including records past the retention window. The active segment is never
+// removed, so it survives however long the log went without appends
+func (w *WAL) LastSeq() uint64 {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	var seq uint64
+	for _, seg := range w.segments {
+		seq = max(seq, seg.firstSeq, seg.lastSeq)
+	}
+	return seq
+}
+
+// Segments returns the number of segment files in the log
+func (w *WAL) Segments() int {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return len(w.segments)
+}
+
+// Run periodically flushes the log under the interval fsync policy and
+// removes expired segments, closing the log when ctx is cancelled. Under the
+// other policies Run never flushes
+func (w *WAL) Run(ctx context.Context) {
+	var syncC <-chan time.Time
+	if w.options.Fsync == FsyncInterval && w.options.FsyncInterval > 0 {
+		syncTicker := time.NewTicker(w.options.FsyncInterval)
+		defer syncTicker.Stop()
+		syncC = syncTicker.C
+	}
+
+	retentionTicker := time.NewTicker(time.Minute)
+	defer retentionTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := w.Close(); err != nil {
+				w.logger.Errorf("Failed to close WAL: %v", err)
+			}
+			return
+		case <-syncC:
+			if err := w.Sync(); err != nil {
+				w.logger.Errorf("Failed to sync WAL: %v", err)
+			}
+		case now := <-retentionTicker.C:
+			if err := w.EnforceRetention(now); err != nil {
+				w.logger.Errorf("Failed to enforce WAL retention: %v", err)
+			}
+		}
+	}
+}
+
+// Close flushes and closes the active segment
+func (w *WAL) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.syncLocked()
+	if closeErr := w.file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close WAL segment: %w", closeErr)
+	}
+	w.file = nil
+	return err
+}
@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testWALOptions(t *testing.T) WALOptions {
+	return WALOptions{
+		Dir:          t.TempDir(),
+		Fsync:        FsyncAlways,
+		SegmentBytes: 1 << 20,
+		Retention:    time.Hour,
+	}
+}
+
+func replayAll(t *testing.T, wal *WAL) []models.PriceUpdate {
+	var updates []models.PriceUpdate
+	require.NoError(t, wal.Replay(func(update models.PriceUpdate) {
+		updates = append(updates, update)
+	}))
+	return updates
+}
+
+func TestParseFsyncPolicy(t *testing.T) {
+	policy, err := ParseFsyncPolicy("Always")
+	assert.NoError(t, err)
+	assert.Equal(t, FsyncAlways, policy)
+
+	_, err = ParseFsyncPolicy("sometimes")
+	assert.Error(t, err)
+}
+
+func TestWALAppendAndReplay(t *testing.T) {
+	logger := logrus.New()
+	options := testWALOptions(t)
+
+	wal, err := OpenWAL(options, logger)
+	require.NoError(t, err)
+
+	now := time.Now()
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, wal.Append(models.PriceUpdate{Seq: uint64(i), Timestamp: now, Price: float64(i), Symbol: "BTC"}))
+	}
+	require.NoError(t, wal.Close())
+
+	// Appending after close fails
+	assert.Error(t, wal.Append(models.PriceUpdate{Seq: 4, Timestamp: now, Symbol: "BTC"}))
+
+	reopened, err := OpenWAL(options, logger)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	updates := replayAll(t, reopened)
+	require.Len(t, updates, 3)
+	assert.Equal(t, uint64(1), updates[0].Seq)
+	assert.Equal(t, 3.0, updates[2].Price)
+}
+
+func TestWALSegmentRotation(t *testing.T) {
+	logger := logrus.New()
+	options := testWALOptions(t)
+	options.SegmentBytes = 1
+
+	wal, err := OpenWAL(options, logger)
+	require.NoError(t, err)
+	defer wal.Close()
+
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, wal.Append(models.PriceUpdate{Seq: uint64(i), Timestamp: time.Now(), Price: float64(i), Symbol: "BTC"}))
+	}
+
+	// Every record fills a segment, so every append rotates
+	assert.Equal(t, 5, wal.Segments())
+	assert.FileExists(t, filepath.Join(options.Dir, "00000000000000000003.wal"))
+	assert.Len(t, replayAll(t, wal), 5)
+}
+
+func TestWALRetention(t *testing.T) {
+	logger := logrus.New()
+	options := testWALOptions(t)
+	options.SegmentBytes = 1
+
+	wal, err := OpenWAL(options, logger)
+	require.NoError(t, err)
+	defer wal.Close()
+
+	now := time.Now()
+	require.NoError(t, wal.Append(models.PriceUpdate{Seq: 1, Timestamp: now.Add(-3 * time.Hour), Symbol: "BTC"}))
+	require.NoError(t, wal.Append(models.PriceUpdate{Seq: 2, Timestamp: now.Add(-2 * time.Hour), Symbol: "BTC"}))
+	require.NoError(t, wal.Append(models.PriceUpdate{Seq: 3, Timestamp: now, Symbol: "BTC"}))
+
+	// Expired records are skipped on replay even before their segment is removed
+	assert.Len(t, replayAll(t, wal), 1)
+
+	require.NoError(t, wal.EnforceRetention(now))
+	assert.Equal(t, 1, wal.Segments())
+	assert.NoFileExists(t, filepath.Join(options.Dir, "00000000000000000001.wal"))
+}
+
+func TestWALRecoversFromTornWrite(t *testing.T) {
+	logger := logrus.New()
+	options := testWALOptions(t)
+
+	wal, err := OpenWAL(options, logger)
+	require.NoError(t, err)
+	require.NoError(t, wal.Append(models.PriceUpdate{Seq: 1, Timestamp: time.Now(), Price: 100.0, Symbol: "BTC"}))
+	require.NoError(t, wal.Close())
+
+	// Simulate a crash in the middle of writing a record
+	path := filepath.Join(options.Dir, "00000000000000000001.wal")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = file.Write([]byte{42, 0, 0, 0, 1, 2})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	reopened, err := OpenWAL(options, logger)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	// The torn record is cut off and appends continue after the valid one
+	require.NoError(t, reopened.Append(models.PriceUpdate{Seq: 2, Timestamp: time.Now(), Price: 101.0, Symbol: "BTC"}))
+
+	updates := replayAll(t, reopened)
+	require.Len(t, updates, 2)
+	assert.Equal(t, 101.0, updates[1].Price)
+}
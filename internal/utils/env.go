@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // GetEnvInt retrieves an environment variable as an integer
@@ -24,3 +26,33 @@ func GetEnvString(key string, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// GetEnvStringSlice retrieves a comma-separated environment variable as a slice
+// Whitespace around items is trimmed and empty items are skipped
+// Returns defaultValue if the environment variable is not set or has no items
+func GetEnvStringSlice(key string, defaultValue []string) []string {
+	var values []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
+
+// InstanceID identifies this server process among replicas
+// Returns INSTANCE_ID if set, otherwise the hostname and process ID
+func InstanceID() string {
+	if id := os.Getenv("INSTANCE_ID"); id != "" {
+		return id
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
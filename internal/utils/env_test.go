@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"fmt"
 	"os"
 	"testing"
 
@@ -116,3 +117,40 @@ func TestGetEnvStringEdgeCases(t *testing.T) {
 	result = GetEnvString("TEST_SPACES", "default")
 	assert.Equal(t, "hello world\nwith newlines", result)
 }
+
+func TestGetEnvStringSlice(t *testing.T) {
+	// Test with comma-separated values and surrounding whitespace
+	os.Setenv("TEST_SLICE", "coindesk, binance ,coinbase")
+	defer os.Unsetenv("TEST_SLICE")
+
+	result := GetEnvStringSlice("TEST_SLICE", []string{"default"})
+	assert.Equal(t, []string{"coindesk", "binance", "coinbase"}, result)
+
+	// Test with empty items (should be skipped)
+	os.Setenv("TEST_SLICE_EMPTY_ITEMS", "a,,b,")
+	defer os.Unsetenv("TEST_SLICE_EMPTY_ITEMS")
+
+	result = GetEnvStringSlice("TEST_SLICE_EMPTY_ITEMS", []string{"default"})
+	assert.Equal(t, []string{"a", "b"}, result)
+
+	// Test with only separators (should return default)
+	os.Setenv("TEST_SLICE_SEPARATORS", " , ,")
+	defer os.Unsetenv("TEST_SLICE_SEPARATORS")
+
+	result = GetEnvStringSlice("TEST_SLICE_SEPARATORS", []string{"default"})
+	assert.Equal(t, []string{"default"}, result)
+
+	// Test with non-existent environment variable
+	result = GetEnvStringSlice("NON_EXISTENT", []string{"default"})
+	assert.Equal(t, []string{"default"}, result)
+}
+
+func TestInstanceID(t *testing.T) {
+	// Test the default, hostname and process ID
+	hostname, _ := os.Hostname()
+	assert.Equal(t, fmt.Sprintf("%s-%d", hostname, os.Getpid()), InstanceID())
+
+	// Test with an explicit ID
+	t.Setenv("INSTANCE_ID", "replica-1")
+	assert.Equal(t, "replica-1", InstanceID())
+}
@@ -0,0 +1,275 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+)
+
+// Headers sent with every webhook POST. The signature covers the timestamp
+// and body, so receivers can reject replayed deliveries
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// ErrDeadLetterNotFound is returned when redelivering an unknown dead letter
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// delivery is an event on its way to one endpoint
+type delivery struct {
+	id         string
+	endpointID string
+	event      string
+	body       []byte
+	attempt    int
+}
+
+// Sign returns the signature of a webhook body sent at timestamp (Unix
+// seconds): "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>"
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is valid for a webhook body sent at timestamp
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Run delivers queued events with the configured number of workers until ctx
+// is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < d.options.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case dl := <-d.queue:
+					d.attempt(ctx, dl)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// submit queues a delivery attempt without blocking, dead-lettering the
+// event if the queue is full. Callers must hold the lock
+func (d *Dispatcher) submit(dl *delivery) {
+	select {
+	case d.queue <- dl:
+	default:
+		d.deadLetter(dl, "delivery queue is full")
+	}
+}
+
+// attempt POSTs an event once and records the outcome, scheduling a retry
+// or dead-lettering the event on failure
+func (d *Dispatcher) attempt(ctx context.Context, dl *delivery) {
+	d.mutex.RLock()
+	state := d.endpoint(dl.endpointID)
+	var endpoint models.WebhookEndpoint
+	if state != nil {
+		endpoint = state.endpoint
+	}
+	d.mutex.RUnlock()
+
+	if state == nil {
+		d.logger.Debugf("Dropping webhook delivery %s for deleted endpoint %s", dl.id, dl.endpointID)
+		return
+	}
+
+	dl.attempt++
+	start := time.Now()
+	statusCode, err := d.post(ctx, endpoint, dl)
+
+	record := models.WebhookDelivery{
+		ID:         dl.id,
+		EndpointID: dl.endpointID,
+		Event:      dl.event,
+		Attempt:    dl.attempt,
+		StatusCode: statusCode,
+		DurationMs: time.Since(start).Milliseconds(),
+		Timestamp:  start,
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	switch {
+	case err == nil:
+		record.Status = models.DeliveryDelivered
+	case !retryable(statusCode) || dl.attempt >= d.options.MaxAttempts:
+		record.Status = models.DeliveryFailed
+		record.Error = err.Error()
+		d.deadLetter(dl, err.Error())
+	default:
+		delay := d.backoff(dl.attempt)
+		next := time.Now().Add(delay)
+		record.Status = models.DeliveryRetrying
+		record.Error = err.Error()
+		record.NextAttempt = &next
+
+		d.logger.Debugf("Retrying webhook delivery %s to %s in %s: %v", dl.id, dl.endpointID, delay, err)
+		time.AfterFunc(delay, func() {
+			d.mutex.Lock()
+			defer d.mutex.Unlock()
+			d.submit(dl)
+		})
+	}
+
+	d.log = append(d.log, record)
+	if len(d.log) > d.options.LogSize {
+		d.log = d.log[len(d.log)-d.options.LogSize:]
+	}
+}
+
+// post sends one signed delivery attempt, returning the response status code
+func (d *Dispatcher) post(ctx context.Context, endpoint models.WebhookEndpoint, dl *delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(dl.body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "bitcoin-price-streamer")
+	req.Header.Set(EventHeader, dl.event)
+	req.Header.Set(DeliveryHeader, dl.id)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(endpoint.Secret, timestamp, dl.body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to deliver webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryable reports whether a failed attempt may succeed later: network
+// errors, timeouts, rate limiting and server errors are retried, other
+// client errors are not
+func retryable(statusCode int) bool {
+	return statusCode == 0 || statusCode == http.StatusRequestTimeout ||
+		statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// backoff returns the delay before the attempt following attempt, doubling
+// from RetryBase up to RetryMax
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.options.RetryBase
+	for i := 1; i < attempt && delay < d.options.RetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, d.options.RetryMax)
+}
+
+// deadLetter moves an undeliverable event to the dead-letter queue. Callers
+// must hold the lock
+func (d *Dispatcher) deadLetter(dl *delivery, reason string) {
+	d.logger.Warnf("Dead-lettering webhook delivery %s to %s after %d attempts: %s",
+		dl.id, dl.endpointID, dl.attempt, reason)
+
+	d.deadLetters = append(d.deadLetters, models.WebhookDeadLetter{
+		ID:         dl.id,
+		EndpointID: dl.endpointID,
+		Event:      dl.event,
+		Payload:    dl.body,
+		Attempts:   dl.attempt,
+		LastError:  reason,
+		FailedAt:   time.Now(),
+	})
+	if len(d.deadLetters) > d.options.DeadLetterSize {
+		d.deadLetters = d.deadLetters[len(d.deadLetters)-d.options.DeadLetterSize:]
+	}
+}
+
+// Deliveries returns up to limit of the most recent delivery attempts,
+// optionally only to endpointID, oldest first
+func (d *Dispatcher) Deliveries(endpointID string, limit int) []models.WebhookDelivery {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return lastMatching(d.log, limit, func(record models.WebhookDelivery) bool {
+		return endpointID == "" || record.EndpointID == endpointID
+	})
+}
+
+// DeadLetters returns up to limit of the most recent dead letters,
+// optionally only for endpointID, oldest first
+func (d *Dispatcher) DeadLetters(endpointID string, limit int) []models.WebhookDeadLetter {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return lastMatching(d.deadLetters, limit, func(letter models.WebhookDeadLetter) bool {
+		return endpointID == "" || letter.EndpointID == endpointID
+	})
+}
+
+// lastMatching returns up to limit of the last items matching keep, in order
+func lastMatching[T any](items []T, limit int, keep func(T) bool) []T {
+	start := len(items)
+	for count := 0; start > 0 && (limit <= 0 || count < limit); {
+		start--
+		if keep(items[start]) {
+			count++
+		}
+	}
+
+	var matched []T
+	for _, item := range items[start:] {
+		if keep(item) {
+			matched = append(matched, item)
+		}
+	}
+	return matched
+}
+
+// Redeliver removes a dead letter and queues it for delivery with a fresh
+// set of attempts
+func (d *Dispatcher) Redeliver(id string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for i, letter := range d.deadLetters {
+		if letter.ID != id {
+			continue
+		}
+		if d.endpoint(letter.EndpointID) == nil {
+			return fmt.Errorf("webhook endpoint %s no longer exists", letter.EndpointID)
+		}
+
+		d.deadLetters = append(d.deadLetters[:i], d.deadLetters[i+1:]...)
+		d.submit(&delivery{id: letter.ID, endpointID: letter.EndpointID, event: letter.Event, body: letter.Payload})
+		d.logger.Infof("Redelivering dead-lettered webhook %s to %s", letter.ID, letter.EndpointID)
+		return nil
+	}
+	return ErrDeadLetterNotFound
+}
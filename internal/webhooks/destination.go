@@ -0,0 +1,125 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenDestination is returned for webhook URLs, and connections,
+// to an address the server must not reach on behalf of a client
+var ErrForbiddenDestination = errors.New("webhook destination is not allowed")
+
+// reservedNetworks are blocked on top of the private, loopback, link-local,
+// multicast and unspecified ranges: shared address space (which hosts some
+// cloud metadata services), "this network" and other special-purpose blocks
+var reservedNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// ParseNetworks parses a list of CIDR prefixes or single IP addresses
+func ParseNetworks(values []string) ([]netip.Prefix, error) {
+	networks := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		if addr, err := netip.ParseAddr(value); err == nil {
+			networks = append(networks, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", value, err)
+		}
+		networks = append(networks, prefix.Masked())
+	}
+	return networks, nil
+}
+
+// guard decides which addresses webhooks may be delivered to. Public
+// addresses always are; internal ones only if they fall in an allowed network
+type guard struct {
+	allowed []netip.Prefix
+}
+
+// check returns ErrForbiddenDestination if addr is internal and not allowed
+func (g guard) check(addr netip.Addr) error {
+	addr = addr.Unmap()
+	for _, network := range g.allowed {
+		if network.Contains(addr) {
+			return nil
+		}
+	}
+	if internal(addr) {
+		return fmt.Errorf("%w: %s", ErrForbiddenDestination, addr)
+	}
+	return nil
+}
+
+// internal reports whether addr is private, loopback, link-local (including
+// the 169.254.169.254 metadata address), multicast, unspecified or reserved
+func internal(addr netip.Addr) bool {
+	if addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || addr.IsUnspecified() {
+		return true
+	}
+	for _, network := range reservedNetworks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHost validates the host of an endpoint URL at registration. IP
+// literals and localhost names are checked here; other names are checked
+// against every address they resolve to when a delivery connects
+func (g guard) checkHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return g.check(netip.AddrFrom4([4]byte{127, 0, 0, 1}))
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return g.check(addr)
+	}
+	return nil
+}
+
+// control refuses connections to forbidden addresses once the dialer has
+// resolved them, so names resolving to internal addresses, DNS rebinding and
+// redirects cannot reach them either
+func (g guard) control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenDestination, host)
+	}
+	return g.check(addr)
+}
+
+// client returns the HTTP client deliveries are sent with. It connects
+// directly rather than through a proxy from the environment, so the guard
+// sees the real destination of every connection
+func (g guard) client(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   g.control,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,287 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Options configures webhook delivery
+type Options struct {
+	// Workers is the number of concurrent deliveries
+	Workers int
+	// QueueSize bounds the events waiting for delivery; events beyond it are
+	// dead-lettered
+	QueueSize int
+	// Timeout bounds each delivery attempt
+	Timeout time.Duration
+	// MaxAttempts is the number of attempts before an event is dead-lettered
+	MaxAttempts int
+	// RetryBase is the delay before the first retry, doubling with each
+	// further attempt up to RetryMax
+	RetryBase time.Duration
+	RetryMax  time.Duration
+	// LogSize and DeadLetterSize bound the delivery log and dead-letter queue
+	LogSize        int
+	DeadLetterSize int
+	// AllowedNetworks are internal networks endpoints may nevertheless be
+	// in. Private, loopback, link-local and metadata addresses are refused
+	// unless listed here
+	AllowedNetworks []netip.Prefix
+}
+
+// endpointState is a registered endpoint and the last price sent to it per
+// symbol, for its min_change_percent filter
+type endpointState struct {
+	endpoint  models.WebhookEndpoint
+	lastPrice map[string]float64
+}
+
+// Dispatcher delivers price updates and alerts to registered webhook
+// endpoints as HMAC-signed JSON POSTs, retrying failed deliveries with
+// exponential backoff and dead-lettering those that exhaust their attempts
+type Dispatcher struct {
+	options     Options
+	endpoints   []*endpointState
+	queue       chan *delivery
+	guard       guard
+	client      *http.Client
+	log         []models.WebhookDelivery
+	deadLetters []models.WebhookDeadLetter
+	nextID      uint64
+	mutex       sync.RWMutex
+	logger      *logrus.Logger
+}
+
+// New creates a webhook dispatcher. Deliveries start once Run is called
+func New(options Options, logger *logrus.Logger) (*Dispatcher, error) {
+	if options.Workers <= 0 || options.QueueSize <= 0 || options.MaxAttempts <= 0 {
+		return nil, fmt.Errorf("webhook workers, queue size and attempts must be positive")
+	}
+	if options.RetryBase <= 0 || options.RetryMax < options.RetryBase {
+		return nil, fmt.Errorf("webhook retry delays must be positive with the maximum at least the base")
+	}
+
+	guard := guard{allowed: options.AllowedNetworks}
+	return &Dispatcher{
+		options: options,
+		queue:   make(chan *delivery, options.QueueSize),
+		guard:   guard,
+		client:  guard.client(options.Timeout),
+		logger:  logger,
+	}, nil
+}
+
+// NewFromEnv creates a webhook dispatcher configured by WEBHOOK_WORKERS,
+// WEBHOOK_QUEUE_SIZE, WEBHOOK_TIMEOUT_MS, WEBHOOK_MAX_ATTEMPTS,
+// WEBHOOK_RETRY_BASE_MS, WEBHOOK_RETRY_MAX_MS, WEBHOOK_LOG_SIZE,
+// WEBHOOK_DEAD_LETTER_SIZE and WEBHOOK_ALLOWED_NETWORKS
+func NewFromEnv(logger *logrus.Logger) (*Dispatcher, error) {
+	allowed, err := ParseNetworks(utils.GetEnvStringSlice("WEBHOOK_ALLOWED_NETWORKS", nil))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_ALLOWED_NETWORKS: %w", err)
+	}
+
+	return New(Options{
+		Workers:         utils.GetEnvInt("WEBHOOK_WORKERS", 4),
+		QueueSize:       utils.GetEnvInt("WEBHOOK_QUEUE_SIZE", 1000),
+		Timeout:         time.Duration(utils.GetEnvInt("WEBHOOK_TIMEOUT_MS", 5000)) * time.Millisecond,
+		MaxAttempts:     utils.GetEnvInt("WEBHOOK_MAX_ATTEMPTS", 5),
+		RetryBase:       time.Duration(utils.GetEnvInt("WEBHOOK_RETRY_BASE_MS", 1000)) * time.Millisecond,
+		RetryMax:        time.Duration(utils.GetEnvInt("WEBHOOK_RETRY_MAX_MS", 60000)) * time.Millisecond,
+		LogSize:         utils.GetEnvInt("WEBHOOK_LOG_SIZE", 500),
+		DeadLetterSize:  utils.GetEnvInt("WEBHOOK_DEAD_LETTER_SIZE", 1000),
+		AllowedNetworks: allowed,
+	}, logger)
+}
+
+// AddEndpoint validates and registers an endpoint, generating a signing
+// secret if none is given, and returns it with its assigned ID. Endpoints
+// at internal addresses are refused with ErrForbiddenDestination
+func (d *Dispatcher) AddEndpoint(endpoint models.WebhookEndpoint) (models.WebhookEndpoint, error) {
+	target, err := url.Parse(endpoint.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return models.WebhookEndpoint{}, fmt.Errorf("url must be an absolute http or https URL")
+	}
+	if target.User != nil {
+		return models.WebhookEndpoint{}, fmt.Errorf("url must not contain credentials")
+	}
+	if err := d.guard.checkHost(target.Hostname()); err != nil {
+		return models.WebhookEndpoint{}, err
+	}
+	if len(endpoint.Events) == 0 {
+		endpoint.Events = []string{models.WebhookEventPrice, models.WebhookEventAlert}
+	}
+	for _, event := range endpoint.Events {
+		if event != models.WebhookEventPrice && event != models.WebhookEventAlert {
+			return models.WebhookEndpoint{}, fmt.Errorf("unknown webhook event: %s", event)
+		}
+	}
+	if endpoint.MinChangePercent < 0 {
+		return models.WebhookEndpoint{}, fmt.Errorf("min_change_percent must not be negative")
+	}
+	for i, symbol := range endpoint.Symbols {
+		endpoint.Symbols[i] = strings.ToUpper(strings.TrimSpace(symbol))
+	}
+	if endpoint.Secret == "" {
+		if endpoint.Secret, err = randomHex(32); err != nil {
+			return models.WebhookEndpoint{}, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.nextID++
+	endpoint.ID = "wh-" + strconv.FormatUint(d.nextID, 10)
+	endpoint.CreatedAt = time.Now()
+	d.endpoints = append(d.endpoints, &endpointState{endpoint: endpoint, lastPrice: make(map[string]float64)})
+
+	d.logger.Infof("Registered webhook %s for %v events to %s", endpoint.ID, endpoint.Events, target.Host)
+	return endpoint, nil
+}
+
+// Endpoints returns the registered endpoints in creation order, without
+// their secrets
+func (d *Dispatcher) Endpoints() []models.WebhookEndpoint {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	endpoints := make([]models.WebhookEndpoint, len(d.endpoints))
+	for i, state := range d.endpoints {
+		endpoints[i] = state.endpoint
+		endpoints[i].Secret = ""
+	}
+	return endpoints
+}
+
+// Endpoint returns the endpoint with the given ID, without its secret
+func (d *Dispatcher) Endpoint(id string) (models.WebhookEndpoint, bool) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	state := d.endpoint(id)
+	if state == nil {
+		return models.WebhookEndpoint{}, false
+	}
+	endpoint := state.endpoint
+	endpoint.Secret = ""
+	return endpoint, true
+}
+
+// endpoint looks up an endpoint. Callers must hold the lock
+func (d *Dispatcher) endpoint(id string) *endpointState {
+	for _, state := range d.endpoints {
+		if state.endpoint.ID == id {
+			return state
+		}
+	}
+	return nil
+}
+
+// DeleteEndpoint removes an endpoint, reporting whether it existed. Its
+// pending retries are dropped
+func (d *Dispatcher) DeleteEndpoint(id string) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for i, state := range d.endpoints {
+		if state.endpoint.ID == id {
+			d.endpoints = append(d.endpoints[:i], d.endpoints[i+1:]...)
+			d.logger.Infof("Deleted webhook %s", id)
+			return true
+		}
+	}
+	return false
+}
+
+// Add queues a price update for every endpoint whose filters it passes
+func (d *Dispatcher) Add(update models.PriceUpdate) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for _, state := range d.endpoints {
+		endpoint := state.endpoint
+		if !slices.Contains(endpoint.Events, models.WebhookEventPrice) || !matchesSymbol(endpoint, update.Symbol) {
+			continue
+		}
+
+		if endpoint.MinChangePercent > 0 {
+			if last, sent := state.lastPrice[update.Symbol]; sent && last != 0 &&
+				math.Abs(update.Price-last)/last*100 < endpoint.MinChangePercent {
+				continue
+			}
+		}
+		state.lastPrice[update.Symbol] = update.Price
+
+		d.enqueue(endpoint.ID, models.WebhookEventPrice, update)
+	}
+}
+
+// AddAlert queues an alert for every endpoint subscribed to alerts of its symbol
+func (d *Dispatcher) AddAlert(alert models.Alert) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for _, state := range d.endpoints {
+		endpoint := state.endpoint
+		if slices.Contains(endpoint.Events, models.WebhookEventAlert) && matchesSymbol(endpoint, alert.Symbol) {
+			d.enqueue(endpoint.ID, models.WebhookEventAlert, alert)
+		}
+	}
+}
+
+// matchesSymbol reports whether an endpoint wants events for symbol
+func matchesSymbol(endpoint models.WebhookEndpoint, symbol string) bool {
+	return len(endpoint.Symbols) == 0 || slices.Contains(endpoint.Symbols, symbol)
+}
+
+// payload is the JSON body POSTed to endpoints
+type payload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// enqueue builds the payload of an event and queues its first delivery
+// attempt. Callers must hold the lock
+func (d *Dispatcher) enqueue(endpointID, event string, data interface{}) {
+	id, err := randomHex(16)
+	if err != nil {
+		d.logger.Errorf("Failed to generate webhook delivery ID: %v", err)
+		return
+	}
+
+	body, err := json.Marshal(payload{ID: id, Event: event, CreatedAt: time.Now(), Data: data})
+	if err != nil {
+		d.logger.Errorf("Failed to marshal %s webhook payload: %v", event, err)
+		return
+	}
+
+	d.submit(&delivery{id: id, endpointID: endpointID, event: event, body: body})
+}
+
+// randomHex returns n random bytes hex-encoded
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
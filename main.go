@@ -2,16 +2,27 @@ package main
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"bitcoin-price-streamer/internal/alerts"
+	"bitcoin-price-streamer/internal/bus"
+	"bitcoin-price-streamer/internal/candles"
+	"bitcoin-price-streamer/internal/election"
+	"bitcoin-price-streamer/internal/grpcapi"
 	"bitcoin-price-streamer/internal/handlers"
+	"bitcoin-price-streamer/internal/indicators"
+	"bitcoin-price-streamer/internal/metrics"
+	"bitcoin-price-streamer/internal/provider"
+	"bitcoin-price-streamer/internal/rollup"
 	"bitcoin-price-streamer/internal/service"
 	"bitcoin-price-streamer/internal/storage"
 	"bitcoin-price-streamer/internal/utils"
+	"bitcoin-price-streamer/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -27,18 +38,109 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Initialize storage for missed updates with configurable capacity
-	storageCapacity := utils.GetEnvInt("STORAGE_CAPACITY", 1000)
-	storage := storage.NewPriceStorage(ctx, storageCapacity, logger)
+	// Initialize per-symbol storage for missed updates, in memory or on disk
+	storage, err := storage.NewFromEnv(ctx, logger)
+	if err != nil {
+		logger.Fatalf("Failed to configure storage: %v", err)
+	}
+
+	// Initialize upstream price provider(s)
+	priceProvider, err := provider.NewFromEnv(logger)
+	if err != nil {
+		logger.Fatalf("Failed to configure price providers: %v", err)
+	}
+
+	// Metrics shared by every subsystem, served at /metrics
+	metricsRegistry := metrics.NewRegistry()
 
 	// Initialize price service
-	priceService := service.NewPriceService(storage, logger)
+	priceService := service.NewPriceService(storage, priceProvider, logger)
+	priceService.SetMetrics(metricsRegistry)
+
+	// Aggregate stored and live prices into candles
+	candleBuilder := candles.NewBuilder(logger)
+	candleBuilder.Backfill(storage)
+	priceService.Observe(candleBuilder.Add)
+	go candleBuilder.Run(ctx)
+
+	// Downsample prices into long-term retention tiers
+	rollups, err := rollup.NewFromEnv(logger)
+	if err != nil {
+		logger.Fatalf("Failed to configure rollups: %v", err)
+	}
+	rollups.Backfill(storage)
+	priceService.Observe(rollups.Add)
+	go rollups.Run(ctx)
+
+	// Compute technical indicators incrementally on each stored price
+	indicatorCalculator, err := indicators.NewFromEnv(logger)
+	if err != nil {
+		logger.Fatalf("Failed to configure indicators: %v", err)
+	}
+	indicatorCalculator.Backfill(storage)
+	priceService.Observe(indicatorCalculator.Add)
+
+	// Evaluate alert rules on each stored price, after its indicators
+	alertEngine := alerts.NewEngine(indicatorCalculator, logger)
+	alertEngine.Backfill(storage)
+	priceService.Observe(alertEngine.Add)
+
+	// Push prices and alerts to registered webhook endpoints
+	webhookDispatcher, err := webhooks.NewFromEnv(logger)
+	if err != nil {
+		logger.Fatalf("Failed to configure webhooks: %v", err)
+	}
+	priceService.Observe(webhookDispatcher.Add)
+	alertEngine.Observe(webhookDispatcher.AddAlert)
+	go webhookDispatcher.Run(ctx)
+
+	// Share polled prices with the other instances and serve theirs
+	priceBus, err := bus.NewFromEnv(logger)
+	if err != nil {
+		logger.Fatalf("Failed to configure bus: %v", err)
+	}
+	priceService.SetBus(priceBus)
+	go func() {
+		if err := priceService.Follow(ctx); err != nil {
+			logger.Fatalf("Failed to follow price updates: %v", err)
+		}
+	}()
+
+	// Start price polling in background while this instance is the elected
+	// leader, unless it never polls
+	elector, err := election.NewFromEnv(logger)
+	if err != nil {
+		logger.Fatalf("Failed to configure leader election: %v", err)
+	}
+	elector.SetMetrics(metricsRegistry)
+
+	// Instances that do not poll only get prices from other processes
+	polling := utils.GetEnvString("POLL_PRICES", "true") == "true"
+	if bus.Local(priceBus) && (elector.Shared() || !polling) {
+		logger.Fatalf("The %s bus does not reach other instances, so followers would never receive prices: "+
+			"set BUS_BACKEND=redis with LEADER_ELECTION or POLL_PRICES=false", priceBus.Name())
+	}
 
-	// Start price polling in background
-	go priceService.StartPolling(ctx)
+	electionCtx, stopElection := context.WithCancel(ctx)
+	electionDone := make(chan struct{})
+	if polling {
+		go func() {
+			defer close(electionDone)
+			elector.Run(electionCtx, priceService.StartPolling)
+		}()
+	} else {
+		close(electionDone)
+		logger.Infof("Price polling disabled, serving prices from the %s bus", priceBus.Name())
+	}
 
 	// Initialize handlers
-	handlers := handlers.NewHandlers(priceService, logger)
+	handlers := handlers.NewHandlers(priceService, storage, logger)
+	handlers.SetCandles(candleBuilder)
+	handlers.SetRollups(rollups)
+	handlers.SetIndicators(indicatorCalculator)
+	handlers.SetAlerts(alertEngine)
+	handlers.SetWebhooks(webhookDispatcher)
+	handlers.SetMetrics(metricsRegistry)
 
 	// Setup Gin router
 	router := gin.Default()
@@ -63,6 +165,21 @@ func main() {
 		}
 	}()
 
+	// Serve the same prices over gRPC
+	grpcPort := utils.GetEnvString("GRPC_PORT", "9090")
+	grpcListener, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		logger.Fatalf("Failed to listen on gRPC port %s: %v", grpcPort, err)
+	}
+	grpcServer := grpcapi.NewGRPCServer(priceService, storage, logger)
+
+	go func() {
+		logger.Infof("Starting gRPC server on port %s", grpcPort)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			logger.Fatalf("Failed to start gRPC server: %v", err)
+		}
+	}()
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -79,5 +196,23 @@ func main() {
 		logger.Errorf("Server forced to shutdown: %v", err)
 	}
 
+	// Price streams never finish on their own, so close them outright
+	grpcServer.Stop()
+
+	// Release the leader lease so another instance takes over right away
+	stopElection()
+	<-electionDone
+
+	// Flush persisted history before exiting
+	if err := storage.Close(); err != nil {
+		logger.Errorf("Failed to close storage: %v", err)
+	}
+	if err := rollups.Close(); err != nil {
+		logger.Errorf("Failed to close rollups: %v", err)
+	}
+	if err := priceBus.Close(); err != nil {
+		logger.Errorf("Failed to close bus: %v", err)
+	}
+
 	logger.Info("Server exited gracefully")
 }
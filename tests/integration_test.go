@@ -1,20 +1,32 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"bitcoin-price-streamer/internal/alerts"
+	"bitcoin-price-streamer/internal/candles"
 	"bitcoin-price-streamer/internal/handlers"
+	"bitcoin-price-streamer/internal/indicators"
 	"bitcoin-price-streamer/internal/models"
+	"bitcoin-price-streamer/internal/provider"
+	"bitcoin-price-streamer/internal/rollup"
 	"bitcoin-price-streamer/internal/service"
 	"bitcoin-price-streamer/internal/storage"
+	"bitcoin-price-streamer/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -34,9 +46,17 @@ func TestIntegrationFlow(t *testing.T) {
 			}{
 				List: []models.AssetData{
 					{
-						Symbol:               "BTC",
-						Name:                 "Bitcoin",
-						PriceUSD:             50000.0,
+						Symbol:                         "BTC",
+						Name:                           "Bitcoin",
+						PriceUSD:                       50000.0,
+						PriceUSDLastUpdateTS:           time.Now().Unix(),
+						CirculatingMktCapUSD:           980000000000.0,
+						SpotMoving24HourQuoteVolumeUSD: 25000000000.0,
+					},
+					{
+						Symbol:               "ETH",
+						Name:                 "Ethereum",
+						PriceUSD:             3000.0,
 						PriceUSDLastUpdateTS: time.Now().Unix(),
 					},
 				},
@@ -53,14 +73,15 @@ func TestIntegrationFlow(t *testing.T) {
 	ctx := context.Background()
 
 	// Create storage
-	storage := storage.NewPriceStorage(ctx, 100, logger)
+	storage := storage.NewSymbolStorage(ctx, 100, logger)
 
 	// Create service with mock API
-	priceService := service.NewPriceService(storage, logger)
-	priceService.SetAPIURL(mockServer.URL)
+	coinDesk := provider.NewCoinDeskProvider(logger)
+	coinDesk.SetAPIURL(mockServer.URL)
+	priceService := service.NewPriceService(storage, coinDesk, logger)
 
 	// Create handlers
-	handlers := handlers.NewHandlers(priceService, logger)
+	handlers := handlers.NewHandlers(priceService, storage, logger)
 
 	// Set up Gin router
 	gin.SetMode(gin.TestMode)
@@ -106,7 +127,86 @@ func TestIntegrationFlow(t *testing.T) {
 		assert.Greater(t, len(updates), 0)
 	})
 
-	// Test 3: SSE endpoint
+	// Test 3: Per-symbol endpoints
+	t.Run("Symbol Parameter", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/price/current?symbol=eth", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response models.PriceUpdate
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+		assert.Equal(t, "ETH", response.Symbol)
+		assert.Equal(t, 3000.0, response.Price)
+
+		// History is kept per symbol
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/price/history?symbol=ETH", nil)
+		router.ServeHTTP(w, req)
+
+		var history struct {
+			Symbol  string               `json:"symbol"`
+			Updates []models.PriceUpdate `json:"updates"`
+		}
+		err = json.Unmarshal(w.Body.Bytes(), &history)
+		require.NoError(t, err)
+		assert.Equal(t, "ETH", history.Symbol)
+		require.NotEmpty(t, history.Updates)
+		for _, update := range history.Updates {
+			assert.Equal(t, "ETH", update.Symbol)
+		}
+
+		// Unknown symbols have no data
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/price/current?symbol=DOGE", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		// Tracked symbols are listed
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/price/symbols", nil)
+		router.ServeHTTP(w, req)
+
+		var symbols struct {
+			Symbols []string `json:"symbols"`
+		}
+		err = json.Unmarshal(w.Body.Bytes(), &symbols)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"BTC", "ETH"}, symbols.Symbols)
+	})
+
+	// Test 4: Optional market data fields
+	t.Run("Fields Selector", func(t *testing.T) {
+		// Compact payload by default
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/price/current", nil)
+		router.ServeHTTP(w, req)
+
+		var compact map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &compact))
+		assert.NotContains(t, compact, "market_cap")
+		assert.NotContains(t, compact, "volume_24h")
+
+		// Requested fields are included
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/price/current?fields=market_cap,volume_24h", nil)
+		router.ServeHTTP(w, req)
+
+		var enriched models.PriceUpdate
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &enriched))
+		assert.Equal(t, 980000000000.0, enriched.MarketCap)
+		assert.Equal(t, 25000000000.0, enriched.Volume24h)
+
+		// Unknown fields are rejected
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/price/history?fields=bogus", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	// Test 5: SSE endpoint
 	t.Run("SSE Endpoint", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/api/price/stream", nil)
@@ -137,13 +237,13 @@ func TestStaticFileServing(t *testing.T) {
 	ctx := context.Background()
 
 	// Create storage
-	storage := storage.NewPriceStorage(ctx, 10, logger)
+	storage := storage.NewSymbolStorage(ctx, 10, logger)
 
 	// Create service
-	priceService := service.NewPriceService(storage, logger)
+	priceService := service.NewPriceService(storage, provider.NewCoinDeskProvider(logger), logger)
 
 	// Create handlers
-	h := handlers.NewHandlers(priceService, logger)
+	h := handlers.NewHandlers(priceService, storage, logger)
 
 	// Set up Gin router
 	gin.SetMode(gin.TestMode)
@@ -167,7 +267,7 @@ func TestStaticFileServing(t *testing.T) {
 		defer os.Setenv("STATIC_PATH", "../static")
 
 		// Create new handlers with invalid path
-		newHandlers := handlers.NewHandlers(priceService, logger)
+		newHandlers := handlers.NewHandlers(priceService, storage, logger)
 		router := gin.New()
 		newHandlers.SetupRoutes(router)
 
@@ -185,13 +285,13 @@ func TestIntegrationWithRealData(t *testing.T) {
 	ctx := context.Background()
 
 	// Create storage
-	storage := storage.NewPriceStorage(ctx, 10, logger)
+	storage := storage.NewSymbolStorage(ctx, 10, logger)
 
 	// Create service (will use real API)
-	priceService := service.NewPriceService(storage, logger)
+	priceService := service.NewPriceService(storage, provider.NewCoinDeskProvider(logger), logger)
 
 	// Create handlers
-	handlers := handlers.NewHandlers(priceService, logger)
+	handlers := handlers.NewHandlers(priceService, storage, logger)
 
 	// Set up Gin router
 	gin.SetMode(gin.TestMode)
@@ -209,7 +309,7 @@ func TestIntegrationWithRealData(t *testing.T) {
 		var exists bool
 		for i := 0; i < 30; i++ { // Try for up to 3 seconds
 			time.Sleep(100 * time.Millisecond)
-			latest, exists = storage.GetLatest()
+			latest, exists = storage.Latest("BTC")
 			if exists {
 				break
 			}
@@ -287,14 +387,16 @@ func TestIntegrationErrorHandling(t *testing.T) {
 	ctx := context.Background()
 
 	// Create storage
-	storage := storage.NewPriceStorage(ctx, 10, logger)
+	storage := storage.NewSymbolStorage(ctx, 10, logger)
 
 	// Create service with failing API
-	priceService := service.NewPriceService(storage, logger)
-	priceService.SetAPIURL(mockServer.URL)
+	coinDesk := provider.NewCoinDeskProvider(logger)
+	coinDesk.SetAPIURL(mockServer.URL)
+	monitored := provider.NewMonitoredProvider(coinDesk, provider.NewCircuitBreaker(1, time.Minute), logger)
+	priceService := service.NewPriceService(storage, monitored, logger)
 
 	// Create handlers
-	handlers := handlers.NewHandlers(priceService, logger)
+	handlers := handlers.NewHandlers(priceService, storage, logger)
 
 	// Set up Gin router
 	gin.SetMode(gin.TestMode)
@@ -322,4 +424,1196 @@ func TestIntegrationErrorHandling(t *testing.T) {
 		require.NoError(t, err)
 		assert.Contains(t, response["error"], "No price data available")
 	})
+
+	t.Run("Provider Health", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/providers", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Providers []provider.ProviderHealth `json:"providers"`
+			Count     int                       `json:"count"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+		require.Equal(t, 1, response.Count)
+		assert.Equal(t, "coindesk", response.Providers[0].Name)
+		assert.Equal(t, provider.CircuitOpen, response.Providers[0].CircuitState)
+		assert.Contains(t, response.Providers[0].LastError, "status code: 500")
+	})
+}
+
+func TestWebSocketSymbolSubscriptions(t *testing.T) {
+	// Create mock API server listing two assets
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response models.CoinDeskResponse
+		response.Data.List = []models.AssetData{
+			{Symbol: "BTC", Name: "Bitcoin", PriceUSD: 50000.0, PriceUSDLastUpdateTS: time.Now().Unix()},
+			{Symbol: "ETH", Name: "Ethereum", PriceUSD: 3000.0, PriceUSDLastUpdateTS: time.Now().Unix()},
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer mockServer.Close()
+
+	logger := logrus.New()
+	ctx := context.Background()
+
+	storage := storage.NewSymbolStorage(ctx, 10, logger)
+	coinDesk := provider.NewCoinDeskProvider(logger)
+	coinDesk.SetAPIURL(mockServer.URL)
+	priceService := service.NewPriceService(storage, coinDesk, logger)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.NewHandlers(priceService, storage, logger).SetupRoutes(router)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws?symbols=eth"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Each polling start fetches immediately
+	poll := func() {
+		pollCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		go priceService.StartPolling(pollCtx)
+		<-pollCtx.Done()
+	}
+
+	readPrice := func() models.PriceUpdate {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		var update models.PriceUpdate
+		require.NoError(t, conn.ReadJSON(&update))
+		return update
+	}
+
+	// Wait for the subscription to be registered
+	time.Sleep(50 * time.Millisecond)
+
+	t.Run("Initial Symbols", func(t *testing.T) {
+		poll()
+		update := readPrice()
+		assert.Equal(t, "ETH", update.Symbol)
+	})
+
+	t.Run("Subscribe And Unsubscribe", func(t *testing.T) {
+		require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "subscribe", "symbols": []string{"btc"}}))
+		require.NoError(t, conn.WriteJSON(map[string]interface{}{"type": "unsubscribe", "symbols": []string{"ETH"}}))
+		time.Sleep(50 * time.Millisecond)
+
+		poll()
+		update := readPrice()
+		assert.Equal(t, "BTC", update.Symbol)
+
+		// No ETH update follows
+		conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		_, _, err := conn.ReadMessage()
+		assert.Error(t, err)
+	})
+}
+
+func TestSSELastEventIDResume(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+
+	storage := storage.NewSymbolStorage(ctx, 10, logger)
+	priceService := service.NewPriceService(storage, provider.NewCoinDeskProvider(logger), logger)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.NewHandlers(priceService, storage, logger).SetupRoutes(router)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// Updates in the same second cannot be told apart by 'since'
+	now := time.Now()
+	storage.Add(models.PriceUpdate{Timestamp: now, Price: 100.0, Symbol: "BTC", Name: "Bitcoin"})
+	storage.Add(models.PriceUpdate{Timestamp: now, Price: 101.0, Symbol: "BTC", Name: "Bitcoin"})
+	storage.Add(models.PriceUpdate{Timestamp: now, Price: 10.0, Symbol: "ETH", Name: "Ethereum"})
+	storage.Add(models.PriceUpdate{Timestamp: now, Price: 102.0, Symbol: "BTC", Name: "Bitcoin"})
+
+	reqCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(reqCtx, "GET", server.URL+"/api/price/stream", nil)
+	req.Header.Set("Last-Event-ID", "1")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// Read the replayed events
+	var ids []string
+	var prices []models.PriceUpdate
+	scanner := bufio.NewScanner(resp.Body)
+	for len(prices) < 2 && scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "id:"):
+			ids = append(ids, strings.TrimSpace(strings.TrimPrefix(line, "id:")))
+		case strings.HasPrefix(line, "data:"):
+			var update models.PriceUpdate
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &update))
+			prices = append(prices, update)
+		}
+	}
+
+	// Only BTC updates after sequence 1 are replayed, with their IDs
+	assert.Equal(t, []string{"2", "4"}, ids)
+	require.Len(t, prices, 2)
+	assert.Equal(t, 101.0, prices[0].Price)
+	assert.Equal(t, uint64(2), prices[0].Seq)
+	assert.Equal(t, 102.0, prices[1].Price)
+}
+
+func TestSSEGapAndResetEvents(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+
+	storage := storage.NewSymbolStorage(ctx, 2, logger)
+	priceService := service.NewPriceService(storage, provider.NewCoinDeskProvider(logger), logger)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.NewHandlers(priceService, storage, logger).SetupRoutes(router)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		storage.Add(models.PriceUpdate{Timestamp: now, Price: 100.0 + float64(i), Symbol: "BTC", Name: "Bitcoin"})
+	}
+
+	// readEvents returns the first n events of a stream as name/data pairs
+	readEvents := func(lastEventID string, n int) [][2]string {
+		reqCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+
+		req, _ := http.NewRequestWithContext(reqCtx, "GET", server.URL+"/api/price/stream", nil)
+		req.Header.Set("Last-Event-ID", lastEventID)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var events [][2]string
+		var event string
+		scanner := bufio.NewScanner(resp.Body)
+		for len(events) < n && scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			case strings.HasPrefix(line, "data:"):
+				events = append(events, [2]string{event, strings.TrimPrefix(line, "data:")})
+			}
+		}
+		return events
+	}
+
+	t.Run("Gap", func(t *testing.T) {
+		// Sequence 2 was evicted, so resuming after 1 is truncated
+		events := readEvents("1", 3)
+		require.Len(t, events, 3)
+		assert.Equal(t, "gap", events[0][0])
+
+		var gap models.GapNotice
+		require.NoError(t, json.Unmarshal([]byte(events[0][1]), &gap))
+		assert.Equal(t, "BTC", gap.Symbol)
+		assert.Equal(t, uint64(1), gap.RequestedSeq)
+		assert.Equal(t, uint64(2), gap.MissedThroughSeq)
+		assert.Equal(t, uint64(3), gap.ReplayFromSeq)
+
+		assert.Equal(t, "price", events[1][0])
+		assert.Equal(t, "price", events[2][0])
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		// A position beyond the last sequence cannot be resumed
+		events := readEvents("42", 1)
+		require.Len(t, events, 1)
+		assert.Equal(t, "reset", events[0][0])
+
+		var reset models.ResetNotice
+		require.NoError(t, json.Unmarshal([]byte(events[0][1]), &reset))
+		assert.Equal(t, uint64(42), reset.RequestedSeq)
+		assert.Equal(t, uint64(4), reset.LastSeq)
+	})
+}
+
+// fakeStore is a minimal storage.Store serving a fixed set of updates
+type fakeStore struct {
+	updates []models.PriceUpdate
+}
+
+func (f *fakeStore) Add(update models.PriceUpdate) models.PriceUpdate {
+	update.Seq = uint64(len(f.updates) + 1)
+	f.updates = append(f.updates, update)
+	return update
+}
+
+func (f *fakeStore) Latest(symbol string) (models.PriceUpdate, bool) {
+	for i := len(f.updates) - 1; i >= 0; i-- {
+		if f.updates[i].Symbol == symbol {
+			return f.updates[i], true
+		}
+	}
+	return models.PriceUpdate{}, false
+}
+
+func (f *fakeStore) filter(keep func(models.PriceUpdate) bool) []models.PriceUpdate {
+	var updates []models.PriceUpdate
+	for _, update := range f.updates {
+		if keep(update) {
+			updates = append(updates, update)
+		}
+	}
+	return updates
+}
+
+func (f *fakeStore) Since(symbol string, since time.Time) []models.PriceUpdate {
+	return f.filter(func(u models.PriceUpdate) bool { return u.Symbol == symbol && u.Timestamp.After(since) })
+}
+
+func (f *fakeStore) AfterSeq(symbol string, seq uint64) []models.PriceUpdate {
+	return f.filter(func(u models.PriceUpdate) bool { return u.Symbol == symbol && u.Seq > seq })
+}
+
+func (f *fakeStore) Range(symbol string, from, to time.Time) []models.PriceUpdate {
+	return f.filter(func(u models.PriceUpdate) bool {
+		return u.Symbol == symbol && !u.Timestamp.Before(from) && u.Timestamp.Before(to)
+	})
+}
+
+func (f *fakeStore) Query(symbol string, q storage.Query) storage.Page {
+	return storage.Page{Updates: f.Since(symbol, q.Since)}
+}
+
+func (f *fakeStore) Iterate(symbol string, fn func(models.PriceUpdate) bool) {
+	for _, update := range f.Since(symbol, time.Time{}) {
+		if !fn(update) {
+			return
+		}
+	}
+}
+
+func (f *fakeStore) Symbols() []string { return []string{"BTC"} }
+
+func (f *fakeStore) LastSeq() uint64 { return uint64(len(f.updates)) }
+
+func (f *fakeStore) Close() error { return nil }
+
+func TestHandlersWithFakeStore(t *testing.T) {
+	logger := logrus.New()
+
+	store := &fakeStore{}
+	store.Add(models.PriceUpdate{Timestamp: time.Now(), Price: 100.0, Symbol: "BTC", Name: "Bitcoin"})
+	store.Add(models.PriceUpdate{Timestamp: time.Now(), Price: 101.0, Symbol: "BTC", Name: "Bitcoin"})
+
+	priceService := service.NewPriceService(store, provider.NewCoinDeskProvider(logger), logger)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.NewHandlers(priceService, store, logger).SetupRoutes(router)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/price/current", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var current models.PriceUpdate
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &current))
+	assert.Equal(t, 101.0, current.Price)
+	assert.Equal(t, uint64(2), current.Seq)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/price/history", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var history map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	assert.Equal(t, float64(2), history["count"])
+}
+
+func TestPriceHistoryPagination(t *testing.T) {
+	logger := logrus.New()
+
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	priceService := service.NewPriceService(storage, provider.NewCoinDeskProvider(logger), logger)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.NewHandlers(priceService, storage, logger).SetupRoutes(router)
+
+	baseTime := time.Unix(1700000000, 0)
+	for i := 0; i < 10; i++ {
+		storage.Add(models.PriceUpdate{Timestamp: baseTime.Add(time.Duration(i) * time.Second), Price: float64(i), Symbol: "BTC"})
+	}
+
+	type historyResponse struct {
+		Updates    []models.PriceUpdate `json:"updates"`
+		Count      int                  `json:"count"`
+		NextCursor string               `json:"next_cursor"`
+	}
+	get := func(query string) (int, historyResponse) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/price/history?"+query, nil)
+		router.ServeHTTP(w, req)
+
+		var response historyResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+	prices := func(response historyResponse) []float64 {
+		var result []float64
+		for _, update := range response.Updates {
+			result = append(result, update.Price)
+		}
+		return result
+	}
+
+	t.Run("Latest Page By Default", func(t *testing.T) {
+		code, response := get("limit=3")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []float64{7, 8, 9}, prices(response))
+		require.NotEmpty(t, response.NextCursor)
+
+		// The cursor pages back through older updates
+		_, response = get("limit=3&cursor=" + response.NextCursor)
+		assert.Equal(t, []float64{4, 5, 6}, prices(response))
+	})
+
+	t.Run("Ascending Pages", func(t *testing.T) {
+		since := strconv.FormatInt(baseTime.Add(1*time.Second).Unix(), 10)
+		until := strconv.FormatInt(baseTime.Add(6*time.Second).Unix(), 10)
+
+		_, response := get("order=asc&limit=3&since=" + since + "&until=" + until)
+		assert.Equal(t, []float64{2, 3, 4}, prices(response))
+
+		_, response = get("order=asc&limit=3&since=" + since + "&until=" + until + "&cursor=" + response.NextCursor)
+		assert.Equal(t, []float64{5, 6}, prices(response))
+		assert.Empty(t, response.NextCursor)
+	})
+
+	t.Run("Descending Pages", func(t *testing.T) {
+		_, response := get("order=desc&limit=4")
+		assert.Equal(t, []float64{9, 8, 7, 6}, prices(response))
+
+		_, response = get("order=desc&limit=4&cursor=" + response.NextCursor)
+		assert.Equal(t, []float64{5, 4, 3, 2}, prices(response))
+	})
+
+	t.Run("Invalid Parameters", func(t *testing.T) {
+		code, _ := get("order=sideways")
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = get("cursor=bogus")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestCandleEndpoints(t *testing.T) {
+	logger := logrus.New()
+
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	priceService := service.NewPriceService(storage, provider.NewCoinDeskProvider(logger), logger)
+	builder := candles.NewBuilder(logger)
+	priceService.Observe(builder.Add)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	h := handlers.NewHandlers(priceService, storage, logger)
+	h.SetCandles(builder)
+	h.SetupRoutes(router)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	base := time.Now().UTC().Truncate(time.Minute).Add(-2 * time.Minute)
+	for i, price := range []float64{100, 110, 90, 105, 107} {
+		builder.Add(models.PriceUpdate{Timestamp: base.Add(time.Duration(i*20) * time.Second), Price: price, Symbol: "BTC"})
+	}
+
+	t.Run("History", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/api/candles?symbol=btc&interval=1m&from=" + strconv.FormatInt(base.Unix(), 10))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response struct {
+			Symbol   string          `json:"symbol"`
+			Interval string          `json:"interval"`
+			Candles  []models.Candle `json:"candles"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		assert.Equal(t, "BTC", response.Symbol)
+		assert.Equal(t, "1m", response.Interval)
+		require.Len(t, response.Candles, 2)
+		assert.True(t, response.Candles[0].Closed)
+		assert.Equal(t, 100.0, response.Candles[0].Open)
+		assert.Equal(t, 110.0, response.Candles[0].High)
+		assert.Equal(t, 90.0, response.Candles[0].Low)
+		assert.Equal(t, 90.0, response.Candles[0].Close)
+		assert.Equal(t, 107.0, response.Candles[1].Close)
+	})
+
+	t.Run("Invalid Interval", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/api/candles?interval=7m")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Live Stream", func(t *testing.T) {
+		reqCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		req, _ := http.NewRequestWithContext(reqCtx, "GET", server.URL+"/api/candles/stream?symbol=BTC&interval=1h", nil)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		// The stream starts with the bar in progress
+		scanner := bufio.NewScanner(resp.Body)
+		var candle models.Candle
+		for scanner.Scan() {
+			if line := scanner.Text(); strings.HasPrefix(line, "data:") {
+				require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &candle))
+				break
+			}
+		}
+		assert.Equal(t, "1h", candle.Interval)
+		assert.False(t, candle.Closed)
+		assert.Equal(t, 107.0, candle.Close)
+	})
+}
+
+func TestPriceHistoryResolution(t *testing.T) {
+	logger := logrus.New()
+
+	storage := storage.NewSymbolStorage(context.Background(), 3, logger)
+	priceService := service.NewPriceService(storage, provider.NewCoinDeskProvider(logger), logger)
+	rollups, err := rollup.New(rollup.Options{Tiers: []rollup.Tier{
+		{Name: "1m", Step: time.Minute, Retention: time.Hour},
+		{Name: "1h", Step: time.Hour, Retention: 24 * time.Hour},
+	}}, logger)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	h := handlers.NewHandlers(priceService, storage, logger)
+	h.SetRollups(rollups)
+	h.SetupRoutes(router)
+
+	// Ten ticks a minute apart, of which the raw store keeps the last three
+	base := time.Now().Add(-10 * time.Minute).Truncate(time.Minute)
+	for i := 0; i < 10; i++ {
+		stored := storage.Add(models.PriceUpdate{Timestamp: base.Add(time.Duration(i) * time.Minute), Price: float64(i), Symbol: "BTC"})
+		rollups.Add(stored)
+	}
+
+	get := func(query string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/price/history?"+query, nil)
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+	unix := func(t time.Time) string { return strconv.FormatInt(t.Unix(), 10) }
+
+	t.Run("Raw By Default", func(t *testing.T) {
+		_, response := get("since=" + unix(base))
+		assert.Equal(t, "raw", response["resolution"])
+		assert.Equal(t, float64(3), response["count"])
+		assert.NotNil(t, response["updates"])
+		assert.Nil(t, response["candles"])
+	})
+
+	t.Run("Raw When Covered", func(t *testing.T) {
+		_, response := get("resolution=auto&since=" + unix(base.Add(7*time.Minute)))
+		assert.Equal(t, "raw", response["resolution"])
+		assert.Equal(t, float64(2), response["count"])
+
+		_, response = get("resolution=auto")
+		assert.Equal(t, "raw", response["resolution"])
+		assert.Equal(t, float64(3), response["count"])
+	})
+
+	t.Run("Rollups Beyond Raw", func(t *testing.T) {
+		_, response := get("resolution=auto&since=" + unix(base))
+		assert.Equal(t, "1m", response["resolution"])
+		assert.Equal(t, float64(10), response["count"])
+		assert.Nil(t, response["updates"])
+
+		candles := response["candles"].([]interface{})
+		assert.Equal(t, 0.0, candles[0].(map[string]interface{})["open"])
+
+		// Older than the 1m retention falls back to hourly bars
+		_, response = get("resolution=auto&since=" + unix(time.Now().Add(-2*time.Hour)))
+		assert.Equal(t, "1h", response["resolution"])
+	})
+
+	t.Run("Explicit Resolution", func(t *testing.T) {
+		_, response := get("resolution=raw&since=" + unix(base))
+		assert.Equal(t, "raw", response["resolution"])
+		assert.Equal(t, float64(3), response["count"])
+
+		_, response = get("resolution=1m&order=desc&limit=2")
+		assert.Equal(t, "1m", response["resolution"])
+		candles := response["candles"].([]interface{})
+		require.Len(t, candles, 2)
+		assert.Equal(t, 9.0, candles[0].(map[string]interface{})["close"])
+
+		code, _ := get("resolution=1s")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestIndicatorEndpoints(t *testing.T) {
+	logger := logrus.New()
+
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	priceService := service.NewPriceService(storage, provider.NewCoinDeskProvider(logger), logger)
+
+	options := indicators.DefaultOptions()
+	options.SMAPeriod = 3
+	calculator, err := indicators.New(options, logger)
+	require.NoError(t, err)
+
+	now := time.Now()
+	for i, price := range []float64{100, 102, 101, 104, 103} {
+		storage.Add(models.PriceUpdate{Timestamp: now.Add(time.Duration(i) * time.Second), Price: price, Symbol: "BTC", Name: "Bitcoin"})
+	}
+	calculator.Backfill(storage)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	h := handlers.NewHandlers(priceService, storage, logger)
+	h.SetIndicators(calculator)
+	h.SetupRoutes(router)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	t.Run("Latest", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/api/indicators?symbol=btc")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var latest models.Indicators
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&latest))
+		assert.Equal(t, uint64(5), latest.Seq)
+		assert.Equal(t, 103.0, latest.Price)
+		require.NotNil(t, latest.SMA)
+		assert.InDelta(t, (101.0+104+103)/3, *latest.SMA, 1e-9)
+		assert.Nil(t, latest.RSI, "RSI is still warming up")
+	})
+
+	t.Run("Unknown Symbol", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/api/indicators?symbol=ETH")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("History", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/api/indicators/history?symbol=BTC&limit=2")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var response struct {
+			Indicators []models.Indicators `json:"indicators"`
+			Count      int                 `json:"count"`
+			Periods    map[string]float64  `json:"periods"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		assert.Equal(t, 2, response.Count)
+		assert.Equal(t, uint64(4), response.Indicators[0].Seq)
+		assert.Equal(t, 3.0, response.Periods["sma"])
+	})
+
+	t.Run("SSE Events", func(t *testing.T) {
+		reqCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		req, _ := http.NewRequestWithContext(reqCtx, "GET", server.URL+"/api/price/stream?indicators=true", nil)
+		req.Header.Set("Last-Event-ID", "3")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		// Each replayed price is followed by its indicators
+		var events []string
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() && len(events) < 4 {
+			if line := scanner.Text(); strings.HasPrefix(line, "event:") {
+				events = append(events, strings.TrimSpace(strings.TrimPrefix(line, "event:")))
+			}
+		}
+		assert.Equal(t, []string{"price", "indicators", "price", "indicators"}, events)
+	})
+}
+
+func TestAlertEndpoints(t *testing.T) {
+	logger := logrus.New()
+
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	priceService := service.NewPriceService(storage, provider.NewCoinDeskProvider(logger), logger)
+	engine := alerts.NewEngine(nil, logger)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	h := handlers.NewHandlers(priceService, storage, logger)
+	h.SetAlerts(engine)
+	h.SetupRoutes(router)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	post := func(body string) (int, models.AlertRule) {
+		resp, err := http.Post(server.URL+"/api/alerts/rules", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var rule models.AlertRule
+		json.NewDecoder(resp.Body).Decode(&rule)
+		return resp.StatusCode, rule
+	}
+
+	code, rule := post(`{"symbol": "btc", "type": "price_cross", "direction": "above", "threshold": 100}`)
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, "BTC", rule.Symbol)
+	assert.NotEmpty(t, rule.ID)
+
+	t.Run("Invalid Rule", func(t *testing.T) {
+		code, _ := post(`{"symbol": "BTC", "type": "price_cross", "threshold": 100}`)
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = post(`not json`)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("Get Rule", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/api/alerts/rules/" + rule.ID)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = http.Get(server.URL + "/api/alerts/rules/rule-999")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("SSE Alert Event", func(t *testing.T) {
+		reqCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		req, _ := http.NewRequestWithContext(reqCtx, "GET", server.URL+"/api/price/stream?symbol=BTC&alerts=true", nil)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		// The response arrives once the stream has subscribed to alerts
+		now := time.Now()
+		engine.Add(models.PriceUpdate{Seq: 1, Timestamp: now, Price: 99, Symbol: "BTC"})
+		engine.Add(models.PriceUpdate{Seq: 2, Timestamp: now.Add(time.Second), Price: 101, Symbol: "BTC"})
+
+		scanner := bufio.NewScanner(resp.Body)
+		var event string
+		var alert models.Alert
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.HasPrefix(line, "event:") {
+				event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			}
+			if strings.HasPrefix(line, "data:") && event == "alert" {
+				require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &alert))
+				break
+			}
+		}
+		assert.Equal(t, rule.ID, alert.RuleID)
+		assert.Equal(t, 101.0, alert.Price)
+	})
+
+	t.Run("Recent Alerts And Delete", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/api/alerts?symbol=btc")
+		require.NoError(t, err)
+		var response struct {
+			Alerts []models.Alert `json:"alerts"`
+			Count  int            `json:"count"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		resp.Body.Close()
+		assert.Equal(t, 1, response.Count)
+
+		req, _ := http.NewRequest(http.MethodDelete, server.URL+"/api/alerts/rules/"+rule.ID, nil)
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestWebhookEndpoints(t *testing.T) {
+	logger := logrus.New()
+
+	storage := storage.NewSymbolStorage(context.Background(), 100, logger)
+	priceService := service.NewPriceService(storage, provider.NewCoinDeskProvider(logger), logger)
+
+	dispatcher, err := webhooks.New(webhooks.Options{
+		Workers:         1,
+		QueueSize:       10,
+		Timeout:         time.Second,
+		MaxAttempts:     1,
+		RetryBase:       time.Millisecond,
+		RetryMax:        time.Millisecond,
+		LogSize:         10,
+		DeadLetterSize:  10,
+		AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+	}, logger)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dispatcher.Run(ctx)
+	priceService.Observe(dispatcher.Add)
+
+	// The receiver accepts only correctly signed requests
+	var secret string
+	received := make(chan models.PriceUpdate, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(webhooks.TimestampHeader), 10, 64)
+		if !webhooks.Verify(secret, timestamp, body, r.Header.Get(webhooks.SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var payload struct {
+			Data models.PriceUpdate `json:"data"`
+		}
+		json.Unmarshal(body, &payload)
+		received <- payload.Data
+	}))
+	defer receiver.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	h := handlers.NewHandlers(priceService, storage, logger)
+	h.SetWebhooks(dispatcher)
+	h.SetupRoutes(router)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/webhooks", "application/json",
+		strings.NewReader(`{"url": "`+receiver.URL+`", "events": ["price"], "symbols": ["BTC"]}`))
+	require.NoError(t, err)
+	var endpoint models.WebhookEndpoint
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&endpoint))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.NotEmpty(t, endpoint.Secret)
+	secret = endpoint.Secret
+
+	t.Run("Invalid Webhook", func(t *testing.T) {
+		resp, err := http.Post(server.URL+"/api/webhooks", "application/json", strings.NewReader(`{"url": "not a url"}`))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Delivery And Log", func(t *testing.T) {
+		dispatcher.Add(models.PriceUpdate{Seq: 1, Timestamp: time.Now(), Price: 42000, Symbol: "BTC"})
+
+		select {
+		case update := <-received:
+			assert.Equal(t, 42000.0, update.Price)
+		case <-time.After(2 * time.Second):
+			t.Fatal("webhook was not delivered")
+		}
+
+		require.Eventually(t, func() bool {
+			resp, err := http.Get(server.URL + "/api/webhooks/deliveries?endpoint=" + endpoint.ID)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			var response struct {
+				Deliveries []models.WebhookDelivery `json:"deliveries"`
+			}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+			return len(response.Deliveries) == 1 && response.Deliveries[0].Status == models.DeliveryDelivered
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Listing Hides Secret", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/api/webhooks/" + endpoint.ID)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var listed models.WebhookEndpoint
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+		assert.Equal(t, endpoint.URL, listed.URL)
+		assert.Empty(t, listed.Secret)
+	})
+
+	t.Run("Dead Letters", func(t *testing.T) {
+		// A wrong secret makes the receiver reject the delivery
+		secret = "wrong"
+		dispatcher.Add(models.PriceUpdate{Seq: 2, Timestamp: time.Now(), Price: 43000, Symbol: "BTC"})
+
+		var response struct {
+			DeadLetters []models.WebhookDeadLetter `json:"dead_letters"`
+		}
+		require.Eventually(t, func() bool {
+			resp, err := http.Get(server.URL + "/api/webhooks/dead-letters")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+			return len(response.DeadLetters) == 1
+		}, 2*time.Second, 10*time.Millisecond)
+
+		secret = endpoint.Secret
+		resp, err := http.Post(server.URL+"/api/webhooks/dead-letters/"+response.DeadLetters[0].ID+"/redeliver", "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+		select {
+		case update := <-received:
+			assert.Equal(t, 43000.0, update.Price)
+		case <-time.After(2 * time.Second):
+			t.Fatal("dead letter was not redelivered")
+		}
+
+		resp, err = http.Post(server.URL+"/api/webhooks/dead-letters/missing/redeliver", "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, server.URL+"/api/webhooks/"+endpoint.ID, nil)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+}
+
+func TestWebSocketCommands(t *testing.T) {
+	// Create mock API server listing two assets
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response models.CoinDeskResponse
+		response.Data.List = []models.AssetData{
+			{Symbol: "BTC", Name: "Bitcoin", PriceUSD: 50000.0, PriceUSDLastUpdateTS: time.Now().Unix()},
+			{Symbol: "ETH", Name: "Ethereum", PriceUSD: 3000.0, PriceUSDLastUpdateTS: time.Now().Unix()},
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer mockServer.Close()
+
+	logger := logrus.New()
+	ctx := context.Background()
+
+	storage := storage.NewSymbolStorage(ctx, 10, logger)
+	coinDesk := provider.NewCoinDeskProvider(logger)
+	coinDesk.SetAPIURL(mockServer.URL)
+	priceService := service.NewPriceService(storage, coinDesk, logger)
+
+	start := time.Unix(1700000000, 0)
+	for i := range 3 {
+		storage.Add(models.PriceUpdate{Timestamp: start.Add(time.Duration(i) * time.Second), Price: float64(100 + i), Symbol: "BTC", Name: "Bitcoin"})
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.NewHandlers(priceService, storage, logger).SetupRoutes(router)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws?symbol=btc"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	type reply struct {
+		Type    string          `json:"type"`
+		V       int             `json:"v"`
+		ID      string          `json:"id"`
+		Command string          `json:"command"`
+		Data    json.RawMessage `json:"data"`
+		Error   *struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	send := func(command map[string]interface{}) reply {
+		require.NoError(t, conn.WriteJSON(command))
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		var r reply
+		require.NoError(t, conn.ReadJSON(&r))
+		return r
+	}
+
+	poll := func() {
+		pollCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		go priceService.StartPolling(pollCtx)
+		<-pollCtx.Done()
+	}
+
+	t.Run("Ping", func(t *testing.T) {
+		r := send(map[string]interface{}{"v": 1, "id": "1", "type": "ping"})
+		assert.Equal(t, "response", r.Type)
+		assert.Equal(t, 1, r.V)
+		assert.Equal(t, "1", r.ID)
+		assert.Equal(t, "ping", r.Command)
+	})
+
+	t.Run("Subscribe", func(t *testing.T) {
+		r := send(map[string]interface{}{"v": 1, "id": "2", "type": "subscribe", "symbols": []string{"eth"}})
+		assert.Equal(t, "response", r.Type)
+		assert.JSONEq(t, `{"symbols": ["BTC", "ETH"]}`, string(r.Data))
+
+		r = send(map[string]interface{}{"v": 1, "id": "3", "type": "unsubscribe"})
+		assert.Equal(t, "error", r.Type)
+		assert.Equal(t, "3", r.ID)
+		assert.Equal(t, "invalid_argument", r.Error.Code)
+	})
+
+	t.Run("Snapshot", func(t *testing.T) {
+		r := send(map[string]interface{}{"v": 1, "id": "4", "type": "snapshot"})
+		require.Equal(t, "response", r.Type)
+
+		var snapshot struct {
+			Prices []models.PriceUpdate `json:"prices"`
+		}
+		require.NoError(t, json.Unmarshal(r.Data, &snapshot))
+		require.Len(t, snapshot.Prices, 1)
+		assert.Equal(t, 102.0, snapshot.Prices[0].Price)
+	})
+
+	t.Run("History", func(t *testing.T) {
+		r := send(map[string]interface{}{"v": 1, "id": "5", "type": "history", "symbol": "btc", "order": "asc", "limit": 2})
+		require.Equal(t, "response", r.Type)
+
+		var history struct {
+			Updates    []models.PriceUpdate `json:"updates"`
+			NextCursor string               `json:"next_cursor"`
+		}
+		require.NoError(t, json.Unmarshal(r.Data, &history))
+		require.Len(t, history.Updates, 2)
+		assert.Equal(t, 100.0, history.Updates[0].Price)
+		require.NotEmpty(t, history.NextCursor)
+
+		r = send(map[string]interface{}{"v": 1, "id": "6", "type": "history", "cursor": history.NextCursor})
+		require.Equal(t, "response", r.Type)
+		history.Updates = nil
+		require.NoError(t, json.Unmarshal(r.Data, &history))
+		require.Len(t, history.Updates, 1)
+		assert.Equal(t, 102.0, history.Updates[0].Price)
+
+		r = send(map[string]interface{}{"v": 1, "id": "7", "type": "history", "order": "sideways"})
+		assert.Equal(t, "invalid_argument", r.Error.Code)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		r := send(map[string]interface{}{"v": 2, "id": "8", "type": "ping"})
+		assert.Equal(t, "unsupported_version", r.Error.Code)
+
+		r = send(map[string]interface{}{"v": 1, "id": "9", "type": "teleport"})
+		assert.Equal(t, "unknown_command", r.Error.Code)
+		assert.Equal(t, "teleport", r.Command)
+
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("not json")))
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		var invalid reply
+		require.NoError(t, conn.ReadJSON(&invalid))
+		assert.Equal(t, "invalid_message", invalid.Error.Code)
+
+		r = send(map[string]interface{}{"v": 1, "id": "10", "type": "set_throttle", "interval": "soon"})
+		assert.Equal(t, "invalid_argument", r.Error.Code)
+	})
+
+	t.Run("Throttle", func(t *testing.T) {
+		r := send(map[string]interface{}{"v": 1, "id": "11", "type": "set_throttle", "interval": "1h"})
+		require.Equal(t, "response", r.Type)
+		assert.JSONEq(t, `{"interval": "1h0m0s"}`, string(r.Data))
+
+		// The first update of each symbol is sent right away
+		poll()
+		seen := map[string]bool{}
+		for range 2 {
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			var update models.PriceUpdate
+			require.NoError(t, conn.ReadJSON(&update))
+			seen[update.Symbol] = true
+		}
+		assert.Equal(t, map[string]bool{"BTC": true, "ETH": true}, seen)
+
+		// Later ones are held back within the interval, and released in
+		// sequence order when the throttle is lifted
+		poll()
+		r = send(map[string]interface{}{"v": 1, "id": "12", "type": "set_throttle", "interval": "0"})
+		require.Equal(t, "response", r.Type)
+
+		var released []models.PriceUpdate
+		for range 2 {
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			var update models.PriceUpdate
+			require.NoError(t, conn.ReadJSON(&update))
+			released = append(released, update)
+		}
+		assert.Less(t, released[0].Seq, released[1].Seq)
+		assert.Greater(t, released[0].Seq, uint64(5))
+	})
+}
+
+func TestWebSocketHeartbeats(t *testing.T) {
+	t.Setenv("WS_PING_INTERVAL_MS", "50")
+	t.Setenv("WS_PONG_TIMEOUT_MS", "200")
+	t.Setenv("WS_MAX_MESSAGE_SIZE", "256")
+
+	logger := logrus.New()
+	ctx := context.Background()
+
+	storage := storage.NewSymbolStorage(ctx, 10, logger)
+	priceService := service.NewPriceService(storage, provider.NewCoinDeskProvider(logger), logger)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.NewHandlers(priceService, storage, logger).SetupRoutes(router)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws"
+
+	metricsBody := func() string {
+		resp, err := http.Get(server.URL + "/metrics")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	t.Run("Live Peer Is Kept", func(t *testing.T) {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		// Reading answers pings automatically, keeping the connection alive
+		pings := make(chan struct{}, 100)
+		conn.SetPingHandler(func(data string) error {
+			pings <- struct{}{}
+			return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		})
+		conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+		_, _, err = conn.ReadMessage()
+		require.Error(t, err)
+
+		assert.Greater(t, len(pings), 3)
+		assert.Contains(t, metricsBody(), "websocket_connections 1\n")
+	})
+
+	t.Run("Silent Peer Is Reaped", func(t *testing.T) {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		// Never reading means pings go unanswered
+		assert.Eventually(t, func() bool {
+			return strings.Contains(metricsBody(), `websocket_disconnects_total{reason="pong_timeout"} 1`)
+		}, 2*time.Second, 20*time.Millisecond)
+	})
+
+	t.Run("Oversized Message", func(t *testing.T) {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("x", 1024))))
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		for {
+			if _, _, err = conn.ReadMessage(); err != nil {
+				break
+			}
+		}
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseMessageTooBig), "unexpected error: %v", err)
+
+		assert.Eventually(t, func() bool {
+			return strings.Contains(metricsBody(), `websocket_disconnects_total{reason="message_too_large"} 1`)
+		}, time.Second, 20*time.Millisecond)
+	})
+}
+
+func TestClientsEndpoint(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+
+	storage := storage.NewSymbolStorage(ctx, 10, logger)
+	priceService := service.NewPriceService(storage, provider.NewCoinDeskProvider(logger), logger)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.NewHandlers(priceService, storage, logger).SetupRoutes(router)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/price/stream?slow_consumer=ignore")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/api/price/stream?throttle=often")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws?symbols=eth&slow_consumer=conflate&throttle=2s"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var clients struct {
+		Clients []service.ClientStats `json:"clients"`
+		Count   int                   `json:"count"`
+	}
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(server.URL + "/api/clients")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&clients))
+		return clients.Count == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, service.PolicyConflate, clients.Clients[0].Policy)
+	assert.Equal(t, "2s", clients.Clients[0].Throttle)
+	assert.Equal(t, []string{"ETH"}, clients.Clients[0].Symbols)
+	assert.Equal(t, uint64(0), clients.Clients[0].Dropped)
 }